@@ -0,0 +1,42 @@
+// Command bookshelf-migrate links bookmarks stored with only a comma separated folder path to the
+// folders they are in, so that they can be found by the server, and then does the same for the
+// bookmarks in the trash so that they can be restored. It only needs to be run once, but running it
+// again does nothing.
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/conalli/bookshelf-backend/pkg/db/mongodb"
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("Couldn't make a new logger, %v", err)
+	}
+	defer logger.Sync()
+	if os.Getenv("LOCAL") != "production" {
+		if err := godotenv.Load(); err != nil {
+			log.Fatal("Could not load .env file")
+		}
+	}
+	sugar := logger.Sugar()
+	ctx := context.Background()
+	db := mongodb.New(ctx, sugar)
+	defer db.Disconnect(ctx)
+	numMigrated, err := db.MigrateBookmarkParents(ctx)
+	if err != nil {
+		log.Fatalf("Could not migrate bookmarks: %v", err)
+	}
+	log.Printf("Migrated %d bookmarks", numMigrated)
+	numMigrated, err = db.MigrateTrashParents(ctx)
+	if err != nil {
+		log.Fatalf("Could not migrate trash: %v", err)
+	}
+	log.Printf("Migrated %d deleted bookmarks", numMigrated)
+}
@@ -3,9 +3,10 @@ package testutils
 import (
 	"context"
 	"fmt"
-	"log"
-	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/conalli/bookshelf-backend/pkg/apierr"
 	"github.com/conalli/bookshelf-backend/pkg/http/request"
@@ -18,6 +19,13 @@ import (
 type Testdb struct {
 	Users     map[string]accounts.User
 	Bookmarks []bookmarks.Bookmark
+	Trash     []bookmarks.Bookmark
+	Snapshots []bookmarks.Snapshot
+	Shares    []bookmarks.Share
+	// Staged holds the bookmarks staged by replacing imports, by import ID.
+	Staged map[string][]bookmarks.Bookmark
+	// mu guards bookmarks updated in the background by link checks, enrichment and imports.
+	mu sync.Mutex
 }
 
 // NewDB returns a new Testdb.
@@ -48,12 +56,17 @@ func (t *Testdb) AddDefaultUsers() *Testdb {
 			IsFolder: true,
 		},
 		{
-			ID:       "c55fdaace3388c2189875fc5",
-			APIKey:   "bd1eb780-0124-11ed-b939-0242ac120002",
-			Name:     "bbc",
-			Path:     ",News,",
-			URL:      "bbc.co.uk",
-			IsFolder: false,
+			ID:         "c55fdaace3388c2189875fc5",
+			APIKey:     "bd1eb780-0124-11ed-b939-0242ac120002",
+			Name:       "bbc",
+			ParentID:   "newsfolderid",
+			Ancestors:  []string{"newsfolderid"},
+			Path:       ",News,",
+			URL:        "bbc.co.uk",
+			IsFolder:   false,
+			CreatedAt:  time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC),
+			ModifiedAt: time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC),
+			IconURI:    "https://www.bbc.co.uk/favicon.ico",
 		},
 	}
 	return t
@@ -158,6 +171,8 @@ func (t *Testdb) DeleteCmd(ctx context.Context, body request.DeleteCmd, APIKey s
 
 // GetAllBookmarks gets all bookmarks from the test db.
 func (t *Testdb) GetAllBookmarks(ctx context.Context, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 	books := make([]bookmarks.Bookmark, 0)
 	for _, v := range t.Bookmarks {
 		if v.APIKey == APIKey {
@@ -167,65 +182,665 @@ func (t *Testdb) GetAllBookmarks(ctx context.Context, APIKey string) ([]bookmark
 	return books, nil
 }
 
-// GetBookmarksFolder gets all bookmarks from the test db.
-func (t *Testdb) GetBookmarksFolder(ctx context.Context, path, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
-	folder := []bookmarks.Bookmark{}
+// MigrateBookmarkParents links bookmarks added to the test db with only a path to the folders they
+// are in, as the migration does for bookmarks stored in MongoDB.
+func (t *Testdb) MigrateBookmarkParents(ctx context.Context) (int, error) {
+	byUser := make(map[string][]bookmarks.Bookmark)
+	for _, b := range t.Bookmarks {
+		byUser[b.APIKey] = append(byUser[b.APIKey], b)
+	}
+	now := time.Now().UTC()
+	for APIKey, books := range byUser {
+		created := bookmarks.MigrateParents(books, APIKey, now)
+		for _, b := range books {
+			*t.findBookmark(b.ID, APIKey) = b
+		}
+		t.Bookmarks = append(t.Bookmarks, created...)
+	}
+	return len(t.Bookmarks), nil
+}
+
+// MigrateTrashParents links bookmarks added to the test db trash with only a path to the folders they
+// were deleted with, as the migration does for the trash stored in MongoDB.
+func (t *Testdb) MigrateTrashParents(ctx context.Context) (int, error) {
+	byUser := make(map[string][]bookmarks.Bookmark)
+	for _, b := range t.Trash {
+		byUser[b.APIKey] = append(byUser[b.APIKey], b)
+	}
+	now := time.Now().UTC()
+	for APIKey, trashed := range byUser {
+		books, _ := t.GetAllBookmarks(ctx, APIKey)
+		created := bookmarks.MigrateTrashParents(trashed, books, APIKey, now)
+		for _, b := range trashed {
+			i := slices.IndexFunc(t.Trash, func(d bookmarks.Bookmark) bool { return d.ID == b.ID })
+			t.Trash[i] = b
+		}
+		t.Trash = append(t.Trash, created...)
+	}
+	return len(t.Trash), nil
+}
+
+// GetBookmarksFolder gets the folder found by query along with everything inside of it and the folders
+// it is inside from the test db.
+func (t *Testdb) GetBookmarksFolder(ctx context.Context, query bookmarks.FolderQuery, APIKey string) (*bookmarks.FolderContents, apierr.Error) {
+	var contents *bookmarks.FolderContents
+	for _, val := range t.Bookmarks {
+		if val.APIKey != APIKey || !val.IsFolder {
+			continue
+		}
+		var match bool
+		switch {
+		case len(query.ID) > 0:
+			match = val.ID == query.ID
+		case len(query.Path) > 0:
+			match = val.ChildPath() == query.Path
+		default:
+			match = strings.EqualFold(val.Name, query.Name)
+		}
+		if match && (contents == nil || bookmarks.CompareFolderMatches(val, contents.Folder) < 0) {
+			contents = &bookmarks.FolderContents{Folder: val}
+		}
+	}
+	if contents == nil {
+		return nil, apierr.NewNotFoundError("folder not found")
+	}
 	for _, val := range t.Bookmarks {
-		check := ""
-		if val.IsFolder {
-			check = val.Name
-		} else {
-			check = val.Path
+		if val.APIKey != APIKey {
+			continue
 		}
-		match, err := regexp.Match("(?i)"+path, []byte(check))
-		if err != nil {
-			return nil, apierr.NewBadRequestError("invalid bookmark folder path")
+		if val.IsInside(contents.Folder.ID) {
+			contents.Contents = append(contents.Contents, val)
+		} else if contents.Folder.IsInside(val.ID) {
+			contents.Ancestors = append(contents.Ancestors, val)
 		}
-		if match {
-			folder = append(folder, val)
+	}
+	return contents, nil
+}
+
+// ListBookmarks gets a sorted page of a users bookmarks from the test db.
+func (t *Testdb) ListBookmarks(ctx context.Context, query bookmarks.ListQuery, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	books := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && (query.After == nil || query.After.IsAfter(b)) {
+			books = append(books, b)
 		}
 	}
-	return folder, nil
+	slices.SortFunc(books, func(a, b bookmarks.Bookmark) int {
+		return bookmarks.CompareListBookmarks(a, b, query.Sort)
+	})
+	return books[:min(query.Limit, len(books))], nil
 }
 
-// AddBookmark adds a bookmark to the test db.
-func (t *Testdb) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error) {
+// SearchBookmarks searches a users bookmarks in the test db.
+func (t *Testdb) SearchBookmarks(ctx context.Context, query bookmarks.SearchQuery, APIKey string) (*bookmarks.SearchResults, apierr.Error) {
+	terms := bookmarks.SearchTerms(query.Query)
+	folderIDs := []string{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.IsFolder && len(query.Folder) > 0 && b.ChildPath() == query.Folder {
+			folderIDs = append(folderIDs, b.ID)
+		}
+	}
+	results := []bookmarks.SearchResult{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey != APIKey || len(query.Folder) > 0 && !slices.ContainsFunc(folderIDs, b.IsInside) {
+			continue
+		}
+		if score := bookmarks.ScoreBookmark(b, terms); score > 0 {
+			results = append(results, bookmarks.SearchResult{Bookmark: b, Score: score})
+		}
+	}
+	bookmarks.SortSearchResults(results)
+	total := len(results)
+	start := min(query.Skip(), total)
+	end := min(start+query.Limit, total)
+	return &bookmarks.SearchResults{
+		Query:   query.Query,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		Total:   total,
+		Results: results[start:end],
+	}, nil
+}
+
+// GetBookmarksByTags gets all folders and the bookmarks matching the given tags from the test db.
+func (t *Testdb) GetBookmarksByTags(ctx context.Context, tags []string, matchAll bool, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	books := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && (b.IsFolder || bookmarks.HasTags(b, tags, matchAll)) {
+			books = append(books, b)
+		}
+	}
+	return books, nil
+}
+
+// GetTagCounts counts the bookmarks with each tag in the test db.
+func (t *Testdb) GetTagCounts(ctx context.Context, APIKey string) ([]bookmarks.TagCount, apierr.Error) {
+	counts := map[string]int{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey != APIKey {
+			continue
+		}
+		for _, tag := range b.Tags {
+			counts[tag]++
+		}
+	}
+	res := []bookmarks.TagCount{}
+	for tag, count := range counts {
+		res = append(res, bookmarks.TagCount{Tag: tag, Count: count})
+	}
+	return res, nil
+}
+
+// AddBookmark adds a bookmark to the end of its folder in the test db, creating any missing folders.
+func (t *Testdb) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (*bookmarks.Bookmark, apierr.Error) {
 	if _, err := t.GetUserByAPIKey(ctx, APIKey); err != nil {
-		return 0, apierr.NewBadRequestError("User does not exist.")
+		return nil, apierr.NewBadRequestError("User does not exist.")
 	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now().UTC()
+	id, _ := randomID(12)
 	bookmark := bookmarks.Bookmark{
-		APIKey: APIKey,
-		Name:   requestData.Name,
-		Path:   requestData.Path,
-		URL:    requestData.URL,
+		ID:         id,
+		APIKey:     APIKey,
+		Name:       requestData.Name,
+		URL:        requestData.URL,
+		IsFolder:   requestData.IsFolder,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if !bookmark.IsFolder {
+		bookmark.CanonicalURL = bookmarks.CanonicalURL(bookmark.URL)
+	}
+	dest, err := bookmarks.AddDestination(t.folders(APIKey), requestData, APIKey, now)
+	if err != nil {
+		return nil, apierr.NewNotFoundError(err.Error())
+	}
+	dest.Move(&bookmark)
+	for _, f := range dest.Created {
+		t.insertAtEnd(f)
+	}
+	bookmark = t.insertAtEnd(bookmark)
+	return &bookmark, nil
+}
+
+func (t *Testdb) folders(APIKey string) []bookmarks.Bookmark {
+	folders := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.IsFolder {
+			folders = append(folders, b)
+		}
+	}
+	return folders
+}
+
+func (t *Testdb) nextPosition(APIKey, parentID string) int {
+	var siblings []bookmarks.Bookmark
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.ParentID == parentID {
+			siblings = append(siblings, b)
+		}
+	}
+	return bookmarks.NextPosition(siblings)
+}
+
+func (t *Testdb) insertAtEnd(b bookmarks.Bookmark) bookmarks.Bookmark {
+	b.Position = t.nextPosition(b.APIKey, b.ParentID)
+	t.Bookmarks = append(t.Bookmarks, b)
+	return b
+}
+
+// EnrichBookmark fills in a bookmarks empty fields from page metadata in the test db.
+func (t *Testdb) EnrichBookmark(ctx context.Context, bookmarkID, APIKey string, meta bookmarks.PageMetadata) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.findBookmark(bookmarkID, APIKey)
+	if b == nil {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	if !bookmarks.ApplyPageMetadata(b, meta) {
+		return 0, nil
 	}
-	t.Bookmarks = append(t.Bookmarks, bookmark)
 	return 1, nil
 }
 
+// AddManyBookmarks adds bookmarks to the test db.
 func (t *Testdb) AddManyBookmarks(ctx context.Context, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
-	t.Bookmarks = append(t.Bookmarks, bookmarks...)
-	return len(bookmarks), nil
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.addMany(bookmarks), nil
+}
+
+func (t *Testdb) addMany(bookmarks []bookmarks.Bookmark) int {
+	for _, b := range bookmarks {
+		if len(b.ID) == 0 {
+			b.ID, _ = randomID(12)
+		}
+		t.Bookmarks = append(t.Bookmarks, b)
+	}
+	return len(bookmarks)
+}
+
+// UpdateManyBookmarks replaces bookmarks in the test db.
+func (t *Testdb) UpdateManyBookmarks(ctx context.Context, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	numUpdated := 0
+	for _, b := range bookmarks {
+		for idx := range t.Bookmarks {
+			if t.Bookmarks[idx].ID == b.ID && t.Bookmarks[idx].APIKey == b.APIKey {
+				t.Bookmarks[idx] = b
+				numUpdated++
+				break
+			}
+		}
+	}
+	return numUpdated, nil
+}
+
+// ReplaceAllBookmarks replaces all of a users bookmarks in the test db.
+func (t *Testdb) ReplaceAllBookmarks(ctx context.Context, APIKey string, books []bookmarks.Bookmark) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey != APIKey {
+			kept = append(kept, b)
+		}
+	}
+	t.Bookmarks = kept
+	owned := make([]bookmarks.Bookmark, len(books))
+	for i, b := range books {
+		b.APIKey = APIKey
+		owned[i] = b
+	}
+	return t.addMany(owned), nil
+}
+
+// StageBookmarks stages bookmarks to replace a users bookmarks with in the test db.
+func (t *Testdb) StageBookmarks(ctx context.Context, importID string, books []bookmarks.Bookmark) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Staged == nil {
+		t.Staged = map[string][]bookmarks.Bookmark{}
+	}
+	for _, b := range books {
+		if len(b.ID) == 0 {
+			b.ID, _ = randomID(12)
+		}
+		b.APIKey = ""
+		t.Staged[importID] = append(t.Staged[importID], b)
+	}
+	return len(books), nil
+}
+
+// ReplaceWithStagedBookmarks replaces all of a users bookmarks with the bookmarks staged with
+// importID in the test db.
+func (t *Testdb) ReplaceWithStagedBookmarks(ctx context.Context, importID, APIKey string) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	kept := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey != APIKey {
+			kept = append(kept, b)
+		}
+	}
+	staged := t.Staged[importID]
+	for _, b := range staged {
+		b.APIKey = APIKey
+		kept = append(kept, b)
+	}
+	t.Bookmarks = kept
+	delete(t.Staged, importID)
+	return len(staged), nil
+}
+
+// DeleteStagedBookmarks removes the bookmarks staged with importID from the test db.
+func (t *Testdb) DeleteStagedBookmarks(ctx context.Context, importID string) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	numDeleted := len(t.Staged[importID])
+	delete(t.Staged, importID)
+	return numDeleted, nil
+}
+
+// UpdateBookmark updates a bookmark and the paths and ancestors of any descendants in the test db.
+func (t *Testdb) UpdateBookmark(ctx context.Context, bookmarkID string, requestData request.UpdateBookmark, APIKey string) (int, apierr.Error) {
+	i := -1
+	for idx := range t.Bookmarks {
+		if t.Bookmarks[idx].ID == bookmarkID && t.Bookmarks[idx].APIKey == APIKey {
+			i = idx
+			break
+		}
+	}
+	if i < 0 {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	now := time.Now().UTC()
+	dest, err := bookmarks.UpdateDestination(t.folders(APIKey), requestData, APIKey, now)
+	if err != nil {
+		return 0, apierr.NewNotFoundError(err.Error())
+	}
+	current := t.Bookmarks[i]
+	updated, err := bookmarks.ApplyUpdate(current, requestData, dest, now)
+	if err != nil {
+		return 0, apierr.NewBadRequestError(err.Error())
+	}
+	if dest != nil {
+		for _, f := range dest.Created {
+			t.insertAtEnd(f)
+		}
+	}
+	if updated.ParentID != current.ParentID {
+		updated.Position = t.nextPosition(APIKey, updated.ParentID)
+	}
+	t.Bookmarks[i] = updated
+	numUpdated := 1
+	if !current.IsFolder {
+		return numUpdated, nil
+	}
+	return numUpdated + t.moveDescendants(current, updated.ChildPath(), updated.ChildAncestors()), nil
+}
+
+// moveDescendants moves everything inside folder so that the folder's contents have the path and
+// ancestors childPath and childAncestors instead.
+func (t *Testdb) moveDescendants(folder bookmarks.Bookmark, childPath string, childAncestors []string) int {
+	numMoved := 0
+	for idx := range t.Bookmarks {
+		b := &t.Bookmarks[idx]
+		if b.APIKey != folder.APIKey || !b.IsInside(folder.ID) {
+			continue
+		}
+		b.Path = bookmarks.ReplacePathPrefix(b.Path, folder.ChildPath(), childPath)
+		b.Ancestors = bookmarks.ReplaceAncestorsPrefix(b.Ancestors, len(folder.Ancestors)+1, childAncestors)
+		if b.ParentID == folder.ID && len(childAncestors) > 0 {
+			b.ParentID = childAncestors[len(childAncestors)-1]
+		} else if b.ParentID == folder.ID {
+			b.ParentID = ""
+		}
+		numMoved++
+	}
+	return numMoved
+}
+
+// GetBrokenBookmarks gets bookmarks with broken links from the test db.
+func (t *Testdb) GetBrokenBookmarks(ctx context.Context, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	books := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.LinkStatus != nil && b.LinkStatus.IsBroken() {
+			books = append(books, b)
+		}
+	}
+	return books, nil
+}
+
+// UpdateLinkStatuses records link check results in the test db.
+func (t *Testdb) UpdateLinkStatuses(ctx context.Context, APIKey string, results []bookmarks.LinkResult) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	numUpdated := 0
+	for _, r := range results {
+		if b := t.findBookmark(r.ID, APIKey); b != nil {
+			status := r.Status
+			b.LinkStatus = &status
+			numUpdated++
+		}
+	}
+	return numUpdated, nil
+}
+
+// AddTags adds tags to a bookmark in the test db.
+func (t *Testdb) AddTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error) {
+	b := t.findBookmark(bookmarkID, APIKey)
+	if b == nil || b.IsFolder {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	b.Tags = bookmarks.NormalizeTags(append(b.Tags, tags...))
+	return 1, nil
+}
+
+// RemoveTags removes tags from a bookmark in the test db.
+func (t *Testdb) RemoveTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error) {
+	b := t.findBookmark(bookmarkID, APIKey)
+	if b == nil || b.IsFolder {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	kept := []string{}
+	for _, tag := range b.Tags {
+		if !slices.Contains(tags, tag) {
+			kept = append(kept, tag)
+		}
+	}
+	b.Tags = kept
+	return 1, nil
 }
 
-// DeleteBookmark removes a bookmark from the test db.
-func (t *Testdb) DeleteBookmark(ctx context.Context, bookmarkID, APIKey string) (int, apierr.Error) {
+func (t *Testdb) findBookmark(bookmarkID, APIKey string) *bookmarks.Bookmark {
+	for i := range t.Bookmarks {
+		if t.Bookmarks[i].ID == bookmarkID && t.Bookmarks[i].APIKey == APIKey {
+			return &t.Bookmarks[i]
+		}
+	}
+	return nil
+}
+
+// UpdatePositions reorders the contents of a folder in the test db.
+func (t *Testdb) UpdatePositions(ctx context.Context, folderID string, IDs []string, APIKey string) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(folderID) > 0 {
+		if folder := t.findBookmark(folderID, APIKey); folder == nil || !folder.IsFolder {
+			return 0, apierr.NewNotFoundError("folder not found")
+		}
+	}
+	var children []bookmarks.Bookmark
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.ParentID == folderID {
+			children = append(children, b)
+		}
+	}
+	if err := bookmarks.CheckOrder(children, IDs); err != nil {
+		return 0, apierr.NewBadRequestError(err.Error())
+	}
+	for i, id := range IDs {
+		t.findBookmark(id, APIKey).Position = i
+	}
+	return len(IDs), nil
+}
+
+func (t *Testdb) MergeBookmarks(ctx context.Context, keep bookmarks.Bookmark, duplicateIDs []string, APIKey string) (int, apierr.Error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.findBookmark(keep.ID, APIKey)
+	if b == nil {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	*b = keep
+	now := time.Now().UTC()
+	kept := t.Bookmarks[:0]
+	for _, b := range t.Bookmarks {
+		if b.APIKey != APIKey || !slices.Contains(duplicateIDs, b.ID) {
+			kept = append(kept, b)
+			continue
+		}
+		b.DeletedAt = &now
+		b.TrashID = b.ID
+		t.Trash = append(t.Trash, b)
+	}
+	numDeleted := len(t.Bookmarks) - len(kept)
+	t.Bookmarks = kept
+	return numDeleted, nil
+}
+
+// DeleteBookmark removes a bookmark, and any descendants if it is a folder, from the test db.
+func (t *Testdb) DeleteBookmark(ctx context.Context, bookmarkID, mode, APIKey string) (*bookmarks.DeleteResult, apierr.Error) {
 	i := -1
 	for idx := range t.Bookmarks {
-		log.Println(idx, t.Bookmarks[idx], t.Bookmarks[idx].ID == bookmarkID)
-		if t.Bookmarks[idx].ID == bookmarkID {
+		if t.Bookmarks[idx].ID == bookmarkID && t.Bookmarks[idx].APIKey == APIKey {
 			i = idx
 			break
 		}
 	}
 	if i < 0 {
-		return 0, apierr.NewBadRequestError("id not in bookmarks")
+		return nil, apierr.NewNotFoundError("bookmark not found")
+	}
+	now := time.Now().UTC()
+	trash := func(b bookmarks.Bookmark) {
+		b.DeletedAt = &now
+		b.TrashID = bookmarkID
+		t.Trash = append(t.Trash, b)
+	}
+	current := t.Bookmarks[i]
+	t.Bookmarks = append(t.Bookmarks[:i], t.Bookmarks[i+1:]...)
+	trash(current)
+	result := &bookmarks.DeleteResult{Deleted: 1}
+	if !current.IsFolder {
+		return result, nil
+	}
+	if mode == bookmarks.BookmarksDeleteModeReparent {
+		result.Moved = t.moveDescendants(current, current.Path, current.Ancestors)
+		return result, nil
+	}
+	kept := []bookmarks.Bookmark{}
+	for _, b := range t.Bookmarks {
+		if b.APIKey == APIKey && b.IsInside(current.ID) {
+			trash(b)
+			result.Deleted++
+			continue
+		}
+		kept = append(kept, b)
+	}
+	t.Bookmarks = kept
+	return result, nil
+}
+
+func (t *Testdb) GetTrash(ctx context.Context, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	trashed := []bookmarks.Bookmark{}
+	for _, b := range t.Trash {
+		if b.APIKey == APIKey {
+			trashed = append(trashed, b)
+		}
+	}
+	return trashed, nil
+}
+
+func (t *Testdb) RestoreBookmark(ctx context.Context, trashID string, dest *bookmarks.Destination, APIKey string) (int, apierr.Error) {
+	restored := []bookmarks.Bookmark{}
+	kept := []bookmarks.Bookmark{}
+	for _, b := range t.Trash {
+		if b.APIKey == APIKey && b.TrashID == trashID {
+			b.DeletedAt, b.TrashID = nil, ""
+			restored = append(restored, b)
+			continue
+		}
+		kept = append(kept, b)
+	}
+	if len(restored) == 0 {
+		return 0, apierr.NewNotFoundError("bookmark not found in trash")
+	}
+	for _, r := range restored {
+		if slices.ContainsFunc(t.Bookmarks, func(b bookmarks.Bookmark) bool { return b.ID == r.ID }) {
+			return 0, apierr.NewConflictError("a bookmark with the same id already exists")
+		}
+	}
+	if dest != nil {
+		for _, f := range dest.Created {
+			t.insertAtEnd(f)
+		}
+		var moved bookmarks.Bookmark
+		dest.Move(&moved)
+		root := slices.IndexFunc(restored, func(b bookmarks.Bookmark) bool { return b.ID == trashID })
+		depth := len(restored[root].Ancestors)
+		restored[root].ParentID, restored[root].Path = moved.ParentID, moved.Path
+		for i := range restored {
+			restored[i].Ancestors = bookmarks.ReplaceAncestorsPrefix(restored[i].Ancestors, depth, moved.Ancestors)
+		}
+	}
+	t.Bookmarks = append(t.Bookmarks, restored...)
+	t.Trash = kept
+	return len(restored), nil
+}
+
+// AddSnapshot adds a snapshot to the test db.
+func (t *Testdb) AddSnapshot(ctx context.Context, snapshot bookmarks.Snapshot) (string, apierr.Error) {
+	snapshot.ID, _ = randomID(12)
+	t.Snapshots = append(t.Snapshots, snapshot)
+	return snapshot.ID, nil
+}
+
+// GetSnapshots gets a users snapshots from the test db, newest first and without their bookmarks.
+func (t *Testdb) GetSnapshots(ctx context.Context, APIKey string) ([]bookmarks.Snapshot, apierr.Error) {
+	snapshots := []bookmarks.Snapshot{}
+	for i := len(t.Snapshots) - 1; i >= 0; i-- {
+		if s := t.Snapshots[i]; s.APIKey == APIKey {
+			s.Data = nil
+			snapshots = append(snapshots, s)
+		}
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot gets one of a users snapshots from the test db.
+func (t *Testdb) GetSnapshot(ctx context.Context, snapshotID, APIKey string) (*bookmarks.Snapshot, apierr.Error) {
+	for _, s := range t.Snapshots {
+		if s.ID == snapshotID && s.APIKey == APIKey {
+			return &s, nil
+		}
+	}
+	return nil, apierr.NewNotFoundError("snapshot not found")
+}
+
+// AddShare adds a shared folder to the test db.
+func (t *Testdb) AddShare(ctx context.Context, share bookmarks.Share) apierr.Error {
+	t.Shares = append(t.Shares, share)
+	return nil
+}
+
+// GetShare gets the shared folder with slug from the test db.
+func (t *Testdb) GetShare(ctx context.Context, slug string) (*bookmarks.Share, apierr.Error) {
+	for _, s := range t.Shares {
+		if s.Slug == slug {
+			return &s, nil
+		}
+	}
+	return nil, apierr.NewNotFoundError("share not found")
+}
+
+// GetShares gets a users shared folders from the test db, newest first.
+func (t *Testdb) GetShares(ctx context.Context, APIKey string) ([]bookmarks.Share, apierr.Error) {
+	shares := []bookmarks.Share{}
+	for i := len(t.Shares) - 1; i >= 0; i-- {
+		if t.Shares[i].APIKey == APIKey {
+			shares = append(shares, t.Shares[i])
+		}
+	}
+	return shares, nil
+}
+
+// DeleteShare removes one of a users shared folders from the test db.
+func (t *Testdb) DeleteShare(ctx context.Context, slug, APIKey string) (int, apierr.Error) {
+	i := slices.IndexFunc(t.Shares, func(s bookmarks.Share) bool { return s.Slug == slug && s.APIKey == APIKey })
+	if i < 0 {
+		return 0, apierr.NewNotFoundError("share not found")
 	}
-	t.Bookmarks[i] = t.Bookmarks[len(t.Bookmarks)-1]
-	t.Bookmarks = t.Bookmarks[:len(t.Bookmarks)-1]
+	t.Shares = slices.Delete(t.Shares, i, i+1)
 	return 1, nil
 }
 
+// UpdateSettings saves a users settings in the test db.
+func (t *Testdb) UpdateSettings(ctx context.Context, settings accounts.Settings, APIKey string) (int, apierr.Error) {
+	for id, usr := range t.Users {
+		if usr.APIKey == APIKey {
+			usr.SearchEngine, usr.SearchURL, usr.CmdMatching = settings.SearchEngine, settings.SearchURL, settings.CmdMatching
+			t.Users[id] = usr
+			return 1, nil
+		}
+	}
+	return 0, apierr.NewNotFoundError("could not find user")
+}
+
 // Delete removes a user from the test db.
 func (t *Testdb) Delete(ctx context.Context, body request.DeleteUser, APIKey string) (int, apierr.Error) {
 	usr := t.findUserByAPIKey(APIKey)
@@ -264,23 +879,73 @@ func (t *Testdb) Search(ctx context.Context, APIKey, cmd string) (string, error)
 // Cache represents a test cache.
 type Cache struct {
 	Cmds map[string]map[string]string
+	// mu guards users and import jobs, which are updated in the background.
+	mu         sync.Mutex
+	Users      map[string]accounts.User
+	ImportJobs map[string]bookmarks.ImportJob
 }
 
 // NewCache returns a new Cache.
 func NewCache() *Cache {
-	return &Cache{Cmds: map[string]map[string]string{}}
+	return &Cache{
+		Cmds:       map[string]map[string]string{},
+		Users:      map[string]accounts.User{},
+		ImportJobs: map[string]bookmarks.ImportJob{},
+	}
+}
+
+// GetImportJob gets an import job from the test cache.
+func (c *Cache) GetImportJob(ctx context.Context, APIKey, jobID string) (*bookmarks.ImportJob, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	job, ok := c.ImportJobs[APIKey+":"+jobID]
+	if !ok {
+		return nil, bookmarks.ErrImportJobNotFound
+	}
+	job.Errors = slices.Clone(job.Errors)
+	return &job, nil
+}
+
+// SetImportJob saves an import job in the test cache.
+func (c *Cache) SetImportJob(ctx context.Context, job *bookmarks.ImportJob, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	saved := *job
+	saved.Errors = slices.Clone(job.Errors)
+	c.ImportJobs[job.APIKey+":"+job.ID] = saved
+	return nil
 }
 
 func (c *Cache) GetUser(ctx context.Context, userKey string) (accounts.User, error) {
-	return accounts.User{}, fmt.Errorf("no user in cache")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	usr, ok := c.Users[userKey]
+	if !ok {
+		return accounts.User{}, fmt.Errorf("no user in cache")
+	}
+	usr.Cmds = c.Cmds[userKey]
+	return usr, nil
 }
 
 func (c *Cache) AddUser(ctx context.Context, userKey string, user accounts.User) (int64, error) {
-	return 0, fmt.Errorf("no user in cache")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Users[userKey] = user
+	if len(user.Cmds) > 0 {
+		c.Cmds[userKey] = user.Cmds
+	}
+	return 1, nil
 }
 
 func (c *Cache) DeleteUser(ctx context.Context, userKey string) (int64, error) {
-	return 0, fmt.Errorf("no user in cache")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.Users[userKey]; !ok {
+		return 0, fmt.Errorf("no user in cache")
+	}
+	delete(c.Users, userKey)
+	delete(c.Cmds, userKey)
+	return 1, nil
 }
 
 func (c *Cache) GetAllCmds(ctx context.Context, cacheKey string) (map[string]string, error) {
@@ -293,7 +958,10 @@ func (c *Cache) GetOneCmd(ctx context.Context, cacheKey, cmd string) (string, er
 	if !ok {
 		return "", fmt.Errorf("no cmds in cache")
 	}
-	url := val[cmd]
+	url, ok := val[cmd]
+	if !ok {
+		return "", fmt.Errorf("cmd not in cache")
+	}
 	if strings.Contains(url, "http://") || strings.Contains(url, "https://") {
 		return url, nil
 	}
@@ -105,8 +105,18 @@ func MakeJSONRequestBody[T request.APIRequest](data T) (*bytes.Buffer, error) {
 }
 
 func MakeFileRequestBody(path, filename string) (*bytes.Buffer, string, error) {
+	return MakeFileRequestBodyWithFields(path, filename, nil)
+}
+
+// MakeFileRequestBodyWithFields creates a multipart request body with a bookmarks file and additional form fields.
+func MakeFileRequestBodyWithFields(path, filename string, fields map[string]string) (*bytes.Buffer, string, error) {
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
+	for key, val := range fields {
+		if err := writer.WriteField(key, val); err != nil {
+			return nil, "", err
+		}
+	}
 	ff, err := writer.CreateFormFile(bookmarks.BookmarksFileKey, filename)
 	if err != nil {
 		return nil, "", err
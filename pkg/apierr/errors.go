@@ -15,6 +15,8 @@ var (
 	ErrNotFound = errors.New("not found")
 	// ErrUnauthorized represents an HTTP unauthorized error.
 	ErrUnauthorized = errors.New("unauthorized")
+	// ErrConflict represents an HTTP conflict error.
+	ErrConflict = errors.New("conflict")
 	// ErrForbidden represents an HTTP forbidden error.
 	ErrForbidden = errors.New("forbidden")
 	// ErrPermissionDenied represents an HTTP permission denied error.
@@ -101,6 +103,24 @@ func NewWrongCredentialsError(detail string) APIError {
 	}
 }
 
+// NewNotFoundError returns a not found APIError with given arguments.
+func NewNotFoundError(detail string) APIError {
+	return APIError{
+		status: http.StatusNotFound,
+		err:    ErrNotFound,
+		detail: detail,
+	}
+}
+
+// NewConflictError returns a conflict APIError with given arguments.
+func NewConflictError(detail string) APIError {
+	return APIError{
+		status: http.StatusConflict,
+		err:    ErrConflict,
+		detail: detail,
+	}
+}
+
 // NewInternalServerError returns an internal server error APIError.
 func NewInternalServerError() APIError {
 	return APIError{
@@ -2,12 +2,18 @@ package mongodb
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"time"
 
 	"github.com/conalli/bookshelf-backend/pkg/apierr"
 	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // GetAllBookmarks gets all a users bookmarks from the db.
@@ -28,30 +34,256 @@ func (m *Mongo) GetAllBookmarks(ctx context.Context, APIKey string) ([]bookmarks
 	return bookmarks, nil
 }
 
-// GetBookmarksFolder gets all a users bookmarks from the db.
-func (m *Mongo) GetBookmarksFolder(ctx context.Context, path, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+// GetBookmarksFolder gets the users folder found by query along with everything inside of it and the
+// folders it is inside. When more than one folder has the queried name, the one nearest the top level
+// is used.
+func (m *Mongo) GetBookmarksFolder(ctx context.Context, query bookmarks.FolderQuery, APIKey string) (*bookmarks.FolderContents, apierr.Error) {
 	collection := m.db.Collection(CollectionBookmarks)
 	filter := bson.D{
 		bson.E{Key: "api_key", Value: APIKey},
-		bson.E{
-			Key: "$or", Value: bson.A{
-				bson.M{
-					"path": bson.D{
-						bson.E{Key: "$regex", Value: primitive.Regex{Pattern: path, Options: "i"}},
-					},
-				},
-				bson.M{
-					"is_folder": true,
-					"name": bson.D{
-						bson.E{Key: "$regex", Value: primitive.Regex{Pattern: path, Options: "i"}},
-					},
-				},
+		bson.E{Key: "is_folder", Value: true},
+	}
+	switch {
+	case len(query.ID) > 0:
+		oid, err := primitive.ObjectIDFromHex(query.ID)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", query.ID)
+			return nil, apierr.NewBadRequestError("invalid folder id")
+		}
+		filter = append(filter, bson.E{Key: "_id", Value: oid})
+	case len(query.Path) > 0:
+		filter = append(filter, folderPathFilter(query.Path))
+	default:
+		pattern := "^" + regexp.QuoteMeta(query.Name) + "$"
+		filter = append(filter, bson.E{Key: "name", Value: primitive.Regex{Pattern: pattern, Options: "i"}})
+	}
+	// Matches are ordered as bookmarks.CompareFolderMatches orders them, shallowest first.
+	pipeline := mongo.Pipeline{
+		bson.D{bson.E{Key: "$match", Value: filter}},
+		bson.D{bson.E{Key: "$addFields", Value: bson.D{bson.E{Key: "depth", Value: bson.D{
+			bson.E{Key: "$size", Value: bson.D{bson.E{Key: "$ifNull", Value: bson.A{"$ancestors", bson.A{}}}}},
+		}}}}},
+		bson.D{bson.E{Key: "$sort", Value: bson.D{
+			bson.E{Key: "depth", Value: 1},
+			bson.E{Key: "path", Value: 1},
+			bson.E{Key: "position", Value: 1},
+		}}},
+		bson.D{bson.E{Key: "$limit", Value: 1}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		m.log.Errorf("could not find bookmarks folder: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	var folders []bookmarks.Bookmark
+	if err := cursor.All(ctx, &folders); err != nil {
+		m.log.Errorf("could not get bookmarks folder from db cursor: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	if len(folders) == 0 {
+		return nil, apierr.NewNotFoundError("folder not found")
+	}
+	folder := folders[0]
+	contents := &bookmarks.FolderContents{Folder: folder}
+	descendants := bson.D{
+		bson.E{Key: "api_key", Value: APIKey},
+		bson.E{Key: "ancestors", Value: folder.ID},
+	}
+	if err := m.findAll(ctx, collection, descendants, &contents.Contents); err != nil {
+		return nil, err
+	}
+	if len(folder.Ancestors) == 0 {
+		return contents, nil
+	}
+	ancestorIDs := make([]primitive.ObjectID, 0, len(folder.Ancestors))
+	for _, id := range folder.Ancestors {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", id)
+			return nil, apierr.NewInternalServerError()
+		}
+		ancestorIDs = append(ancestorIDs, oid)
+	}
+	ancestors := bson.D{
+		bson.E{Key: "api_key", Value: APIKey},
+		bson.E{Key: "_id", Value: bson.D{bson.E{Key: "$in", Value: ancestorIDs}}},
+	}
+	if err := m.findAll(ctx, collection, ancestors, &contents.Ancestors); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// findAll decodes every bookmark matching filter into books.
+func (m *Mongo) findAll(ctx context.Context, collection *mongo.Collection, filter bson.D, books *[]bookmarks.Bookmark) apierr.Error {
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		m.log.Errorf("could not find bookmarks: %v", err)
+		return apierr.NewInternalServerError()
+	}
+	if err := cursor.All(ctx, books); err != nil {
+		m.log.Errorf("could not get bookmarks from db cursor: %v", err)
+		return apierr.NewInternalServerError()
+	}
+	return nil
+}
+
+// ListBookmarks gets up to query.Limit of a users bookmarks after the query cursor, ordered by the
+// query sort and then by ID.
+func (m *Mongo) ListBookmarks(ctx context.Context, query bookmarks.ListQuery, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	field := listSortField(query.Sort)
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}}
+	if query.After != nil {
+		var value interface{} = query.After.Value
+		if query.Sort == bookmarks.BookmarksListSortCreated {
+			t, err := query.After.Time()
+			if err != nil {
+				return nil, apierr.NewBadRequestError("invalid cursor")
+			}
+			value = t
+		}
+		oid, err := primitive.ObjectIDFromHex(query.After.ID)
+		if err != nil {
+			return nil, apierr.NewBadRequestError("invalid cursor")
+		}
+		filter = append(filter, primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: field, Value: bson.D{primitive.E{Key: "$gt", Value: value}}}},
+			bson.D{
+				primitive.E{Key: field, Value: value},
+				primitive.E{Key: "_id", Value: bson.D{primitive.E{Key: "$gt", Value: oid}}},
 			},
-		},
+		}})
+	}
+	opts := options.Find().
+		SetSort(bson.D{primitive.E{Key: field, Value: 1}, primitive.E{Key: "_id", Value: 1}}).
+		SetLimit(int64(query.Limit))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.Errorf("could not list bookmarks: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	books := []bookmarks.Bookmark{}
+	err = cursor.All(ctx, &books)
+	if err != nil {
+		m.log.Errorf("could not get bookmarks from db cursor: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return books, nil
+}
+
+func listSortField(sort string) string {
+	switch sort {
+	case bookmarks.BookmarksListSortCreated:
+		return "created_at"
+	case bookmarks.BookmarksListSortURL:
+		return "url"
+	default:
+		return "name"
+	}
+}
+
+// SearchBookmarks finds a page of a users bookmarks matching the query using the bookmarks text
+// index, ordered by relevance.
+func (m *Mongo) SearchBookmarks(ctx context.Context, query bookmarks.SearchQuery, APIKey string) (*bookmarks.SearchResults, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	filter := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "$text", Value: bson.D{primitive.E{Key: "$search", Value: query.Query}}},
+	}
+	if len(query.Folder) > 0 {
+		folderIDs, err := m.findFolderIDs(ctx, collection, query.Folder, APIKey)
+		if err != nil {
+			m.log.Errorf("could not find folder to search: %v", err)
+			return nil, apierr.NewInternalServerError()
+		}
+		filter = append(filter, primitive.E{Key: "ancestors", Value: bson.D{primitive.E{Key: "$in", Value: folderIDs}}})
+	}
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		m.log.Errorf("could not count bookmarks matching search: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	score := bson.D{primitive.E{Key: "$meta", Value: "textScore"}}
+	opts := options.Find().
+		SetProjection(bson.D{primitive.E{Key: "score", Value: score}}).
+		SetSort(bson.D{primitive.E{Key: "score", Value: score}, primitive.E{Key: "name", Value: 1}}).
+		SetSkip(int64(query.Skip())).
+		SetLimit(int64(query.Limit))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.Errorf("could not search bookmarks: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	results := []bookmarks.SearchResult{}
+	err = cursor.All(ctx, &results)
+	if err != nil {
+		m.log.Errorf("could not get search results from db cursor: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return &bookmarks.SearchResults{
+		Query:   query.Query,
+		Page:    query.Page,
+		Limit:   query.Limit,
+		Total:   int(total),
+		Results: results,
+	}, nil
+}
+
+// folderPathFilter matches the folders whose contents have the full path, whichever of the commas
+// in it are part of a folder name.
+func folderPathFilter(path string) bson.E {
+	locations := bson.A{}
+	for _, l := range bookmarks.FolderLocations(path) {
+		locations = append(locations, bson.D{bson.E{Key: "path", Value: l.Path}, bson.E{Key: "name", Value: l.Name}})
+	}
+	if len(locations) == 0 {
+		return bson.E{Key: "_id", Value: bson.D{bson.E{Key: "$exists", Value: false}}}
+	}
+	return bson.E{Key: "$or", Value: locations}
+}
+
+// findFolderIDs finds the IDs of a users folders whose contents have the full path.
+func (m *Mongo) findFolderIDs(ctx context.Context, collection *mongo.Collection, path, APIKey string) ([]string, error) {
+	filter := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "is_folder", Value: true},
+		folderPathFilter(path),
+	}
+	opts := options.Find().SetProjection(bson.D{primitive.E{Key: "_id", Value: 1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var folders []bookmarks.Bookmark
+	if err := cursor.All(ctx, &folders); err != nil {
+		return nil, err
+	}
+	folderIDs := make([]string, len(folders))
+	for i, f := range folders {
+		folderIDs[i] = f.ID
+	}
+	return folderIDs, nil
+}
+
+// GetBookmarksByTags gets a users bookmarks with all of the given tags, or any of them when matchAll
+// is false, along with all of the users folders.
+func (m *Mongo) GetBookmarksByTags(ctx context.Context, tags []string, matchAll bool, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	op := "$in"
+	if matchAll {
+		op = "$all"
+	}
+	filter := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "is_folder", Value: true}},
+			bson.D{primitive.E{Key: "tags", Value: bson.D{primitive.E{Key: op, Value: tags}}}},
+		}},
 	}
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
-		m.log.Errorf("could not find all bookmarks by APIKey: %v", err)
+		m.log.Errorf("could not find bookmarks by tags: %v", err)
 		return nil, apierr.NewInternalServerError()
 	}
 	var bookmarks []bookmarks.Bookmark
@@ -63,52 +295,1231 @@ func (m *Mongo) GetBookmarksFolder(ctx context.Context, path, APIKey string) ([]
 	return bookmarks, nil
 }
 
-// AddBookmark adds a new bookmark for a given user.
-func (m *Mongo) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error) {
+// GetTagCounts counts the number of a users bookmarks with each tag.
+func (m *Mongo) GetTagCounts(ctx context.Context, APIKey string) ([]bookmarks.TagCount, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	pipeline := mongo.Pipeline{
+		bson.D{primitive.E{Key: "$match", Value: bson.D{primitive.E{Key: "api_key", Value: APIKey}}}},
+		bson.D{primitive.E{Key: "$unwind", Value: "$tags"}},
+		bson.D{primitive.E{Key: "$group", Value: bson.D{
+			primitive.E{Key: "_id", Value: "$tags"},
+			primitive.E{Key: "count", Value: bson.D{primitive.E{Key: "$sum", Value: 1}}},
+		}}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		m.log.Errorf("could not count bookmark tags: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	counts := []bookmarks.TagCount{}
+	err = cursor.All(ctx, &counts)
+	if err != nil {
+		m.log.Errorf("could not get tag counts from db cursor: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return counts, nil
+}
+
+// EnrichBookmark fills in any of a bookmarks empty name, description, image and icon fields from
+// the metadata of the bookmarked page.
+func (m *Mongo) EnrichBookmark(ctx context.Context, bookmarkID, APIKey string, meta bookmarks.PageMetadata) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	oid, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return 0, apierr.NewBadRequestError("invalid bookmark id")
+	}
+	update := enrichUpdate(meta)
+	if update == nil {
+		return 0, nil
+	}
+	filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		m.log.Errorf("could not enrich bookmark: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	return int(result.ModifiedCount), nil
+}
+
+// enrichUpdate returns an update pipeline that sets each empty field to its page metadata, or nil
+// if there is no metadata. Values are wrapped in $literal so that page text starting with $ is
+// never read as a field path or variable.
+func enrichUpdate(meta bookmarks.PageMetadata) bson.A {
+	fields := bson.D{}
+	for _, f := range []primitive.E{
+		{Key: "name", Value: meta.Title},
+		{Key: "description", Value: meta.Description},
+		{Key: "image", Value: meta.Image},
+		{Key: "icon_uri", Value: meta.Icon},
+	} {
+		if len(f.Value.(string)) == 0 {
+			continue
+		}
+		current := bson.D{primitive.E{Key: "$ifNull", Value: bson.A{"$" + f.Key, ""}}}
+		value := bson.D{primitive.E{Key: "$literal", Value: f.Value}}
+		fields = append(fields, primitive.E{Key: f.Key, Value: bson.D{primitive.E{Key: "$cond", Value: bson.A{
+			bson.D{primitive.E{Key: "$eq", Value: bson.A{current, ""}}}, value, "$" + f.Key,
+		}}}})
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return bson.A{bson.D{primitive.E{Key: "$set", Value: fields}}}
+}
+
+// GetBrokenBookmarks gets a users bookmarks whose last link check failed or returned an error status.
+func (m *Mongo) GetBrokenBookmarks(ctx context.Context, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	filter := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "$or", Value: bson.A{
+			bson.D{primitive.E{Key: "link_status.error", Value: bson.D{primitive.E{Key: "$gt", Value: ""}}}},
+			bson.D{primitive.E{Key: "link_status.status_code", Value: bson.D{primitive.E{Key: "$gte", Value: http.StatusBadRequest}}}},
+		}},
+	}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		m.log.Errorf("could not find broken bookmarks: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	books := []bookmarks.Bookmark{}
+	err = cursor.All(ctx, &books)
+	if err != nil {
+		m.log.Errorf("could not get bookmarks from db cursor: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return books, nil
+}
+
+// UpdateLinkStatuses records the result of checking the links of a users bookmarks.
+func (m *Mongo) UpdateLinkStatuses(ctx context.Context, APIKey string, results []bookmarks.LinkResult) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	models := make([]mongo.WriteModel, 0, len(results))
+	for _, r := range results {
+		oid, err := primitive.ObjectIDFromHex(r.ID)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", r.ID)
+			continue
+		}
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "link_status", Value: r.Status}}}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+	opts := options.BulkWrite().SetOrdered(false)
+	res, err := collection.BulkWrite(ctx, models, opts)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || res == nil {
+			m.log.Errorf("could not update link statuses in db - %v", err)
+			return 0, apierr.NewInternalServerError()
+		}
+		m.log.Errorf("could not update %d link statuses in db - %v", len(bulkErr.WriteErrors), err)
+	}
+	return int(res.MatchedCount), nil
+}
+
+// createBookmarkIndexes creates the text index used to search bookmarks, with fields weighted to
+// match the ranking used by bookmarks.ScoreBookmark, the indexes used to list bookmarks and find
+// the contents of folders, and the indexes used to swap in and expire staged imports.
+func (m *Mongo) createBookmarkIndexes(ctx context.Context) error {
+	collection := m.db.Collection(CollectionBookmarks)
+	index := mongo.IndexModel{
+		Keys: bson.D{
+			primitive.E{Key: "name", Value: "text"},
+			primitive.E{Key: "url", Value: "text"},
+			primitive.E{Key: "path", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("bookmarks_text").
+			SetDefaultLanguage("none").
+			SetWeights(bson.D{
+				primitive.E{Key: "name", Value: bookmarks.BookmarksSearchNameWeight},
+				primitive.E{Key: "url", Value: bookmarks.BookmarksSearchURLWeight},
+				primitive.E{Key: "path", Value: bookmarks.BookmarksSearchPathWeight},
+			}),
+	}
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	if err != nil {
+		return err
+	}
+	listIndexes := []mongo.IndexModel{}
+	for _, sort := range []string{bookmarks.BookmarksListSortName, bookmarks.BookmarksListSortCreated, bookmarks.BookmarksListSortURL} {
+		listIndexes = append(listIndexes, mongo.IndexModel{Keys: bson.D{
+			primitive.E{Key: "api_key", Value: 1},
+			primitive.E{Key: listSortField(sort), Value: 1},
+			primitive.E{Key: "_id", Value: 1},
+		}})
+	}
+	listIndexes = append(listIndexes, mongo.IndexModel{Keys: bson.D{
+		primitive.E{Key: "api_key", Value: 1},
+		primitive.E{Key: "canonical_url", Value: 1},
+	}})
+	listIndexes = append(listIndexes, mongo.IndexModel{Keys: bson.D{
+		primitive.E{Key: "api_key", Value: 1},
+		primitive.E{Key: "parent_id", Value: 1},
+		primitive.E{Key: "position", Value: 1},
+	}})
+	listIndexes = append(listIndexes, mongo.IndexModel{Keys: bson.D{
+		primitive.E{Key: "api_key", Value: 1},
+		primitive.E{Key: "ancestors", Value: 1},
+	}})
+	listIndexes = append(listIndexes, mongo.IndexModel{
+		Keys:    bson.D{primitive.E{Key: "import_id", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	listIndexes = append(listIndexes, mongo.IndexModel{
+		Keys:    bson.D{primitive.E{Key: "staged_at", Value: 1}},
+		Options: options.Index().SetName("bookmarks_staged_ttl").SetExpireAfterSeconds(int32(bookmarks.BookmarksImportJobTTL / time.Second)),
+	})
+	_, err = collection.Indexes().CreateMany(ctx, listIndexes)
+	return err
+}
+
+// AddBookmark adds a new bookmark for a given user to the end of its folder, creating any missing
+// folders in its path, and returns the stored bookmark.
+func (m *Mongo) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (*bookmarks.Bookmark, apierr.Error) {
 	collection := m.db.Collection(CollectionBookmarks)
+	now := time.Now().UTC()
 	data := bookmarks.Bookmark{
-		APIKey:   APIKey,
-		Name:     requestData.Name,
-		Path:     requestData.Path,
-		URL:      requestData.URL,
-		IsFolder: requestData.IsFolder,
+		ID:         bookmarks.NewBookmarkID(),
+		APIKey:     APIKey,
+		Name:       requestData.Name,
+		URL:        requestData.URL,
+		IsFolder:   requestData.IsFolder,
+		CreatedAt:  now,
+		ModifiedAt: now,
+	}
+	if !data.IsFolder {
+		data.CanonicalURL = bookmarks.CanonicalURL(data.URL)
 	}
-	_, err := collection.InsertOne(ctx, data)
+	_, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		folders, err := m.findFolders(sessCtx, collection, APIKey)
+		if err != nil {
+			return nil, err
+		}
+		dest, err := bookmarks.AddDestination(folders, requestData, APIKey, now)
+		if err != nil {
+			return nil, apierr.NewNotFoundError(err.Error())
+		}
+		dest.Move(&data)
+		for _, b := range append(dest.Created, data) {
+			if err := m.insertAtEnd(sessCtx, collection, b); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
 	if err != nil {
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("couldn't insert bookmark: %v", apiErr.Detail())
+			return nil, apiErr
+		}
 		m.log.Errorf("couldn't insert bookmark: %v", err)
-		return 0, apierr.NewInternalServerError()
+		return nil, apierr.NewInternalServerError()
 	}
-	return 1, nil
+	return &data, nil
 }
 
+// insertAtEnd inserts a bookmark after the last of the bookmarks in its folder.
+func (m *Mongo) insertAtEnd(ctx context.Context, collection *mongo.Collection, b bookmarks.Bookmark) error {
+	position, err := nextPosition(ctx, collection, b.APIKey, b.ParentID)
+	if err != nil {
+		return err
+	}
+	b.Position = position
+	doc, err := bookmarkDocument(b)
+	if err != nil {
+		return err
+	}
+	_, err = collection.InsertOne(ctx, doc)
+	return err
+}
+
+// nextPosition returns the position after the last of the bookmarks in the folder parentID.
+func nextPosition(ctx context.Context, collection *mongo.Collection, APIKey, parentID string) (int, error) {
+	siblings := bson.D{primitive.E{Key: "api_key", Value: APIKey}, primitive.E{Key: "parent_id", Value: parentID}}
+	opts := options.FindOne().
+		SetSort(bson.D{primitive.E{Key: "position", Value: -1}}).
+		SetProjection(bson.D{primitive.E{Key: "position", Value: 1}})
+	var last bookmarks.Bookmark
+	switch err := collection.FindOne(ctx, siblings, opts).Decode(&last); {
+	case err == nil:
+		return last.Position + 1, nil
+	case err == mongo.ErrNoDocuments:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
+
+// findFolders gets all of a users folders.
+func (m *Mongo) findFolders(ctx context.Context, collection *mongo.Collection, APIKey string) ([]bookmarks.Bookmark, error) {
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}, primitive.E{Key: "is_folder", Value: true}}
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	folders := []bookmarks.Bookmark{}
+	err = cursor.All(ctx, &folders)
+	return folders, err
+}
+
+// bookmarkDocument converts a bookmark that was given an ID before it was stored into a document
+// with an ObjectID, so that it can be found by ID like any other bookmark.
+func bookmarkDocument(b bookmarks.Bookmark) (interface{}, error) {
+	if len(b.ID) == 0 {
+		return b, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(b.ID)
+	if err != nil {
+		return nil, err
+	}
+	b.ID = ""
+	raw, err := bson.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return append(bson.D{primitive.E{Key: "_id", Value: oid}}, doc...), nil
+}
+
+// bookmarkDocuments converts bookmarks into documents to insert with bookmarkDocument.
+func bookmarkDocuments(books []bookmarks.Bookmark, APIKey string) ([]interface{}, error) {
+	data := make([]interface{}, len(books))
+	for i, b := range books {
+		if len(APIKey) > 0 {
+			b.APIKey = APIKey
+		}
+		doc, err := bookmarkDocument(b)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = doc
+	}
+	return data, nil
+}
+
+// AddManyBookmarks inserts bookmarks into the db, returning the number inserted. Bookmarks
+// that fail to insert do not prevent the rest from being inserted.
 func (m *Mongo) AddManyBookmarks(ctx context.Context, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
 	collection := m.db.Collection(CollectionBookmarks)
-	data := make([]interface{}, len(bookmarks))
-	for i := range bookmarks {
-		data[i] = bookmarks[i]
-	}
-	res, err := collection.InsertMany(ctx, data)
+	data, err := bookmarkDocuments(bookmarks, "")
 	if err != nil {
-		m.log.Errorf("could not insert many bookmarks into db - %v", err)
+		m.log.Errorf("could not convert bookmarks to insert into db - %v", err)
 		return 0, apierr.NewInternalServerError()
 	}
+	opts := options.InsertMany().SetOrdered(false)
+	res, err := collection.InsertMany(ctx, data, opts)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || res == nil {
+			m.log.Errorf("could not insert many bookmarks into db - %v", err)
+			return 0, apierr.NewInternalServerError()
+		}
+		m.log.Errorf("could not insert %d bookmarks into db - %v", len(bulkErr.WriteErrors), err)
+		return len(res.InsertedIDs) - len(bulkErr.WriteErrors), nil
+	}
 	m.log.Infof("inserted %d bookmarks into db", len(res.InsertedIDs))
 	return len(res.InsertedIDs), nil
 }
 
-// DeleteBookmark removes a bookmark for a given user.
-func (m *Mongo) DeleteBookmark(ctx context.Context, bookmarkID, APIKey string) (int, apierr.Error) {
+// UpdateManyBookmarks replaces existing bookmarks with the given bookmarks, matching on their ID.
+func (m *Mongo) UpdateManyBookmarks(ctx context.Context, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	models := make([]mongo.WriteModel, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		oid, err := primitive.ObjectIDFromHex(b.ID)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", b.ID)
+			continue
+		}
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: b.APIKey}}
+		b.ID = ""
+		models = append(models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(b))
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+	opts := options.BulkWrite().SetOrdered(false)
+	res, err := collection.BulkWrite(ctx, models, opts)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || res == nil {
+			m.log.Errorf("could not update many bookmarks in db - %v", err)
+			return 0, apierr.NewInternalServerError()
+		}
+		m.log.Errorf("could not update %d bookmarks in db - %v", len(bulkErr.WriteErrors), err)
+	}
+	return int(res.MatchedCount), nil
+}
+
+// ReplaceAllBookmarks removes all of a users bookmarks and inserts the given bookmarks in their place.
+// The inserted bookmarks are always owned by the given user.
+func (m *Mongo) ReplaceAllBookmarks(ctx context.Context, APIKey string, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	data, err := bookmarkDocuments(bookmarks, APIKey)
+	if err != nil {
+		m.log.Errorf("could not convert bookmarks to insert into db - %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		_, err := collection.DeleteMany(sessCtx, bson.D{primitive.E{Key: "api_key", Value: APIKey}})
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return 0, nil
+		}
+		res, err := collection.InsertMany(sessCtx, data)
+		if err != nil {
+			return nil, err
+		}
+		return len(res.InsertedIDs), nil
+	})
+	if err != nil {
+		m.log.Errorf("could not replace bookmarks in db - %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numAdded, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of replaced bookmarks from transaction result")
+		return 0, apierr.NewInternalServerError()
+	}
+	m.log.Infof("replaced bookmarks with %d bookmarks in db", numAdded)
+	return numAdded, nil
+}
+
+// StageBookmarks inserts bookmarks being imported in place of a users bookmarks, returning the
+// number inserted. Staged bookmarks are marked with importID and have no API key, so they are not
+// seen by the user until ReplaceWithStagedBookmarks swaps them in.
+func (m *Mongo) StageBookmarks(ctx context.Context, importID string, bookmarks []bookmarks.Bookmark) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	data, err := stagedDocuments(bookmarks, importID, time.Now().UTC())
+	if err != nil {
+		m.log.Errorf("could not convert bookmarks to stage in db - %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	opts := options.InsertMany().SetOrdered(false)
+	res, err := collection.InsertMany(ctx, data, opts)
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || res == nil {
+			m.log.Errorf("could not stage bookmarks in db - %v", err)
+			return 0, apierr.NewInternalServerError()
+		}
+		m.log.Errorf("could not stage %d bookmarks in db - %v", len(bulkErr.WriteErrors), err)
+		return len(res.InsertedIDs) - len(bulkErr.WriteErrors), nil
+	}
+	return len(res.InsertedIDs), nil
+}
+
+// stagedDocuments converts bookmarks into documents to stage with importID.
+func stagedDocuments(books []bookmarks.Bookmark, importID string, now time.Time) ([]interface{}, error) {
+	data := make([]interface{}, len(books))
+	for i, b := range books {
+		if len(b.ID) == 0 {
+			b.ID = bookmarks.NewBookmarkID()
+		}
+		b.APIKey = ""
+		doc, err := bookmarkDocument(b)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = append(doc.(bson.D),
+			primitive.E{Key: "import_id", Value: importID},
+			primitive.E{Key: "staged_at", Value: now},
+		)
+	}
+	return data, nil
+}
+
+// ReplaceWithStagedBookmarks removes all of a users bookmarks and gives them the bookmarks staged
+// with importID in their place, returning the number of bookmarks swapped in. Both steps run in
+// the db in one transaction, so the users bookmarks are never partly replaced.
+func (m *Mongo) ReplaceWithStagedBookmarks(ctx context.Context, importID, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		_, err := collection.DeleteMany(sessCtx, bson.D{primitive.E{Key: "api_key", Value: APIKey}})
+		if err != nil {
+			return nil, err
+		}
+		filter := bson.D{primitive.E{Key: "import_id", Value: importID}}
+		update := bson.D{
+			primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "api_key", Value: APIKey}}},
+			primitive.E{Key: "$unset", Value: bson.D{
+				primitive.E{Key: "import_id", Value: ""},
+				primitive.E{Key: "staged_at", Value: ""},
+			}},
+		}
+		res, err := collection.UpdateMany(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		return int(res.ModifiedCount), nil
+	})
+	if err != nil {
+		m.log.Errorf("could not replace bookmarks with staged bookmarks in db - %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numReplaced, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of replaced bookmarks from transaction result")
+		return 0, apierr.NewInternalServerError()
+	}
+	m.log.Infof("replaced bookmarks with %d staged bookmarks in db", numReplaced)
+	return numReplaced, nil
+}
+
+// DeleteStagedBookmarks removes the bookmarks staged with importID, returning the number removed.
+func (m *Mongo) DeleteStagedBookmarks(ctx context.Context, importID string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	res, err := collection.DeleteMany(ctx, bson.D{primitive.E{Key: "import_id", Value: importID}})
+	if err != nil {
+		m.log.Errorf("could not delete staged bookmarks from db - %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	return int(res.DeletedCount), nil
+}
+
+// UpdateBookmark updates a bookmark for a given user, returning the number of bookmarks updated.
+// A bookmark moved to another folder is added to the end of it, creating any missing folders in
+// its path. When a folder is renamed or moved the paths and ancestors of all its descendants are
+// rewritten in the same transaction, so a failed update cannot leave the tree partially moved.
+func (m *Mongo) UpdateBookmark(ctx context.Context, bookmarkID string, requestData request.UpdateBookmark, APIKey string) (int, apierr.Error) {
 	collection := m.db.Collection(CollectionBookmarks)
 	oid, err := primitive.ObjectIDFromHex(bookmarkID)
 	if err != nil {
 		m.log.Error("could not get ObjectID from Hex")
 		return 0, apierr.NewBadRequestError("invalid bookmark id")
 	}
-	filter := bson.D{primitive.E{Key: "_id", Value: oid}}
-	result, err := collection.DeleteOne(ctx, filter)
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now().UTC()
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+		var current bookmarks.Bookmark
+		if err := collection.FindOne(sessCtx, filter).Decode(&current); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, apierr.NewNotFoundError("bookmark not found")
+			}
+			return nil, err
+		}
+		folders, err := m.findFolders(sessCtx, collection, APIKey)
+		if err != nil {
+			return nil, err
+		}
+		dest, err := bookmarks.UpdateDestination(folders, requestData, APIKey, now)
+		if err != nil {
+			return nil, apierr.NewNotFoundError(err.Error())
+		}
+		updated, err := bookmarks.ApplyUpdate(current, requestData, dest, now)
+		if err != nil {
+			return nil, apierr.NewBadRequestError(err.Error())
+		}
+		if dest != nil {
+			for _, f := range dest.Created {
+				if err := m.insertAtEnd(sessCtx, collection, f); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if updated.ParentID != current.ParentID {
+			updated.Position, err = nextPosition(sessCtx, collection, APIKey, updated.ParentID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "name", Value: updated.Name},
+			primitive.E{Key: "parent_id", Value: updated.ParentID},
+			primitive.E{Key: "ancestors", Value: updated.Ancestors},
+			primitive.E{Key: "path", Value: updated.Path},
+			primitive.E{Key: "position", Value: updated.Position},
+			primitive.E{Key: "url", Value: updated.URL},
+			primitive.E{Key: "canonical_url", Value: updated.CanonicalURL},
+			primitive.E{Key: "modified_at", Value: updated.ModifiedAt},
+		}}}
+		if _, err := collection.UpdateOne(sessCtx, filter, update); err != nil {
+			return nil, err
+		}
+		if !current.IsFolder || current.ChildPath() == updated.ChildPath() && updated.ParentID == current.ParentID {
+			return 1, nil
+		}
+		numMoved, err := m.moveDescendants(sessCtx, collection, APIKey, current, updated.ChildPath(), updated.ChildAncestors())
+		if err != nil {
+			return nil, err
+		}
+		return 1 + numMoved, nil
+	})
+	if err != nil {
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("could not update bookmark: %v", apiErr.Detail())
+			return 0, apiErr
+		}
+		m.log.Errorf("could not update bookmark: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numUpdated, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of updated bookmarks from transaction result")
+		return 0, apierr.NewInternalServerError()
+	}
+	return numUpdated, nil
+}
+
+// AddTags adds tags to one of a users bookmarks.
+func (m *Mongo) AddTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error) {
+	update := bson.D{primitive.E{Key: "$addToSet", Value: bson.D{
+		primitive.E{Key: "tags", Value: bson.D{primitive.E{Key: "$each", Value: tags}}},
+	}}}
+	return m.updateTags(ctx, bookmarkID, update, APIKey)
+}
+
+// RemoveTags removes tags from one of a users bookmarks.
+func (m *Mongo) RemoveTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error) {
+	update := bson.D{primitive.E{Key: "$pull", Value: bson.D{
+		primitive.E{Key: "tags", Value: bson.D{primitive.E{Key: "$in", Value: tags}}},
+	}}}
+	return m.updateTags(ctx, bookmarkID, update, APIKey)
+}
+
+func (m *Mongo) updateTags(ctx context.Context, bookmarkID string, update bson.D, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	oid, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return 0, apierr.NewBadRequestError("invalid bookmark id")
+	}
+	filter := bson.D{
+		primitive.E{Key: "_id", Value: oid},
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "is_folder", Value: false},
+	}
+	update = append(update, primitive.E{Key: "$set", Value: bson.D{primitive.E{Key: "modified_at", Value: time.Now().UTC()}}})
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		m.log.Errorf("could not update bookmark tags: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	if result.MatchedCount == 0 {
+		return 0, apierr.NewNotFoundError("bookmark not found")
+	}
+	return int(result.MatchedCount), nil
+}
+
+// UpdatePositions sets the position of each bookmark in the folder folderID, or the top level if
+// folderID is empty, to its index in IDs. IDs must list every bookmark in the folder once. The
+// folder is read, checked and updated in one transaction, so a bookmark moved in or out of the
+// folder at the same time fails the reorder instead of leaving the folder partly ordered.
+func (m *Mongo) UpdatePositions(ctx context.Context, folderID string, IDs []string, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	now := time.Now().UTC()
+	models := make([]mongo.WriteModel, 0, len(IDs))
+	for i, id := range IDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", id)
+			return 0, apierr.NewBadRequestError("invalid bookmark id")
+		}
+		filter := bson.D{
+			primitive.E{Key: "_id", Value: oid},
+			primitive.E{Key: "api_key", Value: APIKey},
+			primitive.E{Key: "parent_id", Value: folderID},
+		}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "position", Value: i},
+			primitive.E{Key: "modified_at", Value: now},
+		}}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	if len(models) == 0 {
+		return 0, nil
+	}
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		if len(folderID) > 0 {
+			folderOID, err := primitive.ObjectIDFromHex(folderID)
+			if err != nil {
+				return nil, apierr.NewBadRequestError("invalid folder id")
+			}
+			filter := bson.D{
+				primitive.E{Key: "_id", Value: folderOID},
+				primitive.E{Key: "api_key", Value: APIKey},
+				primitive.E{Key: "is_folder", Value: true},
+			}
+			if err := collection.FindOne(sessCtx, filter).Err(); err != nil {
+				if err == mongo.ErrNoDocuments {
+					return nil, apierr.NewNotFoundError("folder not found")
+				}
+				return nil, err
+			}
+		}
+		filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}, primitive.E{Key: "parent_id", Value: folderID}}
+		opts := options.Find().SetProjection(bson.D{primitive.E{Key: "_id", Value: 1}})
+		cursor, err := collection.Find(sessCtx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		var children []bookmarks.Bookmark
+		if err := cursor.All(sessCtx, &children); err != nil {
+			return nil, err
+		}
+		if err := bookmarks.CheckOrder(children, IDs); err != nil {
+			return nil, apierr.NewBadRequestError(err.Error())
+		}
+		res, err := collection.BulkWrite(sessCtx, models)
+		if err != nil {
+			return nil, err
+		}
+		if int(res.MatchedCount) != len(IDs) {
+			return nil, apierr.NewConflictError("folder changed while being reordered")
+		}
+		return int(res.MatchedCount), nil
+	})
+	if err != nil {
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("could not reorder folder %s: %v", folderID, apiErr.Detail())
+			return 0, apiErr
+		}
+		m.log.Errorf("could not update bookmark positions: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numUpdated, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of reordered bookmarks from transaction result")
+		return 0, apierr.NewInternalServerError()
+	}
+	return numUpdated, nil
+}
+
+// MergeBookmarks saves the merged bookmark and moves its duplicates to the trash in a single
+// transaction, returning the number of duplicates deleted.
+func (m *Mongo) MergeBookmarks(ctx context.Context, keep bookmarks.Bookmark, duplicateIDs []string, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	oid, err := primitive.ObjectIDFromHex(keep.ID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return 0, apierr.NewBadRequestError("invalid bookmark id")
+	}
+	duplicateOIDs := make([]primitive.ObjectID, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		duplicateOID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			m.log.Errorf("could not get ObjectID from Hex: %s", id)
+			return 0, apierr.NewBadRequestError("invalid bookmark id")
+		}
+		duplicateOIDs = append(duplicateOIDs, duplicateOID)
+	}
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "name", Value: keep.Name},
+			primitive.E{Key: "canonical_url", Value: keep.CanonicalURL},
+			primitive.E{Key: "description", Value: keep.Description},
+			primitive.E{Key: "image", Value: keep.Image},
+			primitive.E{Key: "icon", Value: keep.Icon},
+			primitive.E{Key: "icon_uri", Value: keep.IconURI},
+			primitive.E{Key: "tags", Value: keep.Tags},
+			primitive.E{Key: "created_at", Value: keep.CreatedAt},
+			primitive.E{Key: "modified_at", Value: keep.ModifiedAt},
+		}}}
+		updated, err := collection.UpdateOne(sessCtx, filter, update)
+		if err != nil {
+			return nil, err
+		}
+		if updated.MatchedCount == 0 {
+			return nil, apierr.NewNotFoundError("bookmark not found")
+		}
+		duplicates := bson.D{
+			primitive.E{Key: "_id", Value: bson.D{primitive.E{Key: "$in", Value: duplicateOIDs}}},
+			primitive.E{Key: "api_key", Value: APIKey},
+		}
+		cursor, err := collection.Find(sessCtx, duplicates)
+		if err != nil {
+			return nil, err
+		}
+		var docs []bson.M
+		if err := cursor.All(sessCtx, &docs); err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			return 0, nil
+		}
+		now := time.Now().UTC()
+		trashed := make([]interface{}, 0, len(docs))
+		for _, d := range docs {
+			duplicateOID, ok := d["_id"].(primitive.ObjectID)
+			if !ok {
+				return nil, errors.New("duplicate bookmark has invalid id")
+			}
+			trashed = append(trashed, trashDocument(d, duplicateOID, now))
+		}
+		if _, err := m.db.Collection(CollectionTrash).InsertMany(sessCtx, trashed); err != nil {
+			return nil, err
+		}
+		deleted, err := collection.DeleteMany(sessCtx, duplicates)
+		if err != nil {
+			return nil, err
+		}
+		return int(deleted.DeletedCount), nil
+	})
+	if err != nil {
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("could not merge bookmarks: %v", apiErr.Detail())
+			return 0, apiErr
+		}
+		m.log.Errorf("could not merge bookmarks: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numDeleted, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of deleted bookmarks from transaction result")
+		return 0, apierr.NewInternalServerError()
+	}
+	return numDeleted, nil
+}
+
+// DeleteBookmark moves a bookmark for a given user to the trash, returning a not found error if the
+// bookmark belongs to someone else. Deleting a folder also deletes all of its
+// descendants, unless mode is reparent, in which case they are moved up into the folder's parent.
+func (m *Mongo) DeleteBookmark(ctx context.Context, bookmarkID, mode, APIKey string) (*bookmarks.DeleteResult, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	trash := m.db.Collection(CollectionTrash)
+	oid, err := primitive.ObjectIDFromHex(bookmarkID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return nil, apierr.NewBadRequestError("invalid bookmark id")
+	}
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		now := time.Now().UTC()
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+		raw, err := collection.FindOneAndDelete(sessCtx, filter).Raw()
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				return nil, apierr.NewNotFoundError("bookmark not found")
+			}
+			return nil, err
+		}
+		var current bookmarks.Bookmark
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &current); err != nil {
+			return nil, err
+		}
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return nil, err
+		}
+		trashed := []interface{}{trashDocument(doc, oid, now)}
+		result := &bookmarks.DeleteResult{Deleted: 1}
+		if current.IsFolder && mode == bookmarks.BookmarksDeleteModeReparent {
+			numMoved, err := m.moveDescendants(sessCtx, collection, APIKey, current, current.Path, current.Ancestors)
+			if err != nil {
+				return nil, err
+			}
+			result.Moved = numMoved
+		} else if current.IsFolder {
+			descendants := bson.D{
+				primitive.E{Key: "api_key", Value: APIKey},
+				primitive.E{Key: "ancestors", Value: current.ID},
+			}
+			cursor, err := collection.Find(sessCtx, descendants)
+			if err != nil {
+				return nil, err
+			}
+			var docs []bson.M
+			if err := cursor.All(sessCtx, &docs); err != nil {
+				return nil, err
+			}
+			for _, d := range docs {
+				trashed = append(trashed, trashDocument(d, oid, now))
+			}
+			deleted, err := collection.DeleteMany(sessCtx, descendants)
+			if err != nil {
+				return nil, err
+			}
+			result.Deleted += int(deleted.DeletedCount)
+		}
+		if _, err := trash.InsertMany(sessCtx, trashed); err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+	if err != nil {
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("could not delete bookmark: %v", apiErr.Detail())
+			return nil, apiErr
+		}
+		m.log.Errorf("could not delete bookmark: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	result, ok := res.(*bookmarks.DeleteResult)
+	if !ok {
+		m.log.Error("could not get delete result from transaction result")
+		return nil, apierr.NewInternalServerError()
+	}
+	return result, nil
+}
+
+// trashDocument marks a bookmark document as deleted at now as part of deleting the bookmark trashID,
+// so that it can be restored along with it.
+func trashDocument(doc bson.M, trashID primitive.ObjectID, now time.Time) bson.M {
+	doc["deleted_at"] = now
+	doc["trash_id"] = trashID
+	return doc
+}
+
+// restoreInto moves the documents deleted along with trashID into dest.
+func restoreInto(docs []bson.M, trashID primitive.ObjectID, dest *bookmarks.Destination) {
+	var moved bookmarks.Bookmark
+	dest.Move(&moved)
+	depth := 0
+	for _, d := range docs {
+		if d["_id"] == trashID {
+			depth = len(stringsOf(d["ancestors"]))
+			d["parent_id"] = moved.ParentID
+			d["path"] = moved.Path
+		}
+	}
+	for _, d := range docs {
+		ancestors := bookmarks.ReplaceAncestorsPrefix(stringsOf(d["ancestors"]), depth, moved.Ancestors)
+		if len(ancestors) == 0 {
+			delete(d, "ancestors")
+			continue
+		}
+		d["ancestors"] = ancestors
+	}
+}
+
+// stringsOf converts an array decoded from a document into a slice of strings.
+func stringsOf(v interface{}) []string {
+	arr, _ := v.(primitive.A)
+	strs := make([]string, 0, len(arr))
+	for _, a := range arr {
+		if s, ok := a.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// GetTrash gets all of a users deleted bookmarks from the trash.
+func (m *Mongo) GetTrash(ctx context.Context, APIKey string) ([]bookmarks.Bookmark, apierr.Error) {
+	trash := m.db.Collection(CollectionTrash)
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "deleted_at", Value: -1}})
+	cursor, err := trash.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.Errorf("could not get trash: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	trashed := []bookmarks.Bookmark{}
+	if err := cursor.All(ctx, &trashed); err != nil {
+		m.log.Errorf("could not decode trash: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return trashed, nil
+}
+
+// RestoreBookmark moves every bookmark deleted along with trashID out of the trash, returning the
+// number of bookmarks restored. If dest is not nil they are restored into it, creating any of its
+// missing folders first. Nothing is restored if any of the bookmarks ids is in use again.
+func (m *Mongo) RestoreBookmark(ctx context.Context, trashID string, dest *bookmarks.Destination, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	trash := m.db.Collection(CollectionTrash)
+	oid, err := primitive.ObjectIDFromHex(trashID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return 0, apierr.NewBadRequestError("invalid bookmark id")
+	}
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.D{primitive.E{Key: "trash_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+		cursor, err := trash.Find(sessCtx, filter)
+		if err != nil {
+			return nil, err
+		}
+		var docs []bson.M
+		if err := cursor.All(sessCtx, &docs); err != nil {
+			return nil, err
+		}
+		if len(docs) == 0 {
+			return nil, apierr.NewNotFoundError("bookmark not found in trash")
+		}
+		if dest != nil {
+			for _, f := range dest.Created {
+				if err := m.insertAtEnd(sessCtx, collection, f); err != nil {
+					return nil, err
+				}
+			}
+			restoreInto(docs, oid, dest)
+		}
+		restored := make([]interface{}, 0, len(docs))
+		for _, d := range docs {
+			delete(d, "deleted_at")
+			delete(d, "trash_id")
+			restored = append(restored, d)
+		}
+		if _, err := collection.InsertMany(sessCtx, restored); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, apierr.NewConflictError("a bookmark with the same id already exists")
+			}
+			return nil, err
+		}
+		if _, err := trash.DeleteMany(sessCtx, filter); err != nil {
+			return nil, err
+		}
+		return len(docs), nil
+	})
 	if err != nil {
-		m.log.Errorf("couldn't remove cmd from user: %v", err)
+		var apiErr apierr.Error
+		if errors.As(err, &apiErr) {
+			m.log.Errorf("could not restore bookmark: %v", apiErr.Detail())
+			return 0, apiErr
+		}
+		m.log.Errorf("could not restore bookmark: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	numRestored, ok := res.(int)
+	if !ok {
+		m.log.Error("could not get number of restored bookmarks from transaction result")
 		return 0, apierr.NewInternalServerError()
 	}
-	return int(result.DeletedCount), nil
+	return numRestored, nil
+}
+
+// createTrashIndexes creates a TTL index that purges bookmarks from the trash once they have been
+// deleted for longer than retention. If the index already exists with a different retention it is
+// updated in place.
+func (m *Mongo) createTrashIndexes(ctx context.Context, retention time.Duration) error {
+	trash := m.db.Collection(CollectionTrash)
+	keys := bson.D{primitive.E{Key: "deleted_at", Value: 1}}
+	expireAfter := int32(retention / time.Second)
+	ttl := mongo.IndexModel{
+		Keys:    keys,
+		Options: options.Index().SetName("trash_ttl").SetExpireAfterSeconds(expireAfter),
+	}
+	if _, err := trash.Indexes().CreateOne(ctx, ttl); err != nil {
+		var cmdErr mongo.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Name != "IndexOptionsConflict" {
+			return err
+		}
+		collMod := bson.D{
+			primitive.E{Key: "collMod", Value: CollectionTrash},
+			primitive.E{Key: "index", Value: bson.D{
+				primitive.E{Key: "keyPattern", Value: keys},
+				primitive.E{Key: "expireAfterSeconds", Value: expireAfter},
+			}},
+		}
+		if err := m.db.RunCommand(ctx, collMod).Err(); err != nil {
+			return err
+		}
+	}
+	_, err := trash.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{
+		primitive.E{Key: "api_key", Value: 1},
+		primitive.E{Key: "trash_id", Value: 1},
+	}})
+	return err
+}
+
+// AddSnapshot saves a snapshot of a users bookmarks, returning its ID.
+func (m *Mongo) AddSnapshot(ctx context.Context, snapshot bookmarks.Snapshot) (string, apierr.Error) {
+	collection := m.db.Collection(CollectionSnapshots)
+	snapshot.ID = ""
+	res, err := collection.InsertOne(ctx, snapshot)
+	if err != nil {
+		m.log.Errorf("could not add snapshot: %v", err)
+		return "", apierr.NewInternalServerError()
+	}
+	oid, ok := res.InsertedID.(primitive.ObjectID)
+	if !ok {
+		m.log.Error("could not get ObjectID of inserted snapshot")
+		return "", apierr.NewInternalServerError()
+	}
+	return oid.Hex(), nil
+}
+
+// GetSnapshots gets a users snapshots, newest first, leaving out the bookmarks stored in them.
+func (m *Mongo) GetSnapshots(ctx context.Context, APIKey string) ([]bookmarks.Snapshot, apierr.Error) {
+	collection := m.db.Collection(CollectionSnapshots)
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}}
+	opts := options.Find().
+		SetSort(bson.D{primitive.E{Key: "created_at", Value: -1}}).
+		SetProjection(bson.D{primitive.E{Key: "data", Value: 0}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.Errorf("could not get snapshots: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	snapshots := []bookmarks.Snapshot{}
+	if err := cursor.All(ctx, &snapshots); err != nil {
+		m.log.Errorf("could not decode snapshots: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return snapshots, nil
+}
+
+// GetSnapshot gets one of a users snapshots along with the bookmarks stored in it.
+func (m *Mongo) GetSnapshot(ctx context.Context, snapshotID, APIKey string) (*bookmarks.Snapshot, apierr.Error) {
+	collection := m.db.Collection(CollectionSnapshots)
+	oid, err := primitive.ObjectIDFromHex(snapshotID)
+	if err != nil {
+		m.log.Error("could not get ObjectID from Hex")
+		return nil, apierr.NewBadRequestError("invalid snapshot id")
+	}
+	filter := bson.D{primitive.E{Key: "_id", Value: oid}, primitive.E{Key: "api_key", Value: APIKey}}
+	var snapshot bookmarks.Snapshot
+	if err := collection.FindOne(ctx, filter).Decode(&snapshot); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apierr.NewNotFoundError("snapshot not found")
+		}
+		m.log.Errorf("could not get snapshot: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return &snapshot, nil
+}
+
+// createSnapshotIndexes creates the index used to list a users snapshots newest first.
+func (m *Mongo) createSnapshotIndexes(ctx context.Context) error {
+	collection := m.db.Collection(CollectionSnapshots)
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{
+		primitive.E{Key: "api_key", Value: 1},
+		primitive.E{Key: "created_at", Value: -1},
+	}})
+	return err
+}
+
+// AddShare saves a shared folder.
+func (m *Mongo) AddShare(ctx context.Context, share bookmarks.Share) apierr.Error {
+	collection := m.db.Collection(CollectionShares)
+	if _, err := collection.InsertOne(ctx, share); err != nil {
+		m.log.Errorf("could not add share: %v", err)
+		return apierr.NewInternalServerError()
+	}
+	return nil
+}
+
+// GetShare gets the shared folder with slug, whoever it belongs to.
+func (m *Mongo) GetShare(ctx context.Context, slug string) (*bookmarks.Share, apierr.Error) {
+	collection := m.db.Collection(CollectionShares)
+	filter := bson.D{primitive.E{Key: "_id", Value: slug}}
+	var share bookmarks.Share
+	if err := collection.FindOne(ctx, filter).Decode(&share); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, apierr.NewNotFoundError("share not found")
+		}
+		m.log.Errorf("could not get share: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return &share, nil
+}
+
+// GetShares gets all of a users shared folders, newest first.
+func (m *Mongo) GetShares(ctx context.Context, APIKey string) ([]bookmarks.Share, apierr.Error) {
+	collection := m.db.Collection(CollectionShares)
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}}
+	opts := options.Find().SetSort(bson.D{primitive.E{Key: "created_at", Value: -1}})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		m.log.Errorf("could not get shares: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	shares := []bookmarks.Share{}
+	if err := cursor.All(ctx, &shares); err != nil {
+		m.log.Errorf("could not decode shares: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return shares, nil
+}
+
+// DeleteShare removes one of a users shared folders, returning the number of shares removed.
+func (m *Mongo) DeleteShare(ctx context.Context, slug, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionShares)
+	filter := bson.D{primitive.E{Key: "_id", Value: slug}, primitive.E{Key: "api_key", Value: APIKey}}
+	res, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		m.log.Errorf("could not delete share: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	if res.DeletedCount == 0 {
+		return 0, apierr.NewNotFoundError("share not found")
+	}
+	return int(res.DeletedCount), nil
+}
+
+// createShareIndexes creates a TTL index that removes shares once they have expired, which skips
+// shares without an expiry, and the index used to list a users shares.
+func (m *Mongo) createShareIndexes(ctx context.Context) error {
+	collection := m.db.Collection(CollectionShares)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{primitive.E{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("share_ttl").SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{primitive.E{Key: "api_key", Value: 1}, primitive.E{Key: "created_at", Value: -1}},
+		},
+	})
+	return err
+}
+
+// moveDescendants moves everything inside folder so that the folder's contents have the path and
+// ancestors childPath and childAncestors instead, returning the number of bookmarks moved. Moving
+// the contents to the folder's own path and ancestors moves them up into the folder's parent.
+func (m *Mongo) moveDescendants(ctx context.Context, collection *mongo.Collection, APIKey string, folder bookmarks.Bookmark, childPath string, childAncestors []string) (int, error) {
+	parentID := ""
+	if n := len(childAncestors); n > 0 {
+		parentID = childAncestors[n-1]
+	}
+	if childAncestors == nil {
+		childAncestors = []string{}
+	}
+	children := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "parent_id", Value: folder.ID},
+	}
+	moveChildren := bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "parent_id", Value: parentID},
+		primitive.E{Key: "ancestors", Value: childAncestors},
+		primitive.E{Key: "path", Value: childPath},
+	}}}
+	childResult, err := collection.UpdateMany(ctx, children, moveChildren)
+	if err != nil {
+		return 0, err
+	}
+	oldPrefix := folder.ChildPath()
+	nestedPrefix := childPath
+	if len(nestedPrefix) == 0 {
+		nestedPrefix = ","
+	}
+	nested := bson.D{
+		primitive.E{Key: "api_key", Value: APIKey},
+		primitive.E{Key: "ancestors", Value: folder.ID},
+		primitive.E{Key: "parent_id", Value: bson.D{primitive.E{Key: "$ne", Value: folder.ID}}},
+	}
+	moveNested := bson.A{bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "path", Value: bson.D{primitive.E{Key: "$concat", Value: bson.A{
+			nestedPrefix,
+			bson.D{primitive.E{Key: "$substrBytes", Value: bson.A{"$path", len(oldPrefix), bson.D{
+				primitive.E{Key: "$subtract", Value: bson.A{bson.D{primitive.E{Key: "$strLenBytes", Value: "$path"}}, len(oldPrefix)}},
+			}}}},
+		}}}},
+		primitive.E{Key: "ancestors", Value: bson.D{primitive.E{Key: "$concatArrays", Value: bson.A{
+			childAncestors,
+			bson.D{primitive.E{Key: "$slice", Value: bson.A{"$ancestors", len(folder.Ancestors) + 1, bson.D{
+				primitive.E{Key: "$size", Value: "$ancestors"},
+			}}}},
+		}}}},
+	}}}}
+	nestedResult, err := collection.UpdateMany(ctx, nested, moveNested)
+	if err != nil {
+		return 0, err
+	}
+	return int(childResult.ModifiedCount + nestedResult.ModifiedCount), nil
 }
@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/google/go-cmp/cmp"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEnrichUpdateUsesLiterals(t *testing.T) {
+	t.Parallel()
+	got := enrichUpdate(bookmarks.PageMetadata{Title: "$api_key", Description: "$$ROOT"})
+	want := bson.A{bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "name", Value: bson.D{primitive.E{Key: "$cond", Value: bson.A{
+			bson.D{primitive.E{Key: "$eq", Value: bson.A{bson.D{primitive.E{Key: "$ifNull", Value: bson.A{"$name", ""}}}, ""}}},
+			bson.D{primitive.E{Key: "$literal", Value: "$api_key"}},
+			"$name",
+		}}}},
+		primitive.E{Key: "description", Value: bson.D{primitive.E{Key: "$cond", Value: bson.A{
+			bson.D{primitive.E{Key: "$eq", Value: bson.A{bson.D{primitive.E{Key: "$ifNull", Value: bson.A{"$description", ""}}}, ""}}},
+			bson.D{primitive.E{Key: "$literal", Value: "$$ROOT"}},
+			"$description",
+		}}}},
+	}}}}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if update := enrichUpdate(bookmarks.PageMetadata{}); update != nil {
+		t.Errorf("wanted no update without metadata, got %v", update)
+	}
+}
+
+func TestStagedDocumentsHideBookmarksFromUser(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data, err := stagedDocuments([]bookmarks.Bookmark{{APIKey: "key", Name: "Example"}}, "job", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := data[0].(bson.D).Map()
+	if _, ok := doc["_id"].(primitive.ObjectID); !ok {
+		t.Errorf("wanted staged bookmark to be given an id, got %v", doc["_id"])
+	}
+	if doc["api_key"] != "" || doc["import_id"] != "job" || doc["staged_at"] != now {
+		t.Errorf("wanted staged bookmark to be marked with the import instead of the api key, got %v", doc)
+	}
+}
@@ -0,0 +1,213 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateBookmarkParents links bookmarks stored before folders were referenced by ID to the folders
+// their paths point to, creating any folders that are missing, and returns the number of bookmarks
+// migrated. Only accounts that still have unlinked bookmarks are migrated, so it is safe to run
+// more than once.
+func (m *Mongo) MigrateBookmarkParents(ctx context.Context) (int, error) {
+	collection := m.db.Collection(CollectionBookmarks)
+	unlinked := bson.D{primitive.E{Key: "parent_id", Value: bson.D{primitive.E{Key: "$exists", Value: false}}}}
+	APIKeys, err := collection.Distinct(ctx, "api_key", unlinked)
+	if err != nil {
+		return 0, err
+	}
+	numMigrated := 0
+	for _, k := range APIKeys {
+		APIKey, ok := k.(string)
+		if !ok {
+			continue
+		}
+		n, err := m.migrateBookmarkParents(ctx, collection, APIKey)
+		if err != nil {
+			return numMigrated, fmt.Errorf("could not migrate bookmarks for %s: %w", APIKey, err)
+		}
+		m.log.Infof("migrated %d bookmarks for %s", n, APIKey)
+		numMigrated += n
+	}
+	return numMigrated, nil
+}
+
+// migrateBookmarkParents links all of a users bookmarks to their folders in a single transaction.
+func (m *Mongo) migrateBookmarkParents(ctx context.Context, collection *mongo.Collection, APIKey string) (int, error) {
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		cursor, err := collection.Find(sessCtx, bson.D{primitive.E{Key: "api_key", Value: APIKey}})
+		if err != nil {
+			return nil, err
+		}
+		var books []bookmarks.Bookmark
+		if err := cursor.All(sessCtx, &books); err != nil {
+			return nil, err
+		}
+		created := bookmarks.MigrateParents(books, APIKey, time.Now().UTC())
+		models, err := parentUpdates(books)
+		if err != nil {
+			return nil, err
+		}
+		if len(models) > 0 {
+			if _, err := collection.BulkWrite(sessCtx, models); err != nil {
+				return nil, err
+			}
+		}
+		if len(created) > 0 {
+			data, err := bookmarkDocuments(created, APIKey)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := collection.InsertMany(sessCtx, data); err != nil {
+				return nil, err
+			}
+		}
+		return len(models), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	numMigrated, ok := res.(int)
+	if !ok {
+		return 0, fmt.Errorf("could not get number of migrated bookmarks from transaction result")
+	}
+	return numMigrated, nil
+}
+
+// MigrateTrashParents links bookmarks deleted before folders were referenced by ID to the folders they
+// were deleted with, and directly deleted bookmarks to the folders their paths point to if they still
+// exist, returning the number of bookmarks migrated. It must be run after MigrateBookmarkParents, and
+// only the bookmarks in the trash that are still unlinked are migrated.
+func (m *Mongo) MigrateTrashParents(ctx context.Context) (int, error) {
+	trash := m.db.Collection(CollectionTrash)
+	unlinked := bson.D{primitive.E{Key: "parent_id", Value: bson.D{primitive.E{Key: "$exists", Value: false}}}}
+	APIKeys, err := trash.Distinct(ctx, "api_key", unlinked)
+	if err != nil {
+		return 0, err
+	}
+	numMigrated := 0
+	for _, k := range APIKeys {
+		APIKey, ok := k.(string)
+		if !ok {
+			continue
+		}
+		n, err := m.migrateTrashParents(ctx, trash, APIKey)
+		if err != nil {
+			return numMigrated, fmt.Errorf("could not migrate trash for %s: %w", APIKey, err)
+		}
+		m.log.Infof("migrated %d deleted bookmarks for %s", n, APIKey)
+		numMigrated += n
+	}
+	return numMigrated, nil
+}
+
+// migrateTrashParents links the unlinked bookmarks in a users trash, along with the rest of the
+// bookmarks deleted with them, in a single transaction.
+func (m *Mongo) migrateTrashParents(ctx context.Context, trash *mongo.Collection, APIKey string) (int, error) {
+	res, err := m.SessionWithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		filter := bson.D{
+			primitive.E{Key: "api_key", Value: APIKey},
+			primitive.E{Key: "parent_id", Value: bson.D{primitive.E{Key: "$exists", Value: false}}},
+		}
+		trashIDs, err := trash.Distinct(sessCtx, "trash_id", filter)
+		if err != nil {
+			return nil, err
+		}
+		groups := bson.D{
+			primitive.E{Key: "api_key", Value: APIKey},
+			primitive.E{Key: "trash_id", Value: bson.D{primitive.E{Key: "$in", Value: trashIDs}}},
+		}
+		cursor, err := trash.Find(sessCtx, groups)
+		if err != nil {
+			return nil, err
+		}
+		var trashed []bookmarks.Bookmark
+		if err := cursor.All(sessCtx, &trashed); err != nil {
+			return nil, err
+		}
+		cursor, err = m.db.Collection(CollectionBookmarks).Find(sessCtx, bson.D{primitive.E{Key: "api_key", Value: APIKey}})
+		if err != nil {
+			return nil, err
+		}
+		var books []bookmarks.Bookmark
+		if err := cursor.All(sessCtx, &books); err != nil {
+			return nil, err
+		}
+		created := bookmarks.MigrateTrashParents(trashed, books, APIKey, time.Now().UTC())
+		models, err := parentUpdates(trashed)
+		if err != nil {
+			return nil, err
+		}
+		if len(models) > 0 {
+			if _, err := trash.BulkWrite(sessCtx, models); err != nil {
+				return nil, err
+			}
+		}
+		if len(created) > 0 {
+			data, err := trashedFolderDocuments(created)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := trash.InsertMany(sessCtx, data); err != nil {
+				return nil, err
+			}
+		}
+		return len(models), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	numMigrated, ok := res.(int)
+	if !ok {
+		return 0, fmt.Errorf("could not get number of migrated bookmarks from transaction result")
+	}
+	return numMigrated, nil
+}
+
+// parentUpdates returns the updates that store the folders books are linked to.
+func parentUpdates(books []bookmarks.Bookmark) ([]mongo.WriteModel, error) {
+	models := make([]mongo.WriteModel, 0, len(books))
+	for _, b := range books {
+		oid, err := primitive.ObjectIDFromHex(b.ID)
+		if err != nil {
+			return nil, err
+		}
+		ancestors := b.Ancestors
+		if ancestors == nil {
+			ancestors = []string{}
+		}
+		filter := bson.D{primitive.E{Key: "_id", Value: oid}}
+		update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+			primitive.E{Key: "parent_id", Value: b.ParentID},
+			primitive.E{Key: "ancestors", Value: ancestors},
+			primitive.E{Key: "path", Value: b.Path},
+		}}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+	return models, nil
+}
+
+// trashedFolderDocuments converts folders created in the trash into documents to insert, storing
+// the trash IDs as ObjectIDs as trashDocument does.
+func trashedFolderDocuments(folders []bookmarks.Bookmark) ([]interface{}, error) {
+	data := make([]interface{}, len(folders))
+	for i, f := range folders {
+		trashID, err := primitive.ObjectIDFromHex(f.TrashID)
+		if err != nil {
+			return nil, err
+		}
+		f.TrashID = ""
+		doc, err := bookmarkDocument(f)
+		if err != nil {
+			return nil, err
+		}
+		data[i] = append(doc.(bson.D), primitive.E{Key: "trash_id", Value: trashID})
+	}
+	return data, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/conalli/bookshelf-backend/pkg/apierr"
 	"github.com/conalli/bookshelf-backend/pkg/logs"
 	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -22,6 +23,9 @@ const (
 	CollectionTeams     = "teams"
 	CollectionBookmarks = "bookmarks"
 	CollectionTokens    = "tokens"
+	CollectionTrash     = "trash"
+	CollectionSnapshots = "snapshots"
+	CollectionShares    = "shares"
 )
 
 // Mongo represents a Mongodb client and database.
@@ -40,6 +44,8 @@ func resolveEnv(envType string) string {
 		return os.Getenv("LOCAL_MONGO_URI")
 	case "db":
 		return os.Getenv("DB_NAME")
+	case "trash_retention":
+		return os.Getenv("TRASH_RETENTION_DAYS")
 	default:
 		return ""
 	}
@@ -53,7 +59,21 @@ func New(ctx context.Context, logger logs.Logger) *Mongo {
 	if err != nil {
 		logger.Fatalf("could not connect to mongo client: %v", err)
 	}
-	return &Mongo{log: logger, client: client, db: client.Database(db)}
+	m := &Mongo{log: logger, client: client, db: client.Database(db)}
+	if err := m.createBookmarkIndexes(ctx); err != nil {
+		logger.Errorf("could not create bookmark indexes: %v", err)
+	}
+	retention := bookmarks.ParseTrashRetention(resolveEnv("trash_retention"))
+	if err := m.createTrashIndexes(ctx, retention); err != nil {
+		logger.Errorf("could not create trash indexes: %v", err)
+	}
+	if err := m.createSnapshotIndexes(ctx); err != nil {
+		logger.Errorf("could not create snapshot indexes: %v", err)
+	}
+	if err := m.createShareIndexes(ctx); err != nil {
+		logger.Errorf("could not create share indexes: %v", err)
+	}
+	return m
 }
 
 func (m *Mongo) Disconnect(ctx context.Context) {
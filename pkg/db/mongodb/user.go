@@ -102,6 +102,27 @@ func (m *Mongo) GetUserByEmail(ctx context.Context, email string) (accounts.User
 	return m.DecodeUser(res)
 }
 
+// UpdateSettings saves the users settings, returning the number of users updated.
+func (m *Mongo) UpdateSettings(ctx context.Context, settings accounts.Settings, APIKey string) (int, apierr.Error) {
+	collection := m.db.Collection(CollectionUsers)
+	filter := bson.D{primitive.E{Key: "api_key", Value: APIKey}}
+	update := bson.D{primitive.E{Key: "$set", Value: bson.D{
+		primitive.E{Key: "search_engine", Value: settings.SearchEngine},
+		primitive.E{Key: "search_url", Value: settings.SearchURL},
+		primitive.E{Key: "cmd_matching", Value: settings.CmdMatching},
+	}}}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		m.log.Errorf("could not update user settings: %v", err)
+		return 0, apierr.NewInternalServerError()
+	}
+	if result.MatchedCount == 0 {
+		m.log.Error("couldn't find user with given APIKey")
+		return 0, apierr.NewNotFoundError("could not find user")
+	}
+	return int(result.MatchedCount), nil
+}
+
 // GetAllCmds uses req info to get all users current cmds from the db.
 func (m *Mongo) GetAllCmds(ctx context.Context, APIKey string) (map[string]string, apierr.Error) {
 	collection := m.db.Collection(CollectionUsers)
@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-redis/redis/v8"
+)
+
+// GetImportJob gets the progress of a users bookmark import job from redis.
+func (r *Redis) GetImportJob(ctx context.Context, APIKey, jobID string) (*bookmarks.ImportJob, error) {
+	redisKey := generateRedisKey(KeyTypeImportJob, APIKey+":"+jobID)
+	result, err := r.rdb.Get(ctx, redisKey).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, bookmarks.ErrImportJobNotFound
+		}
+		r.log.Errorf("could not retrieve import job from cache: %+v", err)
+		return nil, err
+	}
+	var job bookmarks.ImportJob
+	if err := json.Unmarshal(result, &job); err != nil {
+		r.log.Errorf("could not decode import job from cache: %+v", err)
+		return nil, err
+	}
+	job.APIKey = APIKey
+	return &job, nil
+}
+
+// SetImportJob saves the progress of a bookmark import job in redis, expiring it after ttl.
+func (r *Redis) SetImportJob(ctx context.Context, job *bookmarks.ImportJob, ttl time.Duration) error {
+	redisKey := generateRedisKey(KeyTypeImportJob, job.APIKey+":"+job.ID)
+	data, err := json.Marshal(job)
+	if err != nil {
+		r.log.Errorf("could not encode import job: %+v", err)
+		return err
+	}
+	if err := r.rdb.Set(ctx, redisKey, data, ttl).Err(); err != nil {
+		r.log.Errorf("could not set import job in redis: %+v", err)
+		return err
+	}
+	return nil
+}
@@ -12,6 +12,7 @@ const (
 	KeyTypeUser      string = "user"
 	KeyTypeCmd       string = "cmds"
 	KeyTypeBookmarks string = "bookmarks"
+	KeyTypeImportJob string = "import"
 )
 
 // Cache represents the redis caching client.
@@ -76,5 +76,8 @@ func userToMap(user accounts.User) map[string]interface{} {
 	data["email_verified"] = user.EmailVerified
 	data["locale"] = user.Locale
 	data["provider"] = user.Provider
+	data["search_engine"] = user.SearchEngine
+	data["search_url"] = user.SearchURL
+	data["cmd_matching"] = user.CmdMatching
 	return data
 }
@@ -1,5 +1,7 @@
 package request
 
+import "time"
+
 // AddCmd represents the expected JSON request for the user/cmd POST endpoint.
 type AddCmd struct {
 	ID  string `json:"id" validate:"len=24,hexadecimal"`
@@ -13,12 +15,31 @@ type DeleteCmd struct {
 	Cmd string `json:"cmd" validate:"min=1,max=30"`
 }
 
-// AddBookmark represents the expected JSON request for the user/bookmark POST endpoint.
+// AddBookmark represents the expected JSON request for the user/bookmark POST endpoint. The bookmark
+// is added to the folder ParentID or, if it is empty, to the folder at Path.
 type AddBookmark struct {
 	Name     string `json:"name,omitempty" validate:"max=30"`
+	ParentID string `json:"parent_id,omitempty" validate:"omitempty,len=24,hexadecimal"`
 	Path     string `json:"path" validate:"max=100"`
 	URL      string `json:"url" validate:"max=200"`
 	IsFolder bool   `json:"is_folder"`
+	// SkipEnrichment stops the bookmark name, description and icons being filled in from the page.
+	SkipEnrichment bool `json:"skip_enrichment,omitempty"`
+}
+
+// UpdateBookmark represents the expected JSON request for the bookmark/{id} PATCH endpoint.
+// Only the fields that are present are updated. A bookmark is moved to the folder ParentID, or to the
+// top level if it is empty, and Path is only used when ParentID is not present.
+type UpdateBookmark struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,max=30"`
+	ParentID *string `json:"parent_id,omitempty" validate:"omitempty,len=24,hexadecimal"`
+	Path     *string `json:"path,omitempty" validate:"omitempty,max=100"`
+	URL      *string `json:"url,omitempty" validate:"omitempty,max=200"`
+}
+
+// BookmarkTags represents the expected JSON request for the bookmark/{id}/tags POST and DELETE endpoints.
+type BookmarkTags struct {
+	Tags []string `json:"tags" validate:"min=1,max=20,dive,min=1,max=30"`
 }
 
 // DeleteBookmark represents the expected JSON request for the user/bookmark POST endpoint.
@@ -28,6 +49,27 @@ type DeleteBookmark struct {
 	URL  string `json:"url,omitempty" validate:"max=200"`
 }
 
+// ReorderBookmarks represents the body of a request to change the order of the bookmarks in a folder.
+type ReorderBookmarks struct {
+	FolderID string   `json:"folder_id,omitempty" validate:"omitempty,len=24,hexadecimal"`
+	IDs      []string `json:"ids" validate:"min=1,max=1000,dive,len=24,hexadecimal"`
+}
+
+// MergeBookmarks represents the body of a request to merge duplicate bookmarks into Keep.
+type MergeBookmarks struct {
+	Keep string   `json:"keep" validate:"len=24,hexadecimal"`
+	IDs  []string `json:"ids,omitempty" validate:"max=100,dive,len=24,hexadecimal"`
+}
+
+// UpdateSettings represents the expected JSON request for the user/settings PUT endpoint. SearchURL is
+// a cmd url template used by the custom search engine, and when searching bookmarks first finds nothing.
+// CmdMatching decides what happens when a cmd is not found, and defaults to auto.
+type UpdateSettings struct {
+	SearchEngine string `json:"search_engine" validate:"oneof=google duckduckgo kagi bing custom bookmarks"`
+	SearchURL    string `json:"search_url,omitempty" validate:"max=200"`
+	CmdMatching  string `json:"cmd_matching,omitempty" validate:"omitempty,oneof=auto suggest off"`
+}
+
 // DeleteUser represents the expected JSON request for the user DELETE endpoint.
 type DeleteUser struct {
 	ID       string `json:"id" validate:"len=24,hexadecimal"`
@@ -86,3 +128,14 @@ type DeleteTeamCmd struct {
 	MemberID string `json:"memberId"`
 	Cmd      string `json:"cmd"`
 }
+
+// CreateSnapshot represents the expected JSON request for the bookmark/snapshots POST endpoint.
+type CreateSnapshot struct {
+	Name string `json:"name,omitempty" validate:"max=100"`
+}
+
+// ShareFolder represents the expected JSON request for the bookmark/{id}/share POST endpoint. A share
+// without ExpiresAt lasts until it is revoked.
+type ShareFolder struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
@@ -8,7 +8,7 @@ import (
 
 // APIRequest represents all API Request types
 type APIRequest interface {
-	SignUp | LogIn | DeleteUser | AddCmd | DeleteCmd | AddBookmark | DeleteBookmark
+	SignUp | LogIn | DeleteUser | AddCmd | DeleteCmd | AddBookmark | UpdateBookmark | BookmarkTags | DeleteBookmark | MergeBookmarks | ReorderBookmarks | CreateSnapshot | ShareFolder | UpdateSettings
 }
 
 // FilterCookies looks through all cookies and returns cookie with given name.
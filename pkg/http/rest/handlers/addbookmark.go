@@ -15,6 +15,7 @@ type AddBookmarkResponse struct {
 	ID       string `json:"id"`
 	NumAdded int    `json:"num_added"`
 	Name     string `json:"name,omitempty"`
+	ParentID string `json:"parent_id"`
 	Path     string `json:"path"`
 	URL      string `json:"url"`
 }
@@ -34,25 +35,21 @@ func AddBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWrite
 			apierr.APIErrorResponse(w, errRes)
 			return
 		}
-		numUpdated, err := b.AddBookmark(r.Context(), addBookReq, APIKey)
+		bookmark, err := b.AddBookmark(r.Context(), addBookReq, APIKey)
 		if err != nil {
 			log.Errorf("error returned while trying to add a new bookmark: %v", err)
 			apierr.APIErrorResponse(w, err)
 			return
 		}
-		if numUpdated == 0 {
-			log.Error("could not add bookmark")
-			err := apierr.NewBadRequestError("error: could not add bookmark")
-			apierr.APIErrorResponse(w, err)
-			return
-		}
 		log.Info("successfully added bookmark")
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		res := AddBookmarkResponse{
-			NumAdded: numUpdated,
+			ID:       bookmark.ID,
+			NumAdded: 1,
 			Name:     addBookReq.Name,
-			Path:     addBookReq.Path,
+			ParentID: bookmark.ParentID,
+			Path:     bookmark.Path,
 			URL:      addBookReq.URL,
 		}
 		json.NewEncoder(w).Encode(res)
@@ -2,14 +2,17 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	tu "github.com/conalli/bookshelf-backend/internal/testutils"
 	"github.com/conalli/bookshelf-backend/pkg/apierr"
 	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
@@ -29,10 +32,11 @@ func TestAddBookmark(t *testing.T) {
 		{
 			name: "Default user",
 			req: request.AddBookmark{
-				Name:     "yt",
-				Path:     ",Google,",
-				URL:      "https://www.youtube.com",
-				IsFolder: false,
+				Name:           "yt",
+				Path:           ",Google,",
+				URL:            "https://www.youtube.com",
+				IsFolder:       false,
+				SkipEnrichment: true,
 			},
 			APIKey:     db.Users["1"].APIKey,
 			statusCode: 200,
@@ -40,10 +44,11 @@ func TestAddBookmark(t *testing.T) {
 		{
 			name: "User doesn't exist",
 			req: request.AddBookmark{
-				Name:     "yt",
-				Path:     ",Google,",
-				URL:      "https://www.youtube.com",
-				IsFolder: false,
+				Name:           "yt",
+				Path:           ",Google,",
+				URL:            "https://www.youtube.com",
+				IsFolder:       false,
+				SkipEnrichment: true,
 			},
 			APIKey:     uuid.New().String(),
 			statusCode: 400,
@@ -83,3 +88,68 @@ func TestAddBookmark(t *testing.T) {
 		})
 	}
 }
+
+func TestAddBookmarkEnrichment(t *testing.T) {
+	t.Parallel()
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Go Blog</title><meta name="description" content="News about Go."><link rel="icon" href="/go.ico"></head></html>`))
+	}))
+	defer site.Close()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	add := func(req request.AddBookmark) string {
+		body, err := tu.MakeJSONRequestBody(req)
+		if err != nil {
+			t.Fatalf("Couldn't create add bookmark request body")
+		}
+		res, err := tu.RequestWithCookie("POST", APIURL, tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to add bookmark with cookie")
+		}
+		defer res.Body.Close()
+		var response handlers.AddBookmarkResponse
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			t.Fatalf("Couldn't decode json body upon adding bookmark")
+		}
+		return response.ID
+	}
+	enrichedID := add(request.AddBookmark{Path: ",News,", URL: site.URL + "/blog"})
+	skippedID := add(request.AddBookmark{Path: ",News,", URL: site.URL + "/skip", SkipEnrichment: true})
+
+	find := func(id string) bookmarks.Bookmark {
+		res, err := tu.RequestWithCookie("GET", APIURL+"/list", tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to list bookmarks with cookie")
+		}
+		defer res.Body.Close()
+		var page bookmarks.BookmarkPage
+		if err := json.NewDecoder(res.Body).Decode(&page); err != nil {
+			t.Fatalf("Couldn't decode json body upon listing bookmarks")
+		}
+		for _, b := range page.Bookmarks {
+			if b.ID == id {
+				return b
+			}
+		}
+		t.Fatalf("Expected bookmark %s to be listed", id)
+		return bookmarks.Bookmark{}
+	}
+	var enriched bookmarks.Bookmark
+	deadline := time.Now().Add(10 * time.Second)
+	for len(enriched.Name) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+		enriched = find(enrichedID)
+	}
+	if enriched.Name != "Go Blog" || enriched.Description != "News about Go." || enriched.IconURI != site.URL+"/go.ico" {
+		t.Errorf("Expected bookmark to be enriched from page metadata: got %+v", enriched)
+	}
+	if skipped := find(skippedID); len(skipped.Name) > 0 || len(skipped.Description) > 0 {
+		t.Errorf("Expected bookmark added with skip_enrichment not to be enriched: got %+v", skipped)
+	}
+}
@@ -9,9 +9,15 @@ import (
 	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/logs"
 	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
 )
 
-// AddBookmarksFile attempts to add bookmarks to user from a given HTML file.
+// bookmarksFileMaxMemory is how much of a bookmarks file upload is kept in memory, with the rest
+// stored in temporary files.
+const bookmarksFileMaxMemory int64 = 10 << 20
+
+// AddBookmarksFile starts importing bookmarks to user from a given bookmarks file in the background,
+// returning the import job so that its progress can be polled.
 func AddBookmarksFile(b bookmarks.Service, log logs.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
@@ -27,26 +33,44 @@ func AddBookmarksFile(b bookmarks.Service, log logs.Logger) http.HandlerFunc {
 			return
 		}
 		r.Body = http.MaxBytesReader(w, r.Body, bookmarks.BookmarksFileMaxSize)
-		err := r.ParseMultipartForm(200_000)
+		err := r.ParseMultipartForm(bookmarksFileMaxMemory)
 		if err != nil {
 			log.Errorf("Could not parse multipart form: %v", err)
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
-		num, apiErr := b.AddBookmarksFromFile(r.Context(), r, APIKey)
+		job, apiErr := b.AddBookmarksFromFile(r.Context(), r, APIKey)
 		if apiErr != nil {
 			log.Errorf("Could not add bookmarks from file: %v", apiErr)
 			apierr.APIErrorResponse(w, apiErr)
 			return
 		}
-		log.Info(num)
+		log.Infof("started bookmarks file import: %s", job.ID)
 		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		res := struct {
-			NumAdded int `json:"num_added"`
-		}{
-			NumAdded: num,
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// GetImportJob is the handler for the bookmark/import/{jobID} GET endpoint. Returns the progress of
+// one of the users bookmark imports.
+func GetImportJob(b bookmarks.Service, log logs.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		jobID := mux.Vars(r)["jobID"]
+		job, err := b.GetImportJob(r.Context(), jobID, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get import job: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
 		}
-		json.NewEncoder(w).Encode(res)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(job)
 	}
 }
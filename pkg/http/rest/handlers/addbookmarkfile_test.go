@@ -2,12 +2,21 @@ package handlers_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	tu "github.com/conalli/bookshelf-backend/internal/testutils"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 func TestAddBookmarkFile(t *testing.T) {
@@ -16,25 +25,96 @@ func TestAddBookmarkFile(t *testing.T) {
 	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
 	srv := httptest.NewServer(r.Handler())
 	defer srv.Close()
+	safariPath := "../../../../internal/testdata/bookmarks/safaribookmarks_basic.html"
+	longURL := "https://example.com/search?q=" + strings.Repeat("a", 2100)
 	tc := []struct {
 		name       string
 		path       string
+		filename   string
+		mode       string
 		APIKey     string
 		statusCode int
-		want       int
+		want       bookmarks.ImportSummary
+		errors     []bookmarks.ImportError
 	}{
 		{
 			name:       "default user, correct request",
-			path:       "../../../../internal/testdata/bookmarks/safaribookmarks_basic.html",
+			path:       safariPath,
+			filename:   "safaribookmarks_basic.html",
 			APIKey:     db.Users["1"].APIKey,
-			statusCode: 200,
-			want:       15,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeMerge, Added: 15},
+		},
+		{
+			name:       "default user, same file merged again",
+			path:       safariPath,
+			filename:   "safaribookmarks_basic.html",
+			mode:       bookmarks.BookmarksImportModeMerge,
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeMerge, Skipped: 15},
+		},
+		{
+			name:       "default user, chrome json",
+			path:       "../../../../internal/testdata/bookmarks/chromebookmarks.json",
+			filename:   "Bookmarks",
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeMerge, Added: 10},
+		},
+		{
+			name:       "default user, firefox json with existing Bookmarks Menu folder",
+			path:       "../../../../internal/testdata/bookmarks/firefoxbookmarks.json",
+			filename:   "bookmarks-2022-07-01.json",
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeMerge, Added: 9, Skipped: 1},
+		},
+		{
+			name:       "default user, same file appended",
+			path:       safariPath,
+			filename:   "safaribookmarks_basic.html",
+			mode:       bookmarks.BookmarksImportModeAppend,
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeAppend, Added: 15},
+		},
+		{
+			name:       "default user, bookmark with too long url",
+			path:       "../../../../internal/testdata/bookmarks/longurls.html",
+			filename:   "longurls.html",
+			mode:       bookmarks.BookmarksImportModeAppend,
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeAppend, Added: 3, Failed: 1},
+			errors:     []bookmarks.ImportError{{Name: "Long search", Path: ",Reading,", URL: longURL, Error: "url too long"}},
+		},
+		{
+			name:       "default user, replace all bookmarks",
+			path:       safariPath,
+			filename:   "safaribookmarks_basic.html",
+			mode:       bookmarks.BookmarksImportModeReplace,
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 202,
+			want:       bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeReplace, Added: 15},
+		},
+		{
+			name:       "default user, unknown mode",
+			path:       safariPath,
+			filename:   "safaribookmarks_basic.html",
+			mode:       "overwrite",
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 400,
 		},
 	}
 	APIURL := srv.URL + "/api/bookmark/file"
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
-			file, ct, err := tu.MakeFileRequestBody(c.path, "safaribookmarks_basic.html")
+			fields := map[string]string{}
+			if len(c.mode) > 0 {
+				fields[bookmarks.BookmarksImportModeKey] = c.mode
+			}
+			file, ct, err := tu.MakeFileRequestBodyWithFields(c.path, c.filename, fields)
 			if err != nil {
 				t.Fatalf("could not create request body: %v", err)
 			}
@@ -49,16 +129,229 @@ func TestAddBookmarkFile(t *testing.T) {
 			if c.statusCode != res.StatusCode {
 				t.Errorf("expected status code %d: got %d", c.statusCode, res.StatusCode)
 			}
-			var got struct {
-				NumAdded int `json:"num_added"`
+			if res.StatusCode >= 400 {
+				return
 			}
-			err = json.NewDecoder(res.Body).Decode(&got)
+			var job bookmarks.ImportJob
+			err = json.NewDecoder(res.Body).Decode(&job)
 			if err != nil {
 				t.Fatalf("couldn't decode api response: %v", err)
 			}
-			if c.want != got.NumAdded {
-				t.Errorf("wanted: %d, got: %d", c.want, got.NumAdded)
+			if job.Status != bookmarks.ImportStatusQueued || len(job.ID) == 0 {
+				t.Errorf("expected a queued import job: got %+v", job)
+			}
+			got := waitForImport(t, srv.URL+"/api/bookmark/import/"+job.ID, c.APIKey)
+			if got.Status != bookmarks.ImportStatusDone {
+				t.Fatalf("expected import job to be done: got %+v", got)
+			}
+			if !cmp.Equal(c.want, got.ImportSummary) {
+				t.Error(cmp.Diff(c.want, got.ImportSummary))
+			}
+			if !cmp.Equal(c.errors, got.Errors) {
+				t.Error(cmp.Diff(c.errors, got.Errors))
 			}
 		})
 	}
+	numBookmarks := 0
+	for _, b := range db.Bookmarks {
+		if b.APIKey == db.Users["1"].APIKey {
+			numBookmarks++
+		}
+	}
+	if numBookmarks != 15 {
+		t.Errorf("expected 15 bookmarks after replacing all bookmarks: got %d", numBookmarks)
+	}
+}
+
+func TestGetImportJob(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	cache := tu.NewCache()
+	cache.ImportJobs[APIKey+":0b6a1c3e-8f1d-4a53-9d6e-2f4f1a7c9b10"] = bookmarks.ImportJob{
+		ID:            "0b6a1c3e-8f1d-4a53-9d6e-2f4f1a7c9b10",
+		APIKey:        APIKey,
+		Status:        bookmarks.ImportStatusRunning,
+		ImportSummary: bookmarks.ImportSummary{Mode: bookmarks.BookmarksImportModeMerge, Added: 500},
+		Parsed:        612,
+	}
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, cache, nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	tc := []struct {
+		name       string
+		jobID      string
+		APIKey     string
+		statusCode int
+	}{
+		{name: "running job", jobID: "0b6a1c3e-8f1d-4a53-9d6e-2f4f1a7c9b10", APIKey: APIKey, statusCode: 200},
+		{name: "job of another user", jobID: "0b6a1c3e-8f1d-4a53-9d6e-2f4f1a7c9b10", APIKey: uuid.New().String(), statusCode: 404},
+		{name: "unknown job", jobID: uuid.New().String(), APIKey: APIKey, statusCode: 404},
+		{name: "invalid job id", jobID: "job", APIKey: APIKey, statusCode: 400},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := tu.RequestWithCookie("GET", srv.URL+"/api/bookmark/import/"+c.jobID, tu.WithAPIKey(c.APIKey))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			if c.statusCode != res.StatusCode {
+				t.Fatalf("expected status code %d: got %d", c.statusCode, res.StatusCode)
+			}
+			if res.StatusCode >= 400 {
+				return
+			}
+			var job bookmarks.ImportJob
+			if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+				t.Fatalf("couldn't decode api response: %v", err)
+			}
+			if job.Status != bookmarks.ImportStatusRunning || job.Parsed != 612 || job.Added != 500 {
+				t.Errorf("expected running job progress: got %+v", job)
+			}
+		})
+	}
+}
+
+// waitForImport polls an import job until it has finished.
+func waitForImport(t *testing.T, URL, APIKey string) bookmarks.ImportJob {
+	t.Helper()
+	var job bookmarks.ImportJob
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		res, err := tu.RequestWithCookie("GET", URL, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("couldn't create request to get import job: %v", err)
+		}
+		err = json.NewDecoder(res.Body).Decode(&job)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("couldn't decode import job: %v", err)
+		}
+		if job.Status == bookmarks.ImportStatusDone || job.Status == bookmarks.ImportStatusFailed {
+			return job
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("import job did not finish: got %+v", job)
+	return job
+}
+
+func TestAddBookmarkFileReplaceFailsAtomically(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	original := slices.Clone(db.Bookmarks)
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	// More than one batch of bookmarks is parsed before a folder without a name fails to parse.
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n<DL><p>\n<DT><H3>Imported</H3>\n<DL><p>\n")
+	for i := 0; i < bookmarks.BookmarksImportBatchSize+100; i++ {
+		fmt.Fprintf(&sb, "<DT><A HREF=\"https://example.com/%d\">Example %d</A>\n", i, i)
+	}
+	sb.WriteString("<DT><H3><A HREF=\"https://example.com/\">Not a name</A></H3>\n</DL><p>\n</DL><p>\n")
+	path := filepath.Join(t.TempDir(), "broken.html")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]string{bookmarks.BookmarksImportModeKey: bookmarks.BookmarksImportModeReplace}
+	file, ct, err := tu.MakeFileRequestBodyWithFields(path, "broken.html", fields)
+	if err != nil {
+		t.Fatalf("could not create request body: %v", err)
+	}
+	res, err := tu.RequestWithCookie("POST", srv.URL+"/api/bookmark/file", tu.WithHeaders(map[string]string{"Content-Type": ct}), tu.WithBody(file), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 202 {
+		t.Fatalf("expected status code 202: got %d", res.StatusCode)
+	}
+	var job bookmarks.ImportJob
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		t.Fatalf("couldn't decode api response: %v", err)
+	}
+	got := waitForImport(t, srv.URL+"/api/bookmark/import/"+job.ID, APIKey)
+	if got.Status != bookmarks.ImportStatusFailed || got.Added != 0 {
+		t.Errorf("expected import job to fail without adding bookmarks: got %+v", got)
+	}
+	if !cmp.Equal(original, db.Bookmarks) {
+		t.Errorf("expected the original bookmarks to be kept: %s", cmp.Diff(original, db.Bookmarks))
+	}
+	if staged := len(db.Staged[job.ID]); staged != 0 {
+		t.Errorf("expected staged bookmarks to be discarded: got %d", staged)
+	}
+}
+
+func TestAddBookmarkFileReplaceLargeFile(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	numFolders, perFolder := 20, bookmarks.BookmarksImportBatchSize/2
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE NETSCAPE-Bookmark-file-1>\n<DL><p>\n")
+	for f := 0; f < numFolders; f++ {
+		fmt.Fprintf(&sb, "<DT><H3>Folder %d</H3>\n<DL><p>\n", f)
+		for i := 0; i < perFolder; i++ {
+			fmt.Fprintf(&sb, "<DT><A HREF=\"https://example.com/%d/%d\">Example %d</A>\n", f, i, i)
+		}
+		sb.WriteString("</DL><p>\n")
+	}
+	sb.WriteString("</DL><p>\n")
+	path := filepath.Join(t.TempDir(), "large.html")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]string{bookmarks.BookmarksImportModeKey: bookmarks.BookmarksImportModeReplace}
+	file, ct, err := tu.MakeFileRequestBodyWithFields(path, "large.html", fields)
+	if err != nil {
+		t.Fatalf("could not create request body: %v", err)
+	}
+	res, err := tu.RequestWithCookie("POST", srv.URL+"/api/bookmark/file", tu.WithHeaders(map[string]string{"Content-Type": ct}), tu.WithBody(file), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 202 {
+		t.Fatalf("expected status code 202: got %d", res.StatusCode)
+	}
+	var job bookmarks.ImportJob
+	if err := json.NewDecoder(res.Body).Decode(&job); err != nil {
+		t.Fatalf("couldn't decode api response: %v", err)
+	}
+	want := numFolders * (perFolder + 1)
+	got := waitForImport(t, srv.URL+"/api/bookmark/import/"+job.ID, APIKey)
+	if got.Status != bookmarks.ImportStatusDone || got.Added != want || got.Failed != 0 {
+		t.Fatalf("expected %d bookmarks to be added: got %+v", want, got)
+	}
+	if staged := len(db.Staged[job.ID]); staged != 0 {
+		t.Errorf("expected no bookmarks to be left staged: got %d", staged)
+	}
+	folders := map[string]string{}
+	numBookmarks := 0
+	for _, b := range db.Bookmarks {
+		if b.APIKey != APIKey {
+			continue
+		}
+		numBookmarks++
+		if b.IsFolder {
+			folders[b.ID] = b.Name
+		}
+		if b.Name == "bbc" {
+			t.Errorf("expected the original bookmarks to be replaced: got %+v", b)
+		}
+	}
+	if numBookmarks != want || len(folders) != numFolders {
+		t.Errorf("expected %d bookmarks in %d folders: got %d in %d", want, numFolders, numBookmarks, len(folders))
+	}
+	for _, b := range db.Bookmarks {
+		if !b.IsFolder && b.APIKey == APIKey && !strings.HasPrefix(b.URL, "https://example.com/"+strings.TrimPrefix(folders[b.ParentID], "Folder ")+"/") {
+			t.Errorf("expected bookmark to be linked to its folder: got %+v", b)
+		}
+	}
 }
@@ -34,6 +34,26 @@ func TestAddCmd(t *testing.T) {
 			APIKey:     db.Users["1"].APIKey,
 			statusCode: 200,
 		},
+		{
+			name: "Default User, url template",
+			req: request.AddCmd{
+				ID:  db.Users["1"].ID,
+				Cmd: "gh",
+				URL: "https://github.com/{user}/{repo:bookshelf-backend}",
+			},
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 200,
+		},
+		{
+			name: "Default User, invalid url template",
+			req: request.AddCmd{
+				ID:  db.Users["1"].ID,
+				Cmd: "go",
+				URL: "https://pkg.go.dev/{1",
+			},
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 400,
+		},
 	}
 	APIURL := srv.URL + "/api/user/cmd"
 	for _, c := range tc {
@@ -49,6 +69,9 @@ func TestAddCmd(t *testing.T) {
 			if res.StatusCode != c.statusCode {
 				t.Errorf("Expected add cmd request to give status code %d: got %d", c.statusCode, res.StatusCode)
 			}
+			if res.StatusCode >= 400 {
+				return
+			}
 			var response handlers.AddCmdResponse
 			err = json.NewDecoder(res.Body).Decode(&response)
 			if err != nil {
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
+)
+
+// GetTags is the handler for the bookmark/tags GET endpoint. Returns each of the users tags along with
+// the number of bookmarks using it.
+func GetTags(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		tags, err := b.GetTags(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get tags: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved tags")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(tags)
+	}
+}
+
+// AddTags is the handler for the bookmark/{id}/tags POST endpoint.
+func AddTags(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return updateTags(b.AddTags, "add", log)
+}
+
+// RemoveTags is the handler for the bookmark/{id}/tags DELETE endpoint.
+func RemoveTags(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return updateTags(b.RemoveTags, "remove", log)
+}
+
+type updateTagsFunc func(ctx context.Context, bookmarkID string, requestData request.BookmarkTags, APIKey string) (int, apierr.Error)
+
+func updateTags(update updateTagsFunc, action string, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		tagsReq, parseErr := request.DecodeJSONRequest[request.BookmarkTags](r.Body)
+		if parseErr != nil {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		bookmarkID := mux.Vars(r)["id"]
+		numUpdated, err := update(r.Context(), bookmarkID, tagsReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to %s bookmark tags: %v", action, err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully updated bookmark tags")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		res := UpdateBookmarkResponse{
+			ID:         bookmarkID,
+			NumUpdated: numUpdated,
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
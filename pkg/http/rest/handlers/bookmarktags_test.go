@@ -0,0 +1,128 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBookmarkTags(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go docs", Path: ",Dev,", URL: "https://go.dev/doc/", Tags: []string{"go", "docs"}},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "MDN", Path: ",Dev,", URL: "https://developer.mozilla.org/", Tags: []string{"docs"}},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: "3f0c7f36-1f3a-4c8e-9d6b-6a3b1f2e4d5c", Name: "Other", URL: "https://go.dev/", Tags: []string{"go"}},
+	)
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	updateCases := []struct {
+		name       string
+		method     string
+		id         string
+		tags       []string
+		statusCode int
+	}{
+		{name: "Add tags", method: "POST", id: db.Bookmarks[1].ID, tags: []string{"news", " uk ", "news"}, statusCode: 200},
+		{name: "Add existing tag", method: "POST", id: "62c7e0a1f1d2b3a4c5d6e7f2", tags: []string{"docs", "web"}, statusCode: 200},
+		{name: "Remove tag", method: "DELETE", id: "62c7e0a1f1d2b3a4c5d6e7f2", tags: []string{"web"}, statusCode: 200},
+		{name: "Tag folder", method: "POST", id: "62c7e0a1f1d2b3a4c5d6e7f0", tags: []string{"dev"}, statusCode: 404},
+		{name: "Tag other users bookmark", method: "POST", id: "62c7e0a1f1d2b3a4c5d6e7f3", tags: []string{"mine"}, statusCode: 404},
+		{name: "No tags", method: "POST", id: db.Bookmarks[1].ID, tags: []string{" "}, statusCode: 400},
+	}
+	for _, c := range updateCases {
+		body, err := tu.MakeJSONRequestBody(request.BookmarkTags{Tags: c.tags})
+		if err != nil {
+			t.Fatalf("Couldn't create tags request body.")
+		}
+		res, err := tu.RequestWithCookie(c.method, APIURL+"/"+c.id+"/tags", tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to update tags with cookie.")
+		}
+		if res.StatusCode != c.statusCode {
+			t.Errorf("%s: expected update tags request to give status code %d: got %d", c.name, c.statusCode, res.StatusCode)
+		}
+		if c.statusCode == 200 {
+			var response handlers.UpdateBookmarkResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil || response.NumUpdated != 1 {
+				t.Errorf("%s: expected 1 bookmark to be updated: got %d (%v)", c.name, response.NumUpdated, err)
+			}
+		}
+		res.Body.Close()
+	}
+	if want := []string{"news", "uk"}; !cmp.Equal(want, db.Bookmarks[1].Tags) {
+		t.Error(cmp.Diff(want, db.Bookmarks[1].Tags))
+	}
+
+	res, err := tu.RequestWithCookie("GET", APIURL+"/tags", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to get tags with cookie.")
+	}
+	var counts []bookmarks.TagCount
+	if err := json.NewDecoder(res.Body).Decode(&counts); err != nil {
+		t.Fatalf("Couldn't decode json body upon getting tags.")
+	}
+	res.Body.Close()
+	wantCounts := []bookmarks.TagCount{{Tag: "docs", Count: 2}, {Tag: "go", Count: 1}, {Tag: "news", Count: 1}, {Tag: "uk", Count: 1}}
+	if !cmp.Equal(wantCounts, counts) {
+		t.Error(cmp.Diff(wantCounts, counts))
+	}
+
+	filterCases := []struct {
+		name       string
+		query      string
+		statusCode int
+		names      []string
+	}{
+		{name: "Match all tags", query: "?tag=docs&tag=go", statusCode: 200, names: []string{"Go docs"}},
+		{name: "Match any tag", query: "?tag=go&tag=news&match=any", statusCode: 200, names: []string{"bbc", "Go docs"}},
+		{name: "No matches", query: "?tag=missing", statusCode: 200, names: nil},
+		{name: "Invalid match", query: "?tag=go&match=some", statusCode: 400},
+		{name: "Empty tag", query: "?tag=", statusCode: 400},
+	}
+	for _, c := range filterCases {
+		res, err := tu.RequestWithCookie("GET", APIURL+c.query, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to filter bookmarks with cookie.")
+		}
+		if res.StatusCode != c.statusCode {
+			t.Errorf("%s: expected filter bookmarks request to give status code %d: got %d", c.name, c.statusCode, res.StatusCode)
+		}
+		if c.statusCode == 200 {
+			var folder bookmarks.Folder
+			if err := json.NewDecoder(res.Body).Decode(&folder); err != nil {
+				t.Fatalf("Couldn't decode json body upon filtering bookmarks.")
+			}
+			if got := folderBookmarkNames(folder); !cmp.Equal(c.names, got) {
+				t.Errorf("%s: %s", c.name, cmp.Diff(c.names, got))
+			}
+		}
+		res.Body.Close()
+	}
+}
+
+func folderBookmarkNames(folder bookmarks.Folder) []string {
+	var names []string
+	for _, b := range folder.Bookmarks {
+		names = append(names, b.Name)
+	}
+	for _, f := range folder.Folders {
+		names = append(names, folderBookmarkNames(f)...)
+	}
+	return names
+}
@@ -11,13 +11,15 @@ import (
 	"github.com/gorilla/mux"
 )
 
-// DeleteBookmarkResponse represents a successful response from the /user/bookmark POST endpoint.
+// DeleteBookmarkResponse represents a successful response from the /bookmark/{id} DELETE endpoint.
 type DeleteBookmarkResponse struct {
 	ID         string `json:"id"`
 	NumDeleted int    `json:"num_deleted"`
+	NumMoved   int    `json:"num_moved"`
 }
 
-// DeleteBookmark is the handler for the bookmark POST endpoint.
+// DeleteBookmark is the handler for the bookmark DELETE endpoint. Deleting a folder deletes its
+// contents too, unless the mode query param is reparent.
 func DeleteBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
@@ -27,13 +29,14 @@ func DeleteBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWr
 			return
 		}
 		bookmarkID := mux.Vars(r)["id"]
-		numUpdated, err := b.DeleteBookmark(r.Context(), bookmarkID, APIKey)
+		mode := r.URL.Query().Get(bookmarks.BookmarksDeleteModeKey)
+		result, err := b.DeleteBookmark(r.Context(), bookmarkID, mode, APIKey)
 		if err != nil {
 			log.Errorf("error returned while trying to delete a bookmark: %v", err)
 			apierr.APIErrorResponse(w, err)
 			return
 		}
-		if numUpdated == 0 {
+		if result.Deleted == 0 {
 			log.Error("could not delete bookmark")
 			err := apierr.NewBadRequestError("error: could not delete bookmark")
 			apierr.APIErrorResponse(w, err)
@@ -44,7 +47,8 @@ func DeleteBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWr
 		w.WriteHeader(http.StatusOK)
 		res := DeleteBookmarkResponse{
 			ID:         bookmarkID,
-			NumDeleted: numUpdated,
+			NumDeleted: result.Deleted,
+			NumMoved:   result.Moved,
 		}
 		json.NewEncoder(w).Encode(res)
 	}
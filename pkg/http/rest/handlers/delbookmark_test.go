@@ -1,6 +1,7 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
@@ -8,12 +9,25 @@ import (
 	tu "github.com/conalli/bookshelf-backend/internal/testutils"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"github.com/go-playground/validator/v10"
 )
 
 func TestDeleteBookmark(t *testing.T) {
 	t.Parallel()
 	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f4", APIKey: APIKey, Name: "Music", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f5", APIKey: APIKey, Name: "Jazz", Path: ",Music,", IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f6", APIKey: APIKey, Name: "Blue Note", Path: ",Music,Jazz,", URL: "https://www.bluenote.com/"},
+	)
+	db.MigrateBookmarkParents(context.Background())
 	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
 	srv := httptest.NewServer(r.Handler())
 	defer srv.Close()
@@ -27,12 +41,43 @@ func TestDeleteBookmark(t *testing.T) {
 		{
 			name:       "Default bookmark, correct request",
 			req:        db.Bookmarks[1].ID,
-			APIKey:     db.Users["1"].APIKey,
+			APIKey:     APIKey,
 			statusCode: 200,
 			res: handlers.DeleteBookmarkResponse{
 				NumDeleted: 1,
 			},
 		},
+		{
+			name:       "Folder deletes descendants",
+			req:        "62c7e0a1f1d2b3a4c5d6e7f0",
+			APIKey:     APIKey,
+			statusCode: 200,
+			res: handlers.DeleteBookmarkResponse{
+				NumDeleted: 4,
+			},
+		},
+		{
+			name:       "Folder reparents descendants",
+			req:        "62c7e0a1f1d2b3a4c5d6e7f4?mode=reparent",
+			APIKey:     APIKey,
+			statusCode: 200,
+			res: handlers.DeleteBookmarkResponse{
+				NumDeleted: 1,
+				NumMoved:   2,
+			},
+		},
+		{
+			name:       "Unknown mode",
+			req:        "62c7e0a1f1d2b3a4c5d6e7f5?mode=orphan",
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
+		{
+			name:       "Bookmark not found",
+			req:        "62c7e0a1f1d2b3a4c5d6e7f0",
+			APIKey:     APIKey,
+			statusCode: 404,
+		},
 	}
 	APIURL := srv.URL + "/api/bookmark/"
 	for _, c := range tc {
@@ -44,6 +89,10 @@ func TestDeleteBookmark(t *testing.T) {
 			if res.StatusCode != c.statusCode {
 				t.Errorf("Expected del bookmark request to give status code %d: got %d", c.statusCode, res.StatusCode)
 			}
+			defer res.Body.Close()
+			if c.statusCode != 200 {
+				return
+			}
 			var response handlers.DeleteBookmarkResponse
 			err = json.NewDecoder(res.Body).Decode(&response)
 			if err != nil {
@@ -52,7 +101,88 @@ func TestDeleteBookmark(t *testing.T) {
 			if response.NumDeleted != c.res.NumDeleted {
 				t.Errorf("Expected %d bookmarks to be deleted: got %d", c.res.NumDeleted, response.NumDeleted)
 			}
-			res.Body.Close()
+			if response.NumMoved != c.res.NumMoved {
+				t.Errorf("Expected %d bookmarks to be moved: got %d", c.res.NumMoved, response.NumMoved)
+			}
 		})
 	}
+	for _, b := range db.Bookmarks {
+		if b.Name == "Jazz" && b.Path != bookmarks.BookmarksBasePath {
+			t.Errorf("Expected Jazz to be moved to the base path: got %s", b.Path)
+		}
+		if b.Name == "Blue Note" && b.Path != ",Jazz," {
+			t.Errorf("Expected Blue Note to be moved to ,Jazz,: got %s", b.Path)
+		}
+	}
+}
+
+func TestDeleteBookmarkOtherUser(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	owner := db.Users["1"]
+	other := accounts.User{
+		ID:     "62c7e0a1f1d2b3a4c5d6e7e0",
+		Name:   "user2",
+		Email:  "other_user@bookshelftest.com",
+		APIKey: "3f0c7f36-1f3a-4c8e-9d6b-6a3b1f2e4d5c",
+	}
+	db.Users["2"] = other
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7e1", APIKey: other.APIKey, Name: "News", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7e2", APIKey: other.APIKey, Name: "CNN", Path: ",News,", URL: "https://www.cnn.com/"},
+	)
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark/"
+	tc := []struct {
+		name       string
+		req        string
+		APIKey     string
+		statusCode int
+	}{
+		{
+			name:       "Delete other users bookmark",
+			req:        "62c7e0a1f1d2b3a4c5d6e7e2",
+			APIKey:     owner.APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Delete other users folder",
+			req:        "62c7e0a1f1d2b3a4c5d6e7e1",
+			APIKey:     owner.APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Other user deletes owners bookmark",
+			req:        db.Bookmarks[1].ID,
+			APIKey:     other.APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Other user deletes own folder",
+			req:        "62c7e0a1f1d2b3a4c5d6e7e1",
+			APIKey:     other.APIKey,
+			statusCode: 200,
+		},
+	}
+	for _, c := range tc {
+		res, err := tu.RequestWithCookie("DELETE", APIURL+c.req, tu.WithAPIKey(c.APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to del bookmark with cookie.")
+		}
+		res.Body.Close()
+		if res.StatusCode != c.statusCode {
+			t.Errorf("%s: expected del bookmark request to give status code %d: got %d", c.name, c.statusCode, res.StatusCode)
+		}
+	}
+	if len(db.Bookmarks) != 2 {
+		t.Fatalf("Expected only the owners 2 bookmarks to remain: got %d", len(db.Bookmarks))
+	}
+	for _, b := range db.Bookmarks {
+		if b.APIKey != owner.APIKey {
+			t.Errorf("Expected remaining bookmark %s to belong to the owner", b.Name)
+		}
+	}
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// GetDuplicates is the handler for the bookmark/duplicates GET endpoint. Returns the groups of the
+// users bookmarks that share a canonical URL.
+func GetDuplicates(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		groups, err := b.GetDuplicates(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get duplicate bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved duplicate bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(groups)
+	}
+}
+
+// MergeDuplicates is the handler for the bookmark/duplicates/merge POST endpoint. Merges duplicate
+// bookmarks into the bookmark to keep and deletes the rest.
+func MergeDuplicates(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		mergeReq, parseErr := request.DecodeJSONRequest[request.MergeBookmarks](r.Body)
+		if parseErr != nil {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		result, err := b.MergeDuplicates(r.Context(), mergeReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to merge duplicate bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully merged duplicate bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
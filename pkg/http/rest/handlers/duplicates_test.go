@@ -0,0 +1,143 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestDuplicates(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	var IDs []string
+	for _, URL := range []string{"https://Example.com/", "https://example.com?utm_source=x", "https://example.org"} {
+		body, err := tu.MakeJSONRequestBody(request.AddBookmark{Name: "Example", Path: ",News,", URL: URL, SkipEnrichment: true})
+		if err != nil {
+			t.Fatalf("Couldn't create add bookmark request body")
+		}
+		res, err := tu.RequestWithCookie("POST", APIURL, tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to add bookmark with cookie")
+		}
+		var response handlers.AddBookmarkResponse
+		err = json.NewDecoder(res.Body).Decode(&response)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("Couldn't decode json body upon adding bookmark")
+		}
+		IDs = append(IDs, response.ID)
+	}
+
+	getDuplicates := func() []bookmarks.DuplicateGroup {
+		res, err := tu.RequestWithCookie("GET", APIURL+"/duplicates", tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to get duplicates with cookie")
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("Expected get duplicates request to give status code 200: got %d", res.StatusCode)
+		}
+		var groups []bookmarks.DuplicateGroup
+		if err := json.NewDecoder(res.Body).Decode(&groups); err != nil {
+			t.Fatalf("Couldn't decode json body upon getting duplicates")
+		}
+		return groups
+	}
+	groups := getDuplicates()
+	if len(groups) != 1 || groups[0].CanonicalURL != "https://example.com" || len(groups[0].Bookmarks) != 2 {
+		t.Fatalf("Expected one group of 2 bookmarks for https://example.com: got %+v", groups)
+	}
+
+	tc := []struct {
+		name       string
+		req        request.MergeBookmarks
+		statusCode int
+		numDeleted int
+	}{
+		{
+			name:       "Not a duplicate",
+			req:        request.MergeBookmarks{Keep: IDs[0], IDs: []string{IDs[2]}},
+			statusCode: 400,
+		},
+		{
+			name:       "Bookmark doesn't exist",
+			req:        request.MergeBookmarks{Keep: "62c7e0a1f1d2b3a4c5d6e7f0"},
+			statusCode: 404,
+		},
+		{
+			name:       "Merge all duplicates",
+			req:        request.MergeBookmarks{Keep: IDs[0]},
+			statusCode: 200,
+			numDeleted: 1,
+		},
+		{
+			name:       "No duplicates left",
+			req:        request.MergeBookmarks{Keep: IDs[0]},
+			statusCode: 400,
+		},
+	}
+	for _, c := range tc {
+		body, err := tu.MakeJSONRequestBody(c.req)
+		if err != nil {
+			t.Fatalf("Couldn't create merge duplicates request body")
+		}
+		res, err := tu.RequestWithCookie("POST", APIURL+"/duplicates/merge", tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to merge duplicates with cookie")
+		}
+		if res.StatusCode != c.statusCode {
+			t.Errorf("%s: expected merge duplicates request to give status code %d: got %d", c.name, c.statusCode, res.StatusCode)
+		}
+		if res.StatusCode == 200 {
+			var result bookmarks.MergeResult
+			if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+				t.Fatalf("Couldn't decode json body upon merging duplicates")
+			}
+			if result.NumDeleted != c.numDeleted || result.Bookmark.ID != c.req.Keep {
+				t.Errorf("%s: expected %d duplicates to be merged into %s: got %+v", c.name, c.numDeleted, c.req.Keep, result)
+			}
+		}
+		res.Body.Close()
+	}
+	if groups := getDuplicates(); len(groups) != 0 {
+		t.Errorf("Expected no duplicates after merging: got %+v", groups)
+	}
+
+	res, err := tu.RequestWithCookie("GET", APIURL+"/trash", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to get trash with cookie")
+	}
+	var trash []bookmarks.TrashItem
+	err = json.NewDecoder(res.Body).Decode(&trash)
+	res.Body.Close()
+	if err != nil {
+		t.Fatalf("Couldn't decode json body upon getting trash")
+	}
+	if len(trash) != 1 || trash[0].ID != IDs[1] {
+		t.Fatalf("Expected the merged duplicate %s to be in the trash: got %+v", IDs[1], trash)
+	}
+	res, err = tu.RequestWithCookie("POST", APIURL+"/trash/"+IDs[1]+"/restore", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to restore bookmark with cookie")
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("Expected merged duplicate to be restored with status code 200: got %d", res.StatusCode)
+	}
+	if groups := getDuplicates(); len(groups) != 1 {
+		t.Errorf("Expected restored duplicate to be found again: got %+v", groups)
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// ExportBookmarks is the handler for the /bookmark/export GET endpoint. Checks credentials + JWT and if
+// authorized returns all users bookmarks as a Netscape bookmark file.
+func ExportBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		w.Header().Set("Content-Type", bookmarks.BookmarksExportContentType)
+		w.Header().Set("Content-Disposition", "attachment; filename=\""+bookmarks.BookmarksExportFileName+"\"")
+		sw := &streamWriter{ResponseWriter: w}
+		err := b.ExportBookmarks(r.Context(), sw, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to export bookmarks: %v", err)
+			sw.abortIfStarted()
+			w.Header().Del("Content-Disposition")
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully exported bookmarks")
+	}
+}
+
+// streamWriter records whether any of a streamed response body has been written, after which the
+// status has been sent and an error response can no longer be written.
+type streamWriter struct {
+	http.ResponseWriter
+	started bool
+}
+
+func (w *streamWriter) Write(b []byte) (int, error) {
+	w.started = true
+	return w.ResponseWriter.Write(b)
+}
+
+// abortIfStarted aborts the connection if part of the body has already been sent, so that the
+// client sees a failed download instead of a truncated file.
+func (w *streamWriter) abortIfStarted() {
+	if w.started {
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestExportBookmarks(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	tc := []struct {
+		name       string
+		APIKey     string
+		statusCode int
+		contains   []string
+	}{
+		{
+			name:       "Default user, correct request",
+			APIKey:     db.Users["1"].APIKey,
+			statusCode: 200,
+			contains: []string{
+				"<!DOCTYPE NETSCAPE-Bookmark-file-1>",
+				"<DT><H3>News</H3>",
+				`<DT><A HREF="bbc.co.uk" ADD_DATE="1656666000" LAST_MODIFIED="1656666000" ICON_URI="https://www.bbc.co.uk/favicon.ico">bbc</A>`,
+			},
+		},
+	}
+	APIURL := srv.URL + "/api/bookmark/export"
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := tu.RequestWithCookie("GET", APIURL, tu.WithAPIKey(c.APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create request to export bookmarks with cookie.")
+			}
+			defer res.Body.Close()
+			if res.StatusCode != c.statusCode {
+				t.Errorf("Expected export bookmarks request to give status code %d: got %d", c.statusCode, res.StatusCode)
+			}
+			if ct := res.Header.Get("Content-Type"); ct != bookmarks.BookmarksExportContentType {
+				t.Errorf("Expected content type %s: got %s", bookmarks.BookmarksExportContentType, ct)
+			}
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Couldn't read body upon exporting bookmarks.")
+			}
+			for _, want := range c.contains {
+				if !strings.Contains(string(body), want) {
+					t.Errorf("Expected exported bookmarks to contain %s", want)
+				}
+			}
+		})
+	}
+}
+
+// failingResponseWriter accepts the first write and fails every write after it.
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+	writes int
+}
+
+func (w *failingResponseWriter) Write(b []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("connection reset")
+	}
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestExportBookmarksAbortsFailedStream(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	for i := 0; i < 200; i++ {
+		db.Bookmarks = append(db.Bookmarks, bookmarks.Bookmark{
+			ID: fmt.Sprintf("62c7e0a1f1d2b3a4c5d6%04x", i), APIKey: APIKey, Name: fmt.Sprintf("Bookmark %d", i),
+			Path: bookmarks.BookmarksBasePath, URL: fmt.Sprintf("https://example.com/%d", i),
+		})
+	}
+	log := tu.NewLogger()
+	handler := handlers.ExportBookmarks(bookmarks.NewService(log, validator.New(), db, tu.NewCache()), log)
+	req := httptest.NewRequest("GET", "/api/bookmark/export", nil)
+	req = req.WithContext(request.AddAPIKeyToContext(req.Context(), APIKey))
+	w := &failingResponseWriter{ResponseRecorder: httptest.NewRecorder()}
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("Expected export to abort the connection: got %v", p)
+		}
+		if w.writes != 2 || strings.Contains(w.Body.String(), "internal server error") {
+			t.Errorf("Expected no error response after the export started: got %d writes and %s", w.writes, w.Body.String())
+		}
+	}()
+	handler(w, req)
+}
@@ -11,7 +11,7 @@ import (
 )
 
 // GetAllBookmarks is the handler for the /user/bookmarks GET endpoint. Checks credentials + JWT and if
-// authorized returns all users bookmarks.
+// authorized returns all users bookmarks, or only those matching the tag query params if given.
 func GetAllBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
@@ -20,7 +20,13 @@ func GetAllBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseW
 			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
 			return
 		}
-		books, err := b.GetAllBookmarks(r.Context(), APIKey)
+		var books *bookmarks.Folder
+		var err apierr.Error
+		if tags, ok := r.URL.Query()[bookmarks.BookmarksTagKey]; ok {
+			books, err = b.GetBookmarksByTags(r.Context(), tags, r.URL.Query().Get(bookmarks.BookmarksTagMatchKey), APIKey)
+		} else {
+			books, err = b.GetAllBookmarks(r.Context(), APIKey)
+		}
 		if err != nil {
 			log.Errorf("error returned while trying to get cmds: %v", err)
 			apierr.APIErrorResponse(w, err)
@@ -10,20 +10,25 @@ import (
 	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 )
 
-// GetBookmarksFolder is the handler for the /user/bookmarks/{Path} GET endpoint. Checks credentials + JWT and if
-// authorized returns all users bookmarks.
+// GetBookmarksFolder is the handler for the bookmark/folder GET endpoint. Checks credentials + JWT and if
+// authorized returns the folder found by its id, path or name query param with all the bookmarks inside it.
 func GetBookmarksFolder(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
-		folder := r.URL.Query().Get("name")
 		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
 		if len(APIKey) < 1 || !ok {
 			log.Error("could not get APIKey from context")
 			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
 			return
 		}
-		books, err := b.GetBookmarksFolder(r.Context(), folder, APIKey)
+		params := r.URL.Query()
+		query := bookmarks.FolderQuery{
+			ID:   params.Get(bookmarks.BookmarksFolderIDKey),
+			Path: params.Get(bookmarks.BookmarksFolderPathKey),
+			Name: params.Get(bookmarks.BookmarksFolderNameKey),
+		}
+		books, err := b.GetBookmarksFolder(r.Context(), query, APIKey)
 		if err != nil {
-			log.Errorf("error returned while trying to get cmds: %v", err)
+			log.Errorf("error returned while trying to get bookmarks folder: %v", err)
 			apierr.APIErrorResponse(w, err)
 			return
 		}
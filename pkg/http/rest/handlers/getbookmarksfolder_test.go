@@ -1,59 +1,161 @@
 package handlers_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest"
 	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
 )
 
 func TestGetBookmarksFolder(t *testing.T) {
 	t.Parallel()
 	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "C++", Path: ",Dev,", IsFolder: true, Position: 1},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true, Position: 2},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+	)
+	db.MigrateBookmarkParents(context.Background())
+	goFolder := bookmarks.Folder{
+		ID:         "62c7e0a1f1d2b3a4c5d6e7f2",
+		Name:       "Go",
+		Path:       ",Dev,",
+		Position:   2,
+		Breadcrumb: []bookmarks.FolderRef{{ID: "62c7e0a1f1d2b3a4c5d6e7f0", Name: "Dev"}},
+		Bookmarks: []bookmarks.Bookmark{{
+			ID:        "62c7e0a1f1d2b3a4c5d6e7f3",
+			APIKey:    APIKey,
+			Name:      "Go docs",
+			ParentID:  "62c7e0a1f1d2b3a4c5d6e7f2",
+			Ancestors: []string{"62c7e0a1f1d2b3a4c5d6e7f0", "62c7e0a1f1d2b3a4c5d6e7f2"},
+			Path:      ",Dev,Go,",
+			URL:       "https://go.dev/doc/",
+		}},
+	}
 	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
 	srv := httptest.NewServer(r.Handler())
 	defer srv.Close()
 	tc := []struct {
 		name       string
-		folder     string
+		query      string
 		APIKey     string
 		statusCode int
 		res        bookmarks.Folder
 	}{
 		{
 			name:       "Default user, correct request",
-			folder:     "News",
-			APIKey:     db.Users["1"].APIKey,
+			query:      "name=News",
+			APIKey:     APIKey,
 			statusCode: 200,
 			res: bookmarks.Folder{
 				ID:   "newsfolderid",
 				Name: "News",
 				Path: bookmarks.BookmarksBasePath,
 				Bookmarks: []bookmarks.Bookmark{{
-					ID:       "c55fdaace3388c2189875fc5",
-					APIKey:   "bd1eb780-0124-11ed-b939-0242ac120002",
-					Name:     "bbc",
-					Path:     ",News,",
-					URL:      "bbc.co.uk",
-					IsFolder: false,
+					ID:         "c55fdaace3388c2189875fc5",
+					APIKey:     "bd1eb780-0124-11ed-b939-0242ac120002",
+					Name:       "bbc",
+					ParentID:   "newsfolderid",
+					Ancestors:  []string{"newsfolderid"},
+					Path:       ",News,",
+					URL:        "bbc.co.uk",
+					IsFolder:   false,
+					CreatedAt:  time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC),
+					ModifiedAt: time.Date(2022, 7, 1, 9, 0, 0, 0, time.UTC),
+					IconURI:    "https://www.bbc.co.uk/favicon.ico",
 				}},
 			},
 		},
+		{
+			name:       "Folder by id",
+			query:      "id=62c7e0a1f1d2b3a4c5d6e7f2",
+			APIKey:     APIKey,
+			statusCode: 200,
+			res:        goFolder,
+		},
+		{
+			name:       "Folder by path",
+			query:      "path=,Dev,Go,",
+			APIKey:     APIKey,
+			statusCode: 200,
+			res:        goFolder,
+		},
+		{
+			name:       "Folder by name with regex characters",
+			query:      "name=c%2B%2B",
+			APIKey:     APIKey,
+			statusCode: 200,
+			res: bookmarks.Folder{
+				ID:         "62c7e0a1f1d2b3a4c5d6e7f1",
+				Name:       "C++",
+				Path:       ",Dev,",
+				Position:   1,
+				Breadcrumb: []bookmarks.FolderRef{{ID: "62c7e0a1f1d2b3a4c5d6e7f0", Name: "Dev"}},
+			},
+		},
+		{
+			name:       "Name is not matched as a regex",
+			query:      "name=G.",
+			APIKey:     APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Path of a bookmark",
+			query:      "path=,Dev,Go,Go%20docs,",
+			APIKey:     APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Missing folder",
+			query:      "id=62c7e0a1f1d2b3a4c5d6e7ff",
+			APIKey:     APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Folder owned by another user",
+			query:      "id=62c7e0a1f1d2b3a4c5d6e7f2",
+			APIKey:     uuid.New().String(),
+			statusCode: 404,
+		},
+		{
+			name:       "More than one lookup key",
+			query:      "id=62c7e0a1f1d2b3a4c5d6e7f2&name=Go",
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
+		{
+			name:       "No lookup key",
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
 	}
-	APIURL := srv.URL + "/api/bookmark/folder?name="
+	APIURL := srv.URL + "/api/bookmark/folder?"
 	for _, c := range tc {
 		t.Run(c.name, func(t *testing.T) {
-			res, err := tu.RequestWithCookie("GET", APIURL+c.folder, tu.WithAPIKey(c.APIKey))
+			res, err := tu.RequestWithCookie("GET", APIURL+c.query, tu.WithAPIKey(c.APIKey))
 			if err != nil {
 				t.Fatalf("Couldn't create request to get bookmarks folder with cookie.")
 			}
+			defer res.Body.Close()
 			if res.StatusCode != c.statusCode {
-				t.Errorf("Expected get bookmarks folder request to give status code %d: got %d", c.statusCode, res.StatusCode)
+				t.Fatalf("Expected get bookmarks folder request to give status code %d: got %d", c.statusCode, res.StatusCode)
+			}
+			if res.StatusCode >= 400 {
+				return
 			}
 			var response bookmarks.Folder
 			err = json.NewDecoder(res.Body).Decode(&response)
@@ -63,7 +165,134 @@ func TestGetBookmarksFolder(t *testing.T) {
 			if !cmp.Equal(response, c.res) {
 				t.Errorf(cmp.Diff(response, c.res))
 			}
-			res.Body.Close()
 		})
 	}
 }
+
+func TestGetBookmarksFolderByNameFindsShallowest(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Build", Path: ",Dev,", IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Arch", Path: ",Dev,Build,", IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "Tools", Path: bookmarks.BookmarksBasePath, IsFolder: true, Position: 1},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f4", APIKey: APIKey, Name: "Arch", Path: ",Tools,", IsFolder: true, Position: 3},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	res, err := tu.RequestWithCookie("GET", srv.URL+"/api/bookmark/folder?name=arch", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to get bookmarks folder with cookie.")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("Expected get bookmarks folder request to give status code 200: got %d", res.StatusCode)
+	}
+	var response bookmarks.Folder
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		t.Fatalf("Couldn't decode json body upon getting bookmarks folder.")
+	}
+	// ",Dev,Build," sorts before ",Tools,", but the folder nested in fewer folders is found.
+	if response.ID != "62c7e0a1f1d2b3a4c5d6e7f4" {
+		t.Errorf("Expected the folder nearest the top level to be found: got %+v", response)
+	}
+}
+
+func TestFolderNamesWithCommas(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = nil
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	page := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+<DT><H3>a</H3>
+<DL><p>
+<DT><A HREF="https://example.com/a">Example a</A>
+</DL><p>
+<DT><H3>a,b</H3>
+<DL><p>
+<DT><A HREF="https://example.com/ab">Example ab</A>
+</DL><p>
+</DL><p>
+`
+	path := filepath.Join(t.TempDir(), "commas.html")
+	if err := os.WriteFile(path, []byte(page), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file, ct, err := tu.MakeFileRequestBodyWithFields(path, "commas.html", nil)
+	if err != nil {
+		t.Fatalf("could not create request body: %v", err)
+	}
+	res, err := tu.RequestWithCookie("POST", srv.URL+"/api/bookmark/file", tu.WithHeaders(map[string]string{"Content-Type": ct}), tu.WithBody(file), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job bookmarks.ImportJob
+	err = json.NewDecoder(res.Body).Decode(&job)
+	res.Body.Close()
+	if err != nil {
+		t.Fatalf("couldn't decode api response: %v", err)
+	}
+	if got := waitForImport(t, srv.URL+"/api/bookmark/import/"+job.ID, APIKey); got.Status != bookmarks.ImportStatusDone || got.Added != 4 {
+		t.Fatalf("expected the folders to be imported: got %+v", got)
+	}
+
+	getFolder := func(path string) bookmarks.Folder {
+		t.Helper()
+		res, err := tu.RequestWithCookie("GET", srv.URL+"/api/bookmark/folder?path="+url.QueryEscape(path), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to get bookmarks folder with cookie.")
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("Expected getting folder %s to give status code 200: got %d", path, res.StatusCode)
+		}
+		var folder bookmarks.Folder
+		if err := json.NewDecoder(res.Body).Decode(&folder); err != nil {
+			t.Fatalf("Couldn't decode json body upon getting bookmarks folder.")
+		}
+		return folder
+	}
+	folder := getFolder(",a,b,")
+	if folder.Name != "a,b" || len(folder.Bookmarks) != 1 || folder.Bookmarks[0].URL != "https://example.com/ab" {
+		t.Fatalf("Expected to find the folder a,b by its path: got %+v", folder)
+	}
+
+	res, err = tu.RequestWithCookie("GET", srv.URL+"/api/bookmark/search?q=example&folder="+url.QueryEscape(",a,"), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to search bookmarks with cookie.")
+	}
+	var results bookmarks.SearchResults
+	err = json.NewDecoder(res.Body).Decode(&results)
+	res.Body.Close()
+	if err != nil {
+		t.Fatalf("Couldn't decode json body upon searching bookmarks.")
+	}
+	if results.Total != 1 || results.Results[0].URL != "https://example.com/a" {
+		t.Errorf("Expected searching folder a to leave out folder a,b: got %+v", results)
+	}
+
+	name := "c,d"
+	body, err := tu.MakeJSONRequestBody(request.UpdateBookmark{Name: &name})
+	if err != nil {
+		t.Fatalf("Couldn't create update bookmark request body")
+	}
+	res, err = tu.RequestWithCookie("PATCH", srv.URL+"/api/bookmark/"+folder.ID, tu.WithBody(body), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to update bookmark with cookie.")
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("Expected renaming folder to c,d to give status code 200: got %d", res.StatusCode)
+	}
+	if renamed := getFolder(",c,d,"); renamed.ID != folder.ID || len(renamed.Bookmarks) != 1 || renamed.Bookmarks[0].Path != ",c,d," {
+		t.Errorf("Expected to find the renamed folder by its new path: got %+v", renamed)
+	}
+}
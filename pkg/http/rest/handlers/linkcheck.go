@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// GetBrokenBookmarks is the handler for the bookmark/broken GET endpoint. Returns the users bookmarks
+// whose links were broken when last checked.
+func GetBrokenBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		books, err := b.GetBrokenBookmarks(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get broken bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved broken bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(books)
+	}
+}
+
+// CheckLinks is the handler for the bookmark/check POST endpoint. Starts checking the links of all
+// the users bookmarks in the background.
+func CheckLinks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		summary, err := b.CheckLinks(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to check links: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully started link check")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(summary)
+	}
+}
@@ -0,0 +1,75 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestCheckLinks(t *testing.T) {
+	t.Parallel()
+	sites := http.NewServeMux()
+	sites.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	sites.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	})
+	site := httptest.NewServer(sites)
+	defer site.Close()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "OK", Path: ",Dev,", URL: site.URL + "/ok"},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Gone", Path: ",Dev,", URL: site.URL + "/gone"},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "Bookmarklet", URL: "javascript:void(0)"},
+	}
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	res, err := tu.RequestWithCookie("POST", APIURL+"/check", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to check links with cookie.")
+	}
+	if res.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected check links request to give status code %d: got %d", http.StatusAccepted, res.StatusCode)
+	}
+	var summary bookmarks.LinkCheckSummary
+	err = json.NewDecoder(res.Body).Decode(&summary)
+	res.Body.Close()
+	if err != nil {
+		t.Fatalf("Couldn't decode json body upon checking links.")
+	}
+	if summary.Queued != 2 {
+		t.Errorf("Expected 2 links to be queued: got %d", summary.Queued)
+	}
+
+	var broken []bookmarks.Bookmark
+	deadline := time.Now().Add(10 * time.Second)
+	for len(broken) == 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+		res, err := tu.RequestWithCookie("GET", APIURL+"/broken", tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to get broken bookmarks with cookie.")
+		}
+		err = json.NewDecoder(res.Body).Decode(&broken)
+		res.Body.Close()
+		if err != nil {
+			t.Fatalf("Couldn't decode json body upon getting broken bookmarks.")
+		}
+	}
+	if len(broken) != 1 || broken[0].Name != "Gone" {
+		t.Fatalf("Expected only the Gone bookmark to be broken: got %+v", broken)
+	}
+	if broken[0].LinkStatus.StatusCode != http.StatusGone {
+		t.Errorf("Expected broken bookmark to have status code %d: got %d", http.StatusGone, broken[0].LinkStatus.StatusCode)
+	}
+}
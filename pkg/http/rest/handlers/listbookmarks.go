@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// ListBookmarks is the handler for the bookmark/list GET endpoint. Returns a page of the users bookmarks
+// as a flat list, along with a cursor to pass as the after query param to get the next page.
+func ListBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		params := r.URL.Query()
+		limit := 0
+		if l := params.Get(bookmarks.BookmarksListLimitKey); len(l) > 0 {
+			var convErr error
+			limit, convErr = strconv.Atoi(l)
+			if convErr != nil {
+				log.Errorf("could not parse list limit: %v", convErr)
+				apierr.APIErrorResponse(w, apierr.NewBadRequestError("invalid limit"))
+				return
+			}
+		}
+		page, err := b.ListBookmarks(r.Context(), params.Get(bookmarks.BookmarksListSortKey), limit, params.Get(bookmarks.BookmarksListAfterKey), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to list bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully listed bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(page)
+	}
+}
@@ -0,0 +1,116 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListBookmarks(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{}
+	names := []string{"Delta", "Alpha", "Charlie", "Bravo", "Alpha"}
+	for i, name := range names {
+		db.Bookmarks = append(db.Bookmarks, bookmarks.Bookmark{
+			ID:        fmt.Sprintf("62c7e0a1f1d2b3a4c5d6e7f%d", i),
+			APIKey:    APIKey,
+			Name:      name,
+			URL:       fmt.Sprintf("https://%d.example.com/", len(names)-i),
+			CreatedAt: time.Date(2022, 7, 1, 9, 0, i, 0, time.UTC),
+		})
+	}
+	db.Bookmarks = append(db.Bookmarks, bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7e0", APIKey: "3f0c7f36-1f3a-4c8e-9d6b-6a3b1f2e4d5c", Name: "Other"})
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark/list"
+
+	listAll := func(sort string) []string {
+		t.Helper()
+		ids := []string{}
+		after := ""
+		for pages := 0; pages < len(names); pages++ {
+			query := url.Values{"limit": {"2"}, "after": {after}}
+			if len(sort) > 0 {
+				query.Set("sort", sort)
+			}
+			res, err := tu.RequestWithCookie("GET", APIURL+"?"+query.Encode(), tu.WithAPIKey(APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create request to list bookmarks with cookie.")
+			}
+			if res.StatusCode != 200 {
+				t.Fatalf("Expected list bookmarks request to give status code 200: got %d", res.StatusCode)
+			}
+			var page bookmarks.BookmarkPage
+			err = json.NewDecoder(res.Body).Decode(&page)
+			res.Body.Close()
+			if err != nil {
+				t.Fatalf("Couldn't decode json body upon listing bookmarks.")
+			}
+			if len(page.Bookmarks) > 2 {
+				t.Fatalf("Expected at most 2 bookmarks per page: got %d", len(page.Bookmarks))
+			}
+			for _, b := range page.Bookmarks {
+				ids = append(ids, b.ID)
+			}
+			if len(page.Next) == 0 {
+				return ids
+			}
+			after = page.Next
+		}
+		t.Fatalf("Expected listing %s to finish within %d pages", sort, len(names))
+		return nil
+	}
+	tc := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{"62c7e0a1f1d2b3a4c5d6e7f1", "62c7e0a1f1d2b3a4c5d6e7f4", "62c7e0a1f1d2b3a4c5d6e7f3", "62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f0"}},
+		{sort: "created", want: []string{"62c7e0a1f1d2b3a4c5d6e7f0", "62c7e0a1f1d2b3a4c5d6e7f1", "62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f3", "62c7e0a1f1d2b3a4c5d6e7f4"}},
+		{sort: "url", want: []string{"62c7e0a1f1d2b3a4c5d6e7f4", "62c7e0a1f1d2b3a4c5d6e7f3", "62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f1", "62c7e0a1f1d2b3a4c5d6e7f0"}},
+	}
+	for _, c := range tc {
+		if got := listAll(c.sort); !cmp.Equal(c.want, got) {
+			t.Errorf("sort %q: %s", c.sort, cmp.Diff(c.want, got))
+		}
+	}
+
+	res, err := tu.RequestWithCookie("GET", APIURL+"?limit=1&sort=created", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to list bookmarks with cookie.")
+	}
+	var page bookmarks.BookmarkPage
+	json.NewDecoder(res.Body).Decode(&page)
+	res.Body.Close()
+	badCases := []struct {
+		name  string
+		query string
+	}{
+		{name: "Unknown sort", query: "?sort=rating"},
+		{name: "Limit too large", query: "?limit=1000"},
+		{name: "Limit not a number", query: "?limit=ten"},
+		{name: "Malformed cursor", query: "?after=not-a-cursor"},
+		{name: "Cursor from another sort", query: "?sort=name&after=" + page.Next},
+	}
+	for _, c := range badCases {
+		res, err := tu.RequestWithCookie("GET", APIURL+c.query, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to list bookmarks with cookie.")
+		}
+		res.Body.Close()
+		if res.StatusCode != 400 {
+			t.Errorf("%s: expected list bookmarks request to give status code 400: got %d", c.name, res.StatusCode)
+		}
+	}
+}
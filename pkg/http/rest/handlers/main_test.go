@@ -0,0 +1,12 @@
+package handlers_test
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// Link checks and enrichment fetch pages from httptest servers on the loopback address.
+	os.Setenv("ALLOW_PRIVATE_FETCH", "true")
+	os.Exit(m.Run())
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/search"
+)
+
+// OpenSearch is the handler for the search/opensearch.xml GET endpoint. Returns an OpenSearch
+// description document so that browsers can add Bookshelf as a search engine.
+func OpenSearch(s search.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, _, ok := request.GetSearchKeysFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get keys from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		description, err := s.OpenSearch(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get opensearch description: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully created opensearch description")
+		w.Header().Set("Content-Type", search.OpenSearchContentType)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(xml.Header))
+		xml.NewEncoder(w).Encode(description)
+	}
+}
+
+// SearchSuggestions is the handler for the search/suggest GET endpoint. Returns OpenSearch
+// suggestions for the q query param, so that browsers can autocomplete cmds, bookmarks and
+// webcli commands in the address bar.
+func SearchSuggestions(s search.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, _, ok := request.GetSearchKeysFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get keys from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		suggestions, err := s.Suggest(r.Context(), APIKey, r.URL.Query().Get("q"))
+		if err != nil {
+			log.Errorf("error returned while trying to get search suggestions: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved search suggestions")
+		w.Header().Set("Content-Type", search.SuggestionsContentType)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(suggestions)
+	}
+}
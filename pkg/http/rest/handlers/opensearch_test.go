@@ -0,0 +1,101 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/conalli/bookshelf-backend/pkg/services/search"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOpenSearch(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/opensearch.xml", tu.WithAPIKey(db.Users["1"].APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create opensearch request: %v", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != 200 || res.Header.Get("Content-Type") != search.OpenSearchContentType {
+		t.Fatalf("Expected opensearch description with status code 200: got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	var description search.OpenSearchDescription
+	if err := xml.NewDecoder(res.Body).Decode(&description); err != nil {
+		t.Fatalf("Couldn't decode opensearch description: %v", err)
+	}
+	templates := map[string]string{}
+	for _, u := range description.URLs {
+		templates[u.Type] = u.Template
+	}
+	if description.ShortName != "Bookshelf" ||
+		!strings.HasSuffix(templates["text/html"], "/api/search/{searchTerms}") ||
+		!strings.HasSuffix(templates[search.SuggestionsContentType], "/api/search/suggest?q={searchTerms}") {
+		t.Errorf("Expected opensearch description with search and suggestion templates: got %+v", description)
+	}
+}
+
+func TestSearchSuggestions(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	usr := db.Users["1"]
+	usr.Cmds = map[string]string{
+		"bbc":    "https://www.bbc.co.uk",
+		"github": "https://github.com/{1}",
+		"gist":   "https://gist.github.com",
+	}
+	db.Users["1"] = usr
+	db.Bookmarks = append(db.Bookmarks, bookmarks.Bookmark{
+		ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: usr.APIKey, Name: "Go blog", Path: bookmarks.BookmarksBasePath, URL: "https://go.dev/blog/",
+	})
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	tc := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "cmd prefix", query: "gi", want: `["gi",["gist","github"],["https://gist.github.com","https://github.com/{1}"],["https://gist.github.com","https://github.com/"]]`},
+		{name: "webcli verb", query: "l", want: `["l",["ls"],["webcli: list bookmarks or cmds"],[""]]`},
+		{name: "cmd typo", query: "bcc", want: `["bcc",["bbc"],["https://www.bbc.co.uk"],["https://www.bbc.co.uk"]]`},
+		{name: "bookmark", query: "go blog", want: `["go blog",["Go blog"],["https://go.dev/blog/"],["https://go.dev/blog/"]]`},
+		{name: "no suggestions", query: "weather", want: `["weather",[],[],[]]`},
+		{name: "empty query", query: "", want: `["",[],[],[]]`},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/suggest?q="+url.QueryEscape(c.query), tu.WithAPIKey(usr.APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create suggest request: %v", err)
+			}
+			defer res.Body.Close()
+			if res.StatusCode != 200 || res.Header.Get("Content-Type") != search.SuggestionsContentType {
+				t.Fatalf("Expected suggestions with status code 200: got %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+			}
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatalf("Couldn't read suggestions: %v", err)
+			}
+			var got, want any
+			if err := json.Unmarshal(body, &got); err != nil {
+				t.Fatalf("Couldn't decode suggestions %s: %v", body, err)
+			}
+			json.Unmarshal([]byte(c.want), &want)
+			if !cmp.Equal(want, got) {
+				t.Error(cmp.Diff(want, got))
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// ReorderBookmarks is the handler for the bookmark/order PUT endpoint. Changes the order of the
+// bookmarks and folders in a folder to the order of the IDs in the request.
+func ReorderBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		reorderReq, parseErr := request.DecodeJSONRequest[request.ReorderBookmarks](r.Body)
+		if parseErr != nil {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		numUpdated, err := b.ReorderBookmarks(r.Context(), reorderReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to reorder bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully reordered bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		res := UpdateBookmarkResponse{
+			ID:         reorderReq.FolderID,
+			NumUpdated: numUpdated,
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
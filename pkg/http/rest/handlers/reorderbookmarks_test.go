@@ -0,0 +1,119 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestReorderBookmarks(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go docs", Path: ",Dev,", URL: "https://go.dev/doc/", Position: 0},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/", Position: 1},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	// New bookmarks are added to the end of their folder.
+	body, err := tu.MakeJSONRequestBody(request.AddBookmark{Name: "MDN", Path: ",Dev,", URL: "https://developer.mozilla.org/", SkipEnrichment: true})
+	if err != nil {
+		t.Fatalf("Couldn't create add bookmark request body")
+	}
+	res, err := tu.RequestWithCookie("POST", APIURL, tu.WithBody(body), tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to add bookmark with cookie")
+	}
+	var added handlers.AddBookmarkResponse
+	if err := json.NewDecoder(res.Body).Decode(&added); err != nil {
+		t.Fatalf("Couldn't decode json body upon adding bookmark")
+	}
+	res.Body.Close()
+
+	tc := []struct {
+		name       string
+		req        request.ReorderBookmarks
+		statusCode int
+		numUpdated int
+	}{
+		{
+			name:       "Reverse folder",
+			req:        request.ReorderBookmarks{FolderID: "62c7e0a1f1d2b3a4c5d6e7f0", IDs: []string{added.ID, "62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f1"}},
+			statusCode: 200,
+			numUpdated: 3,
+		},
+		{
+			name:       "Missing child",
+			req:        request.ReorderBookmarks{FolderID: "62c7e0a1f1d2b3a4c5d6e7f0", IDs: []string{"62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f1"}},
+			statusCode: 400,
+		},
+		{
+			name:       "Folder doesn't exist",
+			req:        request.ReorderBookmarks{FolderID: "62c7e0a1f1d2b3a4c5d6e7ff", IDs: []string{"62c7e0a1f1d2b3a4c5d6e7f1"}},
+			statusCode: 404,
+		},
+		{
+			name:       "Root folder",
+			req:        request.ReorderBookmarks{IDs: []string{"62c7e0a1f1d2b3a4c5d6e7f0"}},
+			statusCode: 200,
+			numUpdated: 1,
+		},
+	}
+	for _, c := range tc {
+		body, err := tu.MakeJSONRequestBody(c.req)
+		if err != nil {
+			t.Fatalf("Couldn't create reorder bookmarks request body")
+		}
+		res, err := tu.RequestWithCookie("PUT", APIURL+"/order", tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to reorder bookmarks with cookie")
+		}
+		defer res.Body.Close()
+		if res.StatusCode != c.statusCode {
+			t.Errorf("%s: expected reorder request to give status code %d: got %d", c.name, c.statusCode, res.StatusCode)
+			continue
+		}
+		if res.StatusCode < 400 {
+			var response handlers.UpdateBookmarkResponse
+			if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+				t.Fatalf("Couldn't decode json body upon reordering bookmarks")
+			}
+			if response.NumUpdated != c.numUpdated {
+				t.Errorf("%s: expected %d bookmarks to be reordered: got %d", c.name, c.numUpdated, response.NumUpdated)
+			}
+		}
+	}
+
+	res, err = tu.RequestWithCookie("GET", APIURL, tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create request to get bookmarks with cookie")
+	}
+	defer res.Body.Close()
+	var folder bookmarks.Folder
+	if err := json.NewDecoder(res.Body).Decode(&folder); err != nil {
+		t.Fatalf("Couldn't decode json body upon getting bookmarks")
+	}
+	if len(folder.Folders) != 1 || len(folder.Folders[0].Bookmarks) != 3 {
+		t.Fatalf("Expected Dev folder with 3 bookmarks: got %+v", folder)
+	}
+	want := []string{"MDN", "GitHub", "Go docs"}
+	for i, b := range folder.Folders[0].Bookmarks {
+		if b.Name != want[i] || b.Position != i {
+			t.Errorf("Expected bookmark %d to be %s: got %s at position %d", i, want[i], b.Name, b.Position)
+		}
+	}
+}
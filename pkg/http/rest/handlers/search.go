@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"os"
 
@@ -39,3 +40,15 @@ func Search(s search.Service, log logs.Logger) func(w http.ResponseWriter, r *ht
 		http.Redirect(w, r, url, http.StatusSeeOther)
 	}
 }
+
+// SearchHelp is the handler for the search/help.json GET endpoint. Returns the help for each of
+// the webcli commands, for the webcli help page to render.
+func SearchHelp(s search.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		help := s.Help(r.Context())
+		log.Info("successfully retrieved webcli help")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(help)
+	}
+}
@@ -1,13 +1,18 @@
 package handlers_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+	"github.com/conalli/bookshelf-backend/pkg/services/search"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -34,6 +39,48 @@ func TestSearchCommand(t *testing.T) {
 	}
 }
 
+func TestSearchTemplate(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	usr := db.Users["1"]
+	usr.Cmds = map[string]string{
+		"gh": "https://github.com/{1}",
+		"g":  "https://www.google.com/search?q={*}",
+		"go": "pkg.go.dev/{pkg:std}?tab={tab:doc}",
+	}
+	db.Users["1"] = usr
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	client := tu.NewRedirectClient()
+	tc := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "positional argument with slash", args: "gh conalli/bookshelf", want: "https://github.com/conalli/bookshelf"},
+		{name: "all arguments", args: "g golang  generics & maps", want: "https://www.google.com/search?q=golang+generics+%26+maps"},
+		{name: "named and defaulted", args: "go net/http tab=versions", want: "http://pkg.go.dev/net/http?tab=versions"},
+		{name: "defaults only", args: "go", want: "http://pkg.go.dev/std?tab=doc"},
+		{name: "scheme in argument of template without scheme", args: "go https:evil.com", want: "http://pkg.go.dev/https%3Aevil.com?tab=doc"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			// Search twice so that the cmd is read from the db and then from the cache.
+			for i := 0; i < 2; i++ {
+				res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/"+url.PathEscape(c.args), tu.WithClient(client), tu.WithAPIKey(usr.APIKey))
+				if err != nil {
+					t.Fatalf("Could not create Search request - %v", err)
+				}
+				res.Body.Close()
+				if dest := res.Header.Get("Location"); dest != c.want {
+					t.Errorf("wanted %s: got %s", c.want, dest)
+				}
+			}
+		})
+	}
+}
+
 func TestSearchLS(t *testing.T) {
 	t.Parallel()
 	db := tu.NewDB().AddDefaultUsers()
@@ -123,6 +170,13 @@ func TestSearchTouch(t *testing.T) {
 			statusCode:  303,
 			redirectURL: redirectURL + "/webcli/success",
 		},
+		{
+			name:        "Incorrect request, invalid url template (touch -c -url)",
+			APIKey:      db.Users["1"].APIKey,
+			flags:       "-c go -url pkg.go.dev/{1",
+			statusCode:  303,
+			redirectURL: redirectURL + "/404",
+		},
 		{
 			name:        "Incorrect request, incorrect APIKey (touch -b -url)",
 			APIKey:      "unknown",
@@ -148,3 +202,101 @@ func TestSearchTouch(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchCmdMatching(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	usr := db.Users["1"]
+	usr.Cmds = map[string]string{
+		"bbc":    "https://www.bbc.co.uk",
+		"github": "https://github.com/{1}",
+		"gitlab": "https://gitlab.com",
+		"gmail":  "https://mail.google.com",
+	}
+	db.Users["1"] = usr
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	client := tu.NewRedirectClient()
+	suggestURL := os.Getenv("ALLOWED_URL_BASE") + "/webcli/suggest?"
+	tc := []struct {
+		name     string
+		matching string
+		args     string
+		want     string
+	}{
+		{name: "auto typo", matching: accounts.CmdMatchingAuto, args: "bcc", want: "https://www.bbc.co.uk"},
+		{name: "auto typo with args", matching: accounts.CmdMatchingAuto, args: "gihtub conalli", want: "https://github.com/conalli"},
+		{name: "auto ambiguous prefix", matching: accounts.CmdMatchingAuto, args: "git repo", want: suggestURL + "cmd=github&cmd=gitlab&q=git+repo"},
+		{name: "auto no match", matching: accounts.CmdMatchingAuto, args: "weather", want: "https://www.google.com/search?q=weather"},
+		{name: "suggest typo", matching: accounts.CmdMatchingSuggest, args: "bcc", want: suggestURL + "cmd=bbc&q=bcc"},
+		{name: "off typo", matching: accounts.CmdMatchingOff, args: "bcc", want: "https://www.google.com/search?q=bcc"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			body, err := tu.MakeJSONRequestBody(request.UpdateSettings{SearchEngine: accounts.SearchEngineGoogle, CmdMatching: c.matching})
+			if err != nil {
+				t.Fatalf("Couldn't create update settings request body.")
+			}
+			res, err := tu.RequestWithCookie("PUT", srv.URL+"/api/user/settings", tu.WithBody(body), tu.WithAPIKey(usr.APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create request to update settings: %v", err)
+			}
+			res.Body.Close()
+			if res.StatusCode != 200 {
+				t.Fatalf("Expected update settings request to give status code 200: got %d", res.StatusCode)
+			}
+			res, err = tu.RequestWithCookie("GET", srv.URL+"/api/search/"+url.PathEscape(c.args), tu.WithClient(client), tu.WithAPIKey(usr.APIKey))
+			if err != nil {
+				t.Fatalf("Could not create Search request - %v", err)
+			}
+			res.Body.Close()
+			if dest := res.Header.Get("Location"); dest != c.want {
+				t.Errorf("wanted %s: got %s", c.want, dest)
+			}
+		})
+	}
+}
+
+func TestSearchHelp(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIKey := db.Users["1"].APIKey
+	res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/help.json", tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Could not create help request - %v", err)
+	}
+	defer res.Body.Close()
+	var help []search.CommandHelp
+	if err := json.NewDecoder(res.Body).Decode(&help); err != nil {
+		t.Fatalf("Couldn't decode help: %v", err)
+	}
+	if len(help) != 3 || help[2].Name != "touch" || help[2].Aliases[0] != "add" || len(help[2].Flags) == 0 {
+		t.Errorf("Expected help for help, ls and touch: got %+v", help)
+	}
+
+	redirectURL := os.Getenv("ALLOWED_URL_BASE")
+	client := tu.NewRedirectClient()
+	tc := []struct {
+		args string
+		want string
+	}{
+		{args: "help", want: redirectURL + "/webcli/help"},
+		{args: "help ls", want: redirectURL + "/webcli/help?cmd=ls"},
+		{args: "help add", want: redirectURL + "/webcli/help?cmd=touch"},
+		{args: "help bbc", want: redirectURL + "/webcli/help"},
+	}
+	for _, c := range tc {
+		res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/"+url.PathEscape(c.args), tu.WithClient(client), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Could not create Search request - %v", err)
+		}
+		res.Body.Close()
+		if dest := res.Header.Get("Location"); dest != c.want {
+			t.Errorf("wanted %s: got %s", c.want, dest)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// SearchBookmarks is the handler for the bookmark/search GET endpoint. Searches the users bookmarks
+// for the q query param, optionally within a folder, returning a page of results by relevance.
+func SearchBookmarks(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		params := r.URL.Query()
+		query := bookmarks.SearchQuery{
+			Query:  params.Get(bookmarks.BookmarksSearchQueryKey),
+			Folder: params.Get(bookmarks.BookmarksSearchFolderKey),
+		}
+		var convErr error
+		if page := params.Get(bookmarks.BookmarksSearchPageKey); len(page) > 0 {
+			query.Page, convErr = strconv.Atoi(page)
+		}
+		if limit := params.Get(bookmarks.BookmarksSearchLimitKey); len(limit) > 0 && convErr == nil {
+			query.Limit, convErr = strconv.Atoi(limit)
+		}
+		if convErr != nil {
+			log.Errorf("could not parse search paging params: %v", convErr)
+			apierr.APIErrorResponse(w, apierr.NewBadRequestError("invalid page or limit"))
+			return
+		}
+		results, err := b.SearchBookmarks(r.Context(), query, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to search bookmarks: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully searched bookmarks")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(results)
+	}
+}
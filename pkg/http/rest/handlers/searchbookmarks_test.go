@@ -0,0 +1,132 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestSearchBookmarks(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go docs", Path: ",Dev,", URL: "https://go.dev/doc/"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Effective Go", Path: ",Dev,", URL: "https://go.dev/doc/effective_go"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "BBC News", Path: bookmarks.BookmarksBasePath, URL: "https://www.bbc.co.uk/news"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f4", APIKey: "3f0c7f36-1f3a-4c8e-9d6b-6a3b1f2e4d5c", Name: "Other Go", URL: "https://go.dev/"},
+	)
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	tc := []struct {
+		name       string
+		query      string
+		statusCode int
+		total      int
+		ids        []string
+	}{
+		{
+			name:       "Name matches rank first",
+			query:      "?q=go",
+			statusCode: 200,
+			total:      2,
+			ids:        []string{"62c7e0a1f1d2b3a4c5d6e7f2", "62c7e0a1f1d2b3a4c5d6e7f1"},
+		},
+		{
+			name:       "Equal scores ordered by name",
+			query:      "?q=bbc",
+			statusCode: 200,
+			total:      2,
+			ids:        []string{"c55fdaace3388c2189875fc5", "62c7e0a1f1d2b3a4c5d6e7f3"},
+		},
+		{
+			name:       "URL path matches",
+			query:      "?q=effective_go",
+			statusCode: 200,
+			total:      1,
+			ids:        []string{"62c7e0a1f1d2b3a4c5d6e7f2"},
+		},
+		{
+			name:       "Folder path matches",
+			query:      "?q=news",
+			statusCode: 200,
+			total:      3,
+			ids:        []string{"62c7e0a1f1d2b3a4c5d6e7f3", "newsfolderid", "c55fdaace3388c2189875fc5"},
+		},
+		{
+			name:       "Scoped to folder",
+			query:      "?q=news&folder=,News,",
+			statusCode: 200,
+			total:      1,
+			ids:        []string{"c55fdaace3388c2189875fc5"},
+		},
+		{
+			name:       "Paged",
+			query:      "?q=go&page=2&limit=1",
+			statusCode: 200,
+			total:      2,
+			ids:        []string{"62c7e0a1f1d2b3a4c5d6e7f1"},
+		},
+		{
+			name:       "No matches",
+			query:      "?q=weather",
+			statusCode: 200,
+			total:      0,
+			ids:        []string{},
+		},
+		{
+			name:       "Missing query",
+			query:      "",
+			statusCode: 400,
+		},
+		{
+			name:       "Invalid limit",
+			query:      "?q=go&limit=1000",
+			statusCode: 400,
+		},
+		{
+			name:       "Invalid folder",
+			query:      "?q=go&folder=Dev",
+			statusCode: 400,
+		},
+	}
+	APIURL := srv.URL + "/api/bookmark/search"
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := tu.RequestWithCookie("GET", APIURL+c.query, tu.WithAPIKey(APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create request to search bookmarks with cookie.")
+			}
+			defer res.Body.Close()
+			if res.StatusCode != c.statusCode {
+				t.Fatalf("Expected search bookmarks request to give status code %d: got %d", c.statusCode, res.StatusCode)
+			}
+			if c.statusCode != 200 {
+				return
+			}
+			var response bookmarks.SearchResults
+			err = json.NewDecoder(res.Body).Decode(&response)
+			if err != nil {
+				t.Fatalf("Couldn't decode json body upon searching bookmarks.")
+			}
+			if response.Total != c.total {
+				t.Errorf("Expected %d results in total: got %d", c.total, response.Total)
+			}
+			if len(response.Results) != len(c.ids) {
+				t.Fatalf("Expected %d results: got %d", len(c.ids), len(response.Results))
+			}
+			for i, id := range c.ids {
+				if response.Results[i].ID != id {
+					t.Errorf("Expected result %d to be %s: got %s", i, id, response.Results[i].ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestSettings(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks, bookmarks.Bookmark{
+		ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Go blog", Path: bookmarks.BookmarksBasePath, URL: "https://go.dev/blog/",
+	})
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	client := tu.NewRedirectClient()
+	settingsURL := srv.URL + "/api/user/settings"
+
+	getSettings := func() accounts.Settings {
+		res, err := tu.RequestWithCookie("GET", settingsURL, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to get settings: %v", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("Expected get settings request to give status code 200: got %d", res.StatusCode)
+		}
+		var settings accounts.Settings
+		if err := json.NewDecoder(res.Body).Decode(&settings); err != nil {
+			t.Fatalf("Couldn't decode settings: %v", err)
+		}
+		return settings
+	}
+	updateSettings := func(req request.UpdateSettings, statusCode int) {
+		body, err := tu.MakeJSONRequestBody(req)
+		if err != nil {
+			t.Fatalf("Couldn't create update settings request body.")
+		}
+		res, err := tu.RequestWithCookie("PUT", settingsURL, tu.WithBody(body), tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create request to update settings: %v", err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected update settings request %+v to give status code %d: got %d", req, statusCode, res.StatusCode)
+		}
+	}
+	search := func(args, want string) {
+		t.Helper()
+		// Search twice so that the user is read from the db and then from the cache.
+		for i := 0; i < 2; i++ {
+			res, err := tu.RequestWithCookie("GET", srv.URL+"/api/search/"+url.PathEscape(args), tu.WithClient(client), tu.WithAPIKey(APIKey))
+			if err != nil {
+				t.Fatalf("Could not create Search request - %v", err)
+			}
+			res.Body.Close()
+			if dest := res.Header.Get("Location"); dest != want {
+				t.Errorf("wanted search for %q to go to %s: got %s", args, want, dest)
+			}
+		}
+	}
+
+	if got := getSettings(); got.SearchEngine != accounts.SearchEngineGoogle {
+		t.Errorf("Expected google to be the default search engine: got %+v", got)
+	}
+	search("golang generics & maps", "https://www.google.com/search?q=golang+generics+%26+maps")
+
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineDuckDuckGo}, 200)
+	if got := getSettings(); got.SearchEngine != accounts.SearchEngineDuckDuckGo {
+		t.Errorf("Expected duckduckgo search engine: got %+v", got)
+	}
+	search("golang generics", "https://duckduckgo.com/?q=golang+generics")
+	search("bbc", "https://www.bbc.co.uk")
+
+	updateSettings(request.UpdateSettings{SearchEngine: "altavista"}, 400)
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineGoogle, CmdMatching: "sometimes"}, 400)
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineCustom}, 400)
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineCustom, SearchURL: "https://search.example.com/"}, 400)
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineCustom, SearchURL: "https://search.example.com/?q={*"}, 400)
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineCustom, SearchURL: "https://search.example.com/?q={*}"}, 200)
+	search("golang generics", "https://search.example.com/?q=golang+generics")
+
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineBookmarks, SearchURL: "https://kagi.com/search?q={*}"}, 200)
+	search("go blog", "https://go.dev/blog/")
+	search("rust book", "https://kagi.com/search?q=rust+book")
+	updateSettings(request.UpdateSettings{SearchEngine: accounts.SearchEngineBookmarks}, 200)
+	search("rust book", "https://www.google.com/search?q=rust+book")
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
+)
+
+// RevokeShareResponse represents a successful response from the /bookmark/shares/{slug} DELETE endpoint.
+type RevokeShareResponse struct {
+	Slug       string `json:"slug"`
+	NumRevoked int    `json:"num_revoked"`
+}
+
+// ShareFolder is the handler for the bookmark/{id}/share POST endpoint. Publishes one of the users
+// folders so that it can be read by anyone with the returned slug.
+func ShareFolder(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		shareReq, parseErr := request.DecodeJSONRequest[request.ShareFolder](r.Body)
+		if parseErr != nil && !errors.Is(parseErr, io.EOF) {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		folderID := mux.Vars(r)["id"]
+		share, err := b.ShareFolder(r.Context(), folderID, shareReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to share a folder: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully shared folder")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(share)
+	}
+}
+
+// GetShares is the handler for the bookmark/shares GET endpoint. Returns the folders the user has shared.
+func GetShares(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		shares, err := b.GetShares(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get shares: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved shares")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(shares)
+	}
+}
+
+// RevokeShare is the handler for the bookmark/shares/{slug} DELETE endpoint. Stops a shared folder
+// being readable.
+func RevokeShare(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		slug := mux.Vars(r)["slug"]
+		numRevoked, err := b.RevokeShare(r.Context(), slug, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to revoke a share: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully revoked share")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RevokeShareResponse{Slug: slug, NumRevoked: numRevoked})
+	}
+}
+
+// GetSharedFolder is the handler for the public share/{slug} GET endpoint. Returns a shared folder and
+// everything inside it as JSON or, when format is html, as a Netscape bookmark file.
+func GetSharedFolder(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		slug := mux.Vars(r)["slug"]
+		switch r.URL.Query().Get(bookmarks.ShareFormatKey) {
+		case "", bookmarks.ShareFormatJSON:
+			folder, err := b.GetSharedFolder(r.Context(), slug)
+			if err != nil {
+				log.Errorf("error returned while trying to get a shared folder: %v", err)
+				apierr.APIErrorResponse(w, err)
+				return
+			}
+			log.Info("successfully retrieved shared folder")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(folder)
+		case bookmarks.ShareFormatHTML:
+			w.Header().Set("Content-Type", bookmarks.BookmarksExportContentType)
+			sw := &streamWriter{ResponseWriter: w}
+			err := b.ExportSharedFolder(r.Context(), sw, slug)
+			if err != nil {
+				log.Errorf("error returned while trying to export a shared folder: %v", err)
+				sw.abortIfStarted()
+				apierr.APIErrorResponse(w, err)
+				return
+			}
+			log.Info("successfully exported shared folder")
+		default:
+			apierr.APIErrorResponse(w, apierr.NewBadRequestError("format must be json or html"))
+		}
+	}
+}
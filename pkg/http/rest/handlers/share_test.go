@@ -0,0 +1,123 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestShareFolder(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/", Tags: []string{"go"}},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/"},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f4", APIKey: APIKey, Name: "Bank", Path: bookmarks.BookmarksBasePath, URL: "https://bank.example.com/"},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+	shareURL := srv.URL + "/api/share/"
+
+	request := func(method, URL, body string, statusCode int, v any) {
+		var reqBody io.Reader
+		if len(body) > 0 {
+			reqBody = strings.NewReader(body)
+		}
+		res, err := tu.RequestWithCookie(method, URL, tu.WithAPIKey(APIKey), tu.WithBody(reqBody))
+		if err != nil {
+			t.Fatalf("Couldn't create %s request to %s with cookie", method, URL)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected %s request to %s to give status code %d: got %d", method, URL, statusCode, res.StatusCode)
+		}
+		if v != nil {
+			if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+				t.Fatalf("Couldn't decode json body from %s", URL)
+			}
+		}
+	}
+	public := func(URL string, statusCode int) string {
+		res, err := http.Get(URL)
+		if err != nil {
+			t.Fatalf("Couldn't get %s: %v", URL, err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected request to %s to give status code %d: got %d", URL, statusCode, res.StatusCode)
+		}
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Couldn't read body from %s: %v", URL, err)
+		}
+		return string(body)
+	}
+
+	var share bookmarks.Share
+	request("POST", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f0/share", "", 201, &share)
+	if len(share.Slug) != 32 || share.FolderID != "62c7e0a1f1d2b3a4c5d6e7f0" || share.ExpiresAt != nil {
+		t.Fatalf("Expected share of Dev without expiry: got %+v", share)
+	}
+	request("POST", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f4/share", "", 404, nil)
+	request("POST", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f0/share", `{"expires_at": "2020-01-01T00:00:00Z"}`, 400, nil)
+
+	body := public(shareURL+share.Slug, 200)
+	if strings.Contains(body, "api_key") || strings.Contains(body, APIKey) {
+		t.Errorf("Expected shared folder not to include api keys: got %s", body)
+	}
+	var folder bookmarks.SharedFolder
+	if err := json.Unmarshal([]byte(body), &folder); err != nil {
+		t.Fatalf("Couldn't decode shared folder: %v", err)
+	}
+	if folder.Name != "Dev" || len(folder.Bookmarks) != 1 || len(folder.Folders) != 1 || folder.Folders[0].Bookmarks[0].Name != "Go docs" {
+		t.Errorf("Expected Dev folder with GitHub and the Go folder: got %+v", folder)
+	}
+	html := public(shareURL+share.Slug+"?format=html", 200)
+	if !strings.HasPrefix(html, "<!DOCTYPE NETSCAPE-Bookmark-file-1>") || !strings.Contains(html, "https://go.dev/doc/") || strings.Contains(html, "bank.example.com") {
+		t.Errorf("Expected Netscape bookmark file of the Dev folder: got %s", html)
+	}
+	public(shareURL+share.Slug+"?format=xml", 400)
+	public(shareURL+"share", 400)
+
+	expiry := time.Now().Add(time.Hour).UTC()
+	var expiring bookmarks.Share
+	request("POST", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f1/share", `{"expires_at": "`+expiry.Format(time.RFC3339)+`"}`, 201, &expiring)
+	public(shareURL+expiring.Slug, 200)
+	for i := range db.Shares {
+		if db.Shares[i].Slug == expiring.Slug {
+			past := time.Now().Add(-time.Minute)
+			db.Shares[i].ExpiresAt = &past
+		}
+	}
+	public(shareURL+expiring.Slug, 404)
+
+	var shares []bookmarks.Share
+	request("GET", APIURL+"/shares", "", 200, &shares)
+	if len(shares) != 2 || shares[0].Slug != expiring.Slug {
+		t.Errorf("Expected 2 shares, newest first: got %+v", shares)
+	}
+	var revoked handlers.RevokeShareResponse
+	request("DELETE", APIURL+"/shares/"+share.Slug, "", 200, &revoked)
+	if revoked.NumRevoked != 1 {
+		t.Errorf("Expected 1 share revoked: got %+v", revoked)
+	}
+	request("DELETE", APIURL+"/shares/"+share.Slug, "", 404, nil)
+	public(shareURL+share.Slug, 404)
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
+)
+
+// RestoreSnapshotResponse represents a successful response from the /bookmark/snapshots/{id}/restore POST endpoint.
+type RestoreSnapshotResponse struct {
+	ID          string `json:"id"`
+	NumRestored int    `json:"num_restored"`
+}
+
+// CreateSnapshot is the handler for the bookmark/snapshots POST endpoint. Saves a copy of all of the
+// users bookmarks, optionally with a name.
+func CreateSnapshot(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		snapshotReq, parseErr := request.DecodeJSONRequest[request.CreateSnapshot](r.Body)
+		if parseErr != nil && !errors.Is(parseErr, io.EOF) {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		snapshot, err := b.CreateSnapshot(r.Context(), snapshotReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to create a snapshot: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully created snapshot")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(snapshot)
+	}
+}
+
+// GetSnapshots is the handler for the bookmark/snapshots GET endpoint. Returns the users snapshots,
+// newest first.
+func GetSnapshots(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		snapshots, err := b.GetSnapshots(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get snapshots: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved snapshots")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(snapshots)
+	}
+}
+
+// DiffSnapshot is the handler for the bookmark/snapshots/{id}/diff GET endpoint. Returns the bookmarks
+// that have been added, removed or changed since the snapshot was taken.
+func DiffSnapshot(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		snapshotID := mux.Vars(r)["id"]
+		diff, err := b.DiffSnapshot(r.Context(), snapshotID, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to diff a snapshot: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully compared snapshot")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(diff)
+	}
+}
+
+// RestoreSnapshot is the handler for the bookmark/snapshots/{id}/restore POST endpoint. Replaces all of
+// the users bookmarks with those in the snapshot.
+func RestoreSnapshot(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		snapshotID := mux.Vars(r)["id"]
+		numRestored, err := b.RestoreSnapshot(r.Context(), snapshotID, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to restore a snapshot: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully restored snapshot")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(RestoreSnapshotResponse{ID: snapshotID, NumRestored: numRestored})
+	}
+}
@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestSnapshots(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go docs", Path: ",Dev,", URL: "https://go.dev/doc/"},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/"},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	request := func(method, URL, body string, statusCode int, v any) {
+		var reqBody io.Reader
+		if len(body) > 0 {
+			reqBody = strings.NewReader(body)
+		}
+		res, err := tu.RequestWithCookie(method, URL, tu.WithAPIKey(APIKey), tu.WithBody(reqBody))
+		if err != nil {
+			t.Fatalf("Couldn't create %s request to %s with cookie", method, URL)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected %s request to %s to give status code %d: got %d", method, URL, statusCode, res.StatusCode)
+		}
+		if v != nil {
+			if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+				t.Fatalf("Couldn't decode json body from %s", URL)
+			}
+		}
+	}
+
+	var snapshot bookmarks.Snapshot
+	request("POST", APIURL+"/snapshots", `{"name": "before cleanup"}`, 201, &snapshot)
+	if len(snapshot.ID) == 0 || snapshot.Name != "before cleanup" || snapshot.Count != 3 || snapshot.Size == 0 {
+		t.Fatalf("Expected named snapshot of 3 bookmarks: got %+v", snapshot)
+	}
+	request("POST", APIURL+"/snapshots", "", 201, nil)
+	request("POST", APIURL+"/snapshots", `{"name": `, 400, nil)
+
+	var snapshots []bookmarks.Snapshot
+	request("GET", APIURL+"/snapshots", "", 200, &snapshots)
+	if len(snapshots) != 2 || snapshots[1].ID != snapshot.ID {
+		t.Fatalf("Expected 2 snapshots, newest first: got %+v", snapshots)
+	}
+
+	// Change the bookmarks after the first snapshot was taken.
+	request("PATCH", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f1", `{"name": "Go documentation"}`, 200, nil)
+	request("DELETE", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f2", "", 200, nil)
+	request("POST", APIURL, `{"name": "Rust", "path": ",Dev,", "url": "https://www.rust-lang.org/", "skip_enrichment": true}`, 200, nil)
+
+	var diff bookmarks.SnapshotDiff
+	request("GET", APIURL+"/snapshots/"+snapshot.ID+"/diff", "", 200, &diff)
+	if diff.SnapshotID != snapshot.ID {
+		t.Errorf("Expected diff of snapshot %s: got %s", snapshot.ID, diff.SnapshotID)
+	}
+	if len(diff.Added) != 1 || diff.Added[0].Name != "Rust" {
+		t.Errorf("Expected Rust to have been added: got %+v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != "62c7e0a1f1d2b3a4c5d6e7f2" {
+		t.Errorf("Expected GitHub to have been removed: got %+v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].After.Name != "Go documentation" || strings.Join(diff.Changed[0].Fields, ",") != "name" {
+		t.Errorf("Expected Go docs to have been renamed: got %+v", diff.Changed)
+	}
+
+	var restored handlers.RestoreSnapshotResponse
+	request("POST", APIURL+"/snapshots/"+snapshot.ID+"/restore", "", 200, &restored)
+	if restored.ID != snapshot.ID || restored.NumRestored != 3 {
+		t.Errorf("Expected 3 bookmarks restored from snapshot: got %+v", restored)
+	}
+	request("GET", APIURL+"/snapshots/"+snapshot.ID+"/diff", "", 200, &diff)
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 {
+		t.Errorf("Expected no differences after restoring snapshot: got %+v", diff)
+	}
+
+	request("GET", APIURL+"/snapshots/62c7e0a1f1d2b3a4c5d6e7ff/diff", "", 404, nil)
+	request("POST", APIURL+"/snapshots/62c7e0a1f1d2b3a4c5d6e7ff/restore", "", 404, nil)
+	request("POST", APIURL+"/snapshots/snapshot/restore", "", 400, nil)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
+)
+
+// RestoreBookmarkResponse represents a successful response from the /bookmark/trash/{id}/restore POST endpoint.
+type RestoreBookmarkResponse struct {
+	ID          string `json:"id"`
+	NumRestored int    `json:"num_restored"`
+	NumCreated  int    `json:"num_created"`
+}
+
+// GetTrash is the handler for the bookmark/trash GET endpoint. Returns the users deleted bookmarks
+// and folders.
+func GetTrash(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		items, err := b.GetTrash(r.Context(), APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to get trash: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully retrieved trash")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(items)
+	}
+}
+
+// RestoreBookmark is the handler for the bookmark/trash/{id}/restore POST endpoint. Restores a deleted
+// bookmark, or a deleted folder and its contents, to its original path.
+func RestoreBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		bookmarkID := mux.Vars(r)["id"]
+		result, err := b.RestoreBookmark(r.Context(), bookmarkID, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to restore a bookmark: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully restored bookmark")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		res := RestoreBookmarkResponse{
+			ID:          bookmarkID,
+			NumRestored: result.Restored,
+			NumCreated:  result.Created,
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
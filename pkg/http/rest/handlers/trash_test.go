@@ -0,0 +1,187 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestTrash(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/"},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	request := func(method, URL string, statusCode int, v any) {
+		res, err := tu.RequestWithCookie(method, URL, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create %s request to %s with cookie", method, URL)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected %s request to %s to give status code %d: got %d", method, URL, statusCode, res.StatusCode)
+		}
+		if v != nil {
+			if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+				t.Fatalf("Couldn't decode json body from %s", URL)
+			}
+		}
+	}
+	getTrash := func() []bookmarks.TrashItem {
+		var items []bookmarks.TrashItem
+		request("GET", APIURL+"/trash", 200, &items)
+		return items
+	}
+	countBookmarks := func() int {
+		var page bookmarks.BookmarkPage
+		request("GET", APIURL+"/list", 200, &page)
+		return len(page.Bookmarks)
+	}
+
+	// Delete a bookmark on its own, then the folder it was in.
+	request("DELETE", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f2", 200, nil)
+	var deleted handlers.DeleteBookmarkResponse
+	request("DELETE", APIURL+"/62c7e0a1f1d2b3a4c5d6e7f0", 200, &deleted)
+	if deleted.NumDeleted != 3 {
+		t.Errorf("Expected folder delete to move 3 bookmarks to the trash: got %d", deleted.NumDeleted)
+	}
+	if n := countBookmarks(); n != 0 {
+		t.Errorf("Expected deleted bookmarks not to be listed: got %d", n)
+	}
+	items := getTrash()
+	if len(items) != 2 || items[0].ID != "62c7e0a1f1d2b3a4c5d6e7f0" || items[0].Contents != 2 || items[1].ID != "62c7e0a1f1d2b3a4c5d6e7f2" {
+		t.Fatalf("Expected trash to list the folder with 2 bookmarks then the bookmark: got %+v", items)
+	}
+	if items[0].DeletedAt == nil {
+		t.Errorf("Expected trashed folder to have a deletion time")
+	}
+
+	// Restoring a bookmark whose folders were deleted recreates them.
+	var restored handlers.RestoreBookmarkResponse
+	request("POST", APIURL+"/trash/62c7e0a1f1d2b3a4c5d6e7f2/restore", 200, &restored)
+	if restored.NumRestored != 1 || restored.NumCreated != 2 {
+		t.Errorf("Expected 1 bookmark restored and 2 folders created: got %+v", restored)
+	}
+	request("POST", APIURL+"/trash/62c7e0a1f1d2b3a4c5d6e7f2/restore", 404, nil)
+	request("POST", APIURL+"/trash/62c7e0a1f1d2b3a4c5d6e7f1/restore", 404, nil)
+
+	var folder bookmarks.Folder
+	request("GET", APIURL, 200, &folder)
+	if len(folder.Folders) != 1 || len(folder.Folders[0].Folders) != 1 || len(folder.Folders[0].Folders[0].Bookmarks) != 1 {
+		t.Fatalf("Expected restored bookmark to be in its original folder: got %+v", folder)
+	}
+	recreatedID := folder.Folders[0].ID
+	// The deleted Dev folder is restored alongside the recreated one.
+	request("POST", APIURL+"/trash/62c7e0a1f1d2b3a4c5d6e7f0/restore", 200, &restored)
+	if restored.NumRestored != 3 || restored.NumCreated != 0 {
+		t.Errorf("Expected folder and its 2 bookmarks to be restored: got %+v", restored)
+	}
+	if n := countBookmarks(); n != 6 {
+		t.Errorf("Expected 6 bookmarks after restoring the folder: got %d", n)
+	}
+	if items := getTrash(); len(items) != 0 {
+		t.Errorf("Expected trash to be empty: got %+v", items)
+	}
+	folder = bookmarks.Folder{}
+	request("GET", APIURL, 200, &folder)
+	if len(folder.Folders) != 2 {
+		t.Fatalf("Expected 2 Dev folders: got %+v", folder)
+	}
+	for _, f := range folder.Folders {
+		want := 0
+		if f.ID == recreatedID {
+			want = 1
+		}
+		if f.Name != "Dev" || len(f.Folders) != 1 || len(f.Folders[0].Bookmarks) != want {
+			t.Errorf("Expected each Dev folder to keep its own bookmarks: got %+v", f)
+		}
+	}
+}
+
+func TestRestoreBookmarkConflict(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	goDocs := bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: bookmarks.BookmarksBasePath, URL: "https://go.dev/doc/"}
+	db.Bookmarks = []bookmarks.Bookmark{goDocs}
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	APIURL := srv.URL + "/api/bookmark"
+
+	request := func(method, URL string, statusCode int) {
+		res, err := tu.RequestWithCookie(method, URL, tu.WithAPIKey(APIKey))
+		if err != nil {
+			t.Fatalf("Couldn't create %s request to %s with cookie", method, URL)
+		}
+		res.Body.Close()
+		if res.StatusCode != statusCode {
+			t.Fatalf("Expected %s request to %s to give status code %d: got %d", method, URL, statusCode, res.StatusCode)
+		}
+	}
+	request("DELETE", APIURL+"/"+goDocs.ID, 200)
+	// A bookmark with the same id is brought back, e.g. by restoring a snapshot.
+	db.Bookmarks = append(db.Bookmarks, goDocs)
+	request("POST", APIURL+"/trash/"+goDocs.ID+"/restore", 409)
+	if len(db.Trash) != 1 || len(db.Bookmarks) != 1 {
+		t.Errorf("Expected conflicting restore to leave the trash and bookmarks unchanged: got %+v and %+v", db.Trash, db.Bookmarks)
+	}
+}
+
+func TestRestoreMigratedTrash(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	deletedAt := time.Now().UTC().Add(-time.Hour)
+	db.Bookmarks = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+	}
+	db.Trash = []bookmarks.Bookmark{
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true, TrashID: "62c7e0a1f1d2b3a4c5d6e7f1", DeletedAt: &deletedAt},
+		{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/", TrashID: "62c7e0a1f1d2b3a4c5d6e7f1", DeletedAt: &deletedAt},
+	}
+	db.MigrateBookmarkParents(context.Background())
+	db.MigrateTrashParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+
+	URL := srv.URL + "/api/bookmark/trash/62c7e0a1f1d2b3a4c5d6e7f1/restore"
+	res, err := tu.RequestWithCookie("POST", URL, tu.WithAPIKey(APIKey))
+	if err != nil {
+		t.Fatalf("Couldn't create POST request to %s with cookie", URL)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("Expected status code 200: got %d", res.StatusCode)
+	}
+	for _, b := range db.Bookmarks {
+		if b.ID != "62c7e0a1f1d2b3a4c5d6e7f2" {
+			continue
+		}
+		if b.ParentID != "62c7e0a1f1d2b3a4c5d6e7f1" || !slices.Equal(b.Ancestors, []string{"62c7e0a1f1d2b3a4c5d6e7f0", "62c7e0a1f1d2b3a4c5d6e7f1"}) {
+			t.Errorf("Expected restored bookmark to be inside the restored folder: got %+v", b)
+		}
+		return
+	}
+	t.Errorf("Expected deleted folder contents to be restored: got %+v", db.Bookmarks)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/gorilla/mux"
+)
+
+// UpdateBookmarkResponse represents a successful response from the /bookmark/{id} PATCH endpoint.
+type UpdateBookmarkResponse struct {
+	ID         string `json:"id"`
+	NumUpdated int    `json:"num_updated"`
+}
+
+// UpdateBookmark is the handler for the bookmark PATCH endpoint.
+func UpdateBookmark(b bookmarks.Service, log logs.Logger) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		APIKey, ok := request.GetAPIKeyFromContext(r.Context())
+		if len(APIKey) < 1 || !ok {
+			log.Error("could not get APIKey from context")
+			apierr.APIErrorResponse(w, apierr.NewInternalServerError())
+			return
+		}
+		updateReq, parseErr := request.DecodeJSONRequest[request.UpdateBookmark](r.Body)
+		if parseErr != nil {
+			errRes := apierr.NewBadRequestError("could not parse request body")
+			apierr.APIErrorResponse(w, errRes)
+			return
+		}
+		bookmarkID := mux.Vars(r)["id"]
+		numUpdated, err := b.UpdateBookmark(r.Context(), bookmarkID, updateReq, APIKey)
+		if err != nil {
+			log.Errorf("error returned while trying to update a bookmark: %v", err)
+			apierr.APIErrorResponse(w, err)
+			return
+		}
+		log.Info("successfully updated bookmark")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		res := UpdateBookmarkResponse{
+			ID:         bookmarkID,
+			NumUpdated: numUpdated,
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
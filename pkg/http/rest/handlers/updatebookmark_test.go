@@ -0,0 +1,151 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest"
+	"github.com/conalli/bookshelf-backend/pkg/http/rest/handlers"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+func TestUpdateBookmark(t *testing.T) {
+	t.Parallel()
+	db := tu.NewDB().AddDefaultUsers()
+	APIKey := db.Users["1"].APIKey
+	db.Bookmarks = append(db.Bookmarks,
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f0", APIKey: APIKey, Name: "Dev", Path: bookmarks.BookmarksBasePath, IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f1", APIKey: APIKey, Name: "Go", Path: ",Dev,", IsFolder: true},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f2", APIKey: APIKey, Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+		bookmarks.Bookmark{ID: "62c7e0a1f1d2b3a4c5d6e7f3", APIKey: APIKey, Name: "GitHub", Path: ",Dev,", URL: "https://github.com/"},
+	)
+	db.MigrateBookmarkParents(context.Background())
+	r := rest.NewRouter(tu.NewLogger(), validator.New(), db, tu.NewCache(), nil)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	str := func(s string) *string { return &s }
+	tc := []struct {
+		name       string
+		id         string
+		req        request.UpdateBookmark
+		APIKey     string
+		statusCode int
+		numUpdated int
+	}{
+		{
+			name:       "Rename bookmark",
+			id:         db.Bookmarks[1].ID,
+			req:        request.UpdateBookmark{Name: str("BBC News"), URL: str("https://www.bbc.co.uk/news")},
+			APIKey:     APIKey,
+			statusCode: 200,
+			numUpdated: 1,
+		},
+		{
+			name:       "Rename folder with descendants",
+			id:         "62c7e0a1f1d2b3a4c5d6e7f0",
+			req:        request.UpdateBookmark{Name: str("Code")},
+			APIKey:     APIKey,
+			statusCode: 200,
+			numUpdated: 4,
+		},
+		{
+			name:       "Move folder into another folder",
+			id:         "62c7e0a1f1d2b3a4c5d6e7f1",
+			req:        request.UpdateBookmark{Path: str(",News,")},
+			APIKey:     APIKey,
+			statusCode: 200,
+			numUpdated: 2,
+		},
+		{
+			name:       "Move folder into itself",
+			id:         "62c7e0a1f1d2b3a4c5d6e7f0",
+			req:        request.UpdateBookmark{Path: str(",Code,")},
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
+		{
+			name:       "Move bookmark into folder by id",
+			id:         "62c7e0a1f1d2b3a4c5d6e7f3",
+			req:        request.UpdateBookmark{ParentID: str("62c7e0a1f1d2b3a4c5d6e7f1")},
+			APIKey:     APIKey,
+			statusCode: 200,
+			numUpdated: 1,
+		},
+		{
+			name:       "Move bookmark into missing folder",
+			id:         "62c7e0a1f1d2b3a4c5d6e7f3",
+			req:        request.UpdateBookmark{ParentID: str("62c7e0a1f1d2b3a4c5d6e7ff")},
+			APIKey:     APIKey,
+			statusCode: 404,
+		},
+		{
+			name:       "Invalid path",
+			id:         db.Bookmarks[1].ID,
+			req:        request.UpdateBookmark{Path: str("News")},
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
+		{
+			name:       "No fields to update",
+			id:         db.Bookmarks[1].ID,
+			req:        request.UpdateBookmark{},
+			APIKey:     APIKey,
+			statusCode: 400,
+		},
+		{
+			name:       "Bookmark owned by another user",
+			id:         db.Bookmarks[1].ID,
+			req:        request.UpdateBookmark{Name: str("mine now")},
+			APIKey:     uuid.New().String(),
+			statusCode: 404,
+		},
+	}
+	APIURL := srv.URL + "/api/bookmark/"
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			body, err := tu.MakeJSONRequestBody(c.req)
+			if err != nil {
+				t.Fatalf("Couldn't create update bookmark request body")
+			}
+			res, err := tu.RequestWithCookie("PATCH", APIURL+c.id, tu.WithBody(body), tu.WithAPIKey(c.APIKey))
+			if err != nil {
+				t.Fatalf("Couldn't create request to update bookmark with cookie.")
+			}
+			defer res.Body.Close()
+			if res.StatusCode != c.statusCode {
+				t.Errorf("Expected update bookmark request to give status code %d: got %d", c.statusCode, res.StatusCode)
+			}
+			if res.StatusCode >= 400 {
+				return
+			}
+			var response handlers.UpdateBookmarkResponse
+			err = json.NewDecoder(res.Body).Decode(&response)
+			if err != nil {
+				t.Fatalf("Couldn't decode json body upon updating bookmark.")
+			}
+			if response.NumUpdated != c.numUpdated {
+				t.Errorf("Expected %d bookmarks to be updated: got %d", c.numUpdated, response.NumUpdated)
+			}
+		})
+	}
+	wantPaths := map[string]string{
+		"62c7e0a1f1d2b3a4c5d6e7f0": bookmarks.BookmarksBasePath,
+		"62c7e0a1f1d2b3a4c5d6e7f1": ",News,",
+		"62c7e0a1f1d2b3a4c5d6e7f2": ",News,Go,",
+		"62c7e0a1f1d2b3a4c5d6e7f3": ",News,Go,",
+	}
+	for _, b := range db.Bookmarks {
+		if want, ok := wantPaths[b.ID]; ok && b.Path != want {
+			t.Errorf("Expected bookmark %s to have path %q: got %q", b.Name, want, b.Path)
+		}
+		if b.ID == "62c7e0a1f1d2b3a4c5d6e7f3" && b.ParentID != "62c7e0a1f1d2b3a4c5d6e7f1" {
+			t.Errorf("Expected bookmark %s to be moved into folder Go: got parent %q", b.Name, b.ParentID)
+		}
+	}
+}
@@ -27,7 +27,7 @@ func NewRouter(l logs.Logger, v *validator.Validate, store db.Storage, cache db.
 	a := auth.NewService(l, v, p, store, cache)
 	u := accounts.NewUserService(l, v, store, cache)
 	s := search.NewService(l, v, store, cache)
-	b := bookmarks.NewService(l, v, store)
+	b := bookmarks.NewService(l, v, store, cache)
 	r := &Router{l, mux.NewRouter()}
 
 	api := r.initRouter()
@@ -35,6 +35,7 @@ func NewRouter(l logs.Logger, v *validator.Validate, store db.Storage, cache db.
 	addUserRoutes(api, u, l)
 	addSearchRoutes(api, s, l)
 	addBookmarkRoutes(api, b, l)
+	addShareRoutes(api, b, l)
 
 	r.router.Use(middleware.RouteLogger(l))
 	return r
@@ -87,6 +88,8 @@ func addUserRoutes(router *mux.Router, u accounts.UserService, l logs.Logger) {
 	user.HandleFunc("/cmd", handlers.GetCmds(u, l)).Methods("GET")
 	user.HandleFunc("/cmd", handlers.AddCmd(u, l)).Methods("POST")
 	user.HandleFunc("/cmd", handlers.DeleteCmd(u, l)).Methods("PATCH")
+	user.HandleFunc("/settings", handlers.GetSettings(u, l)).Methods("GET")
+	user.HandleFunc("/settings", handlers.UpdateSettings(u, l)).Methods("PUT")
 }
 
 func addBookmarkRoutes(router *mux.Router, b bookmarks.Service, l logs.Logger) {
@@ -94,13 +97,43 @@ func addBookmarkRoutes(router *mux.Router, b bookmarks.Service, l logs.Logger) {
 	bookmarks.Use(middleware.Authorized(l))
 	bookmarks.HandleFunc("", handlers.GetAllBookmarks(b, l)).Methods("GET")
 	bookmarks.HandleFunc("", handlers.AddBookmark(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/tags", handlers.GetTags(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/broken", handlers.GetBrokenBookmarks(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/check", handlers.CheckLinks(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/duplicates", handlers.GetDuplicates(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/duplicates/merge", handlers.MergeDuplicates(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/order", handlers.ReorderBookmarks(b, l)).Methods("PUT")
+	bookmarks.HandleFunc("/trash", handlers.GetTrash(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/trash/{id}/restore", handlers.RestoreBookmark(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/snapshots", handlers.GetSnapshots(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/snapshots", handlers.CreateSnapshot(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/snapshots/{id}/diff", handlers.DiffSnapshot(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/snapshots/{id}/restore", handlers.RestoreSnapshot(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/shares", handlers.GetShares(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/shares/{slug}", handlers.RevokeShare(b, l)).Methods("DELETE")
+	bookmarks.HandleFunc("/{id}", handlers.UpdateBookmark(b, l)).Methods("PATCH")
 	bookmarks.HandleFunc("/{id}", handlers.DeleteBookmark(b, l)).Methods("DELETE")
+	bookmarks.HandleFunc("/{id}/tags", handlers.AddTags(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/{id}/tags", handlers.RemoveTags(b, l)).Methods("DELETE")
+	bookmarks.HandleFunc("/{id}/share", handlers.ShareFolder(b, l)).Methods("POST")
 	bookmarks.HandleFunc("/folder", handlers.GetBookmarksFolder(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/list", handlers.ListBookmarks(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/search", handlers.SearchBookmarks(b, l)).Methods("GET")
+	bookmarks.HandleFunc("/export", handlers.ExportBookmarks(b, l)).Methods("GET")
 	bookmarks.HandleFunc("/file", handlers.AddBookmarksFile(b, l)).Methods("POST")
+	bookmarks.HandleFunc("/import/{jobID}", handlers.GetImportJob(b, l)).Methods("GET")
+}
+
+func addShareRoutes(router *mux.Router, b bookmarks.Service, l logs.Logger) {
+	share := router.PathPrefix("/share").Subrouter()
+	share.HandleFunc("/{slug}", handlers.GetSharedFolder(b, l)).Methods("GET")
 }
 
 func addSearchRoutes(router *mux.Router, s search.Service, l logs.Logger) {
 	search := router.PathPrefix("/search").Subrouter()
 	search.Use(middleware.AuthorizedSearch(l))
-	search.HandleFunc("/{args}", handlers.Search(s, l)).Methods("GET")
+	search.HandleFunc("/help.json", handlers.SearchHelp(s, l)).Methods("GET")
+	search.HandleFunc("/opensearch.xml", handlers.OpenSearch(s, l)).Methods("GET")
+	search.HandleFunc("/suggest", handlers.SearchSuggestions(s, l)).Methods("GET").Queries("q", "{q}")
+	search.HandleFunc("/{args:.+}", handlers.Search(s, l)).Methods("GET")
 }
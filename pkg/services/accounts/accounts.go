@@ -13,6 +13,9 @@ type User struct {
 	EmailVerified bool              `json:"email_verified" bson:"email_verified" redis:"email_verified"`
 	Locale        string            `json:"locale" bson:"locale" redis:"locale"`
 	Provider      string            `json:"provider" bson:"provider" redis:"provider"`
+	SearchEngine  string            `json:"search_engine,omitempty" bson:"search_engine,omitempty" redis:"search_engine"`
+	SearchURL     string            `json:"search_url,omitempty" bson:"search_url,omitempty" redis:"search_url"`
+	CmdMatching   string            `json:"cmd_matching,omitempty" bson:"cmd_matching,omitempty" redis:"cmd_matching"`
 	Cmds          map[string]string `json:"cmds,omitempty" bson:"cmds"`
 	Teams         map[string]string `json:"teams,omitempty" bson:"teams"`
 }
@@ -38,6 +38,10 @@ func (s *userService) AddCmd(ctx context.Context, requestData request.AddCmd, AP
 		s.log.Errorf("could not validate ADD CMD request: %v - %v", validateReqErr, validateAPIKeyErr)
 		return 0, apierr.NewBadRequestError("request format incorrect.")
 	}
+	if _, templateErr := ParseCmdTemplate(requestData.URL); templateErr != nil {
+		s.log.Errorf("could not validate ADD CMD request: %v", templateErr)
+		return 0, apierr.NewBadRequestError(templateErr.Error())
+	}
 	numUpdated, err := s.db.AddCmd(reqCtx, requestData, APIKey)
 	s.cache.DeleteCmds(ctx, APIKey)
 	return numUpdated, err
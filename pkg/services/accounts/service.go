@@ -16,6 +16,7 @@ type UserRepository interface {
 	AddCmd(reqCtx context.Context, requestData request.AddCmd, APIKey string) (int, apierr.Error)
 	DeleteCmd(ctx context.Context, requestData request.DeleteCmd, APIKey string) (int, apierr.Error)
 	Delete(reqCtx context.Context, requestData request.DeleteUser, APIKey string) (int, apierr.Error)
+	UpdateSettings(ctx context.Context, settings Settings, APIKey string) (int, apierr.Error)
 }
 
 // UserCache provides access to the cache.
@@ -34,6 +35,8 @@ type UserService interface {
 	AddCmd(reqCtx context.Context, requestData request.AddCmd, APIKey string) (int, apierr.Error)
 	DeleteCmd(ctx context.Context, requestData request.DeleteCmd, APIKey string) (int, apierr.Error)
 	Delete(ctx context.Context, requestData request.DeleteUser, APIKey string) (int, apierr.Error)
+	GetSettings(ctx context.Context, APIKey string) (*Settings, apierr.Error)
+	UpdateSettings(ctx context.Context, requestData request.UpdateSettings, APIKey string) (*Settings, apierr.Error)
 }
 
 type userService struct {
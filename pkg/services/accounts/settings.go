@@ -0,0 +1,126 @@
+package accounts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+)
+
+// Search engines that a user can search with when a cmd is not found.
+const (
+	SearchEngineGoogle     string = "google"
+	SearchEngineDuckDuckGo string = "duckduckgo"
+	SearchEngineKagi       string = "kagi"
+	SearchEngineBing       string = "bing"
+	// SearchEngineCustom searches with the users SearchURL template.
+	SearchEngineCustom string = "custom"
+	// SearchEngineBookmarks opens the users best matching bookmark, falling back to their SearchURL
+	// template, or Google if they do not have one, when none of their bookmarks match.
+	SearchEngineBookmarks string = "bookmarks"
+)
+
+// How cmds that are not found are matched against the users cmds.
+const (
+	// CmdMatchingAuto opens the closest cmd when there is only one likely match, and otherwise lists
+	// the closest cmds in the webcli.
+	CmdMatchingAuto string = "auto"
+	// CmdMatchingSuggest always lists the closest cmds in the webcli.
+	CmdMatchingSuggest string = "suggest"
+	// CmdMatchingOff searches for anything that is not a cmd.
+	CmdMatchingOff string = "off"
+)
+
+// searchEngineURLs are the cmd templates used to search with each web search engine.
+var searchEngineURLs = map[string]string{
+	SearchEngineGoogle:     "https://www.google.com/search?q={*}",
+	SearchEngineDuckDuckGo: "https://duckduckgo.com/?q={*}",
+	SearchEngineKagi:       "https://kagi.com/search?q={*}",
+	SearchEngineBing:       "https://www.bing.com/search?q={*}",
+}
+
+// Settings represents the preferences a user can change.
+type Settings struct {
+	SearchEngine string `json:"search_engine"`
+	SearchURL    string `json:"search_url,omitempty"`
+	CmdMatching  string `json:"cmd_matching"`
+}
+
+// Settings returns the users settings, using Google and automatic cmd matching when they have not
+// chosen otherwise.
+func (u User) Settings() Settings {
+	settings := Settings{SearchEngine: u.SearchEngine, SearchURL: u.SearchURL, CmdMatching: u.CmdMatching}
+	if len(settings.SearchEngine) == 0 {
+		settings.SearchEngine = SearchEngineGoogle
+	}
+	if len(settings.CmdMatching) == 0 {
+		settings.CmdMatching = CmdMatchingAuto
+	}
+	return settings
+}
+
+// WebSearchURL returns the cmd template used to search the web for anything that is not a cmd.
+func (s Settings) WebSearchURL() string {
+	if url, ok := searchEngineURLs[s.SearchEngine]; ok {
+		return url
+	}
+	if len(s.SearchURL) > 0 {
+		return s.SearchURL
+	}
+	return searchEngineURLs[SearchEngineGoogle]
+}
+
+// validateSearchURL checks that a custom search URL is a cmd template with somewhere to put the query.
+func validateSearchURL(searchURL string) error {
+	tmpl, err := ParseCmdTemplate(searchURL)
+	if err != nil {
+		return err
+	}
+	if !tmpl.HasPlaceholders() {
+		return fmt.Errorf("%w: search url needs a placeholder for the query, e.g. {*}", ErrInvalidCmdTemplate)
+	}
+	return nil
+}
+
+// GetSettings returns the users settings.
+func (s *userService) GetSettings(ctx context.Context, APIKey string) (*Settings, apierr.Error) {
+	user, err := s.UserInfo(ctx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	settings := user.Settings()
+	return &settings, nil
+}
+
+// UpdateSettings changes the users settings, returning the settings saved.
+func (s *userService) UpdateSettings(ctx context.Context, requestData request.UpdateSettings, APIKey string) (*Settings, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Struct(requestData)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("could not validate UPDATE SETTINGS request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	if requestData.SearchEngine == SearchEngineCustom && len(requestData.SearchURL) == 0 {
+		s.log.Error("could not validate UPDATE SETTINGS request: custom search engine without search url")
+		return nil, apierr.NewBadRequestError("search_url is required for a custom search engine")
+	}
+	if len(requestData.SearchURL) > 0 {
+		if err := validateSearchURL(requestData.SearchURL); err != nil {
+			s.log.Errorf("could not validate UPDATE SETTINGS request: %v", err)
+			return nil, apierr.NewBadRequestError(err.Error())
+		}
+	}
+	settings := User{
+		SearchEngine: requestData.SearchEngine,
+		SearchURL:    requestData.SearchURL,
+		CmdMatching:  requestData.CmdMatching,
+	}.Settings()
+	if _, err := s.db.UpdateSettings(reqCtx, settings, APIKey); err != nil {
+		return nil, err
+	}
+	s.cache.DeleteUser(ctx, APIKey)
+	return &settings, nil
+}
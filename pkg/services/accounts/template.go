@@ -0,0 +1,213 @@
+package accounts
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCmdTemplate is returned when a cmd URL has a malformed placeholder.
+var ErrInvalidCmdTemplate = errors.New("invalid cmd url template")
+
+// CmdTemplateAllArgs is the placeholder key that is replaced by every positional argument.
+const CmdTemplateAllArgs = "*"
+
+var (
+	placeholderName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	webScheme       = regexp.MustCompile(`(?i)^https?://`)
+	anyScheme       = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*://`)
+)
+
+// CmdTemplate represents a cmd URL with placeholders that are filled in from the arguments given
+// after the cmd. Placeholders are written in braces and can be:
+//
+//   - {1}, {2}...: the positional argument with that number.
+//   - {*}: every positional argument, separated by spaces.
+//   - {name}: the argument given as name=value or, if there is none, the next positional argument
+//     after those used by numbered placeholders.
+//
+// Any placeholder can have a default used when its argument is missing, e.g. {1:golang} or
+// {q:news}, and placeholders without a default are left empty. Values are query escaped inside
+// the query string and path escaped elsewhere, with colons escaped too. Slashes are kept in the
+// path so that one argument can fill several path segments, but not before the path starts, so
+// arguments can never change the scheme or host of the URL. Templates that do not start with an
+// http or https scheme are given http:// before they are filled in.
+type CmdTemplate struct {
+	parts []templatePart
+	names []string
+	// offset is the number of positional arguments used by numbered placeholders.
+	offset int
+}
+
+type templatePart struct {
+	literal     string
+	placeholder *placeholder
+}
+
+type placeholder struct {
+	key     string
+	def     string
+	inQuery bool
+	// inPath is set if the placeholder comes after the scheme and host of the URL.
+	inPath bool
+}
+
+// ParseCmdTemplate parses a cmd URL, returning an error wrapping ErrInvalidCmdTemplate if any of
+// its placeholders are malformed or the filled in URL would not be valid.
+func ParseCmdTemplate(cmdURL string) (*CmdTemplate, error) {
+	t := &CmdTemplate{}
+	if !webScheme.MatchString(cmdURL) {
+		t.addLiteral("http://")
+	}
+	inQuery, inFragment := false, false
+	var seen strings.Builder
+	rest := cmdURL
+	for len(rest) > 0 {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.addLiteral(rest)
+			break
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("%w: unexpected '}' at %q", ErrInvalidCmdTemplate, rest[open:])
+		}
+		literal := rest[:open]
+		t.addLiteral(literal)
+		seen.WriteString(literal)
+		if strings.Contains(literal, "#") {
+			inQuery, inFragment = false, true
+		} else if strings.Contains(literal, "?") && !inFragment {
+			inQuery = true
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] == '{' {
+			return nil, fmt.Errorf("%w: unclosed placeholder at %q", ErrInvalidCmdTemplate, rest[open:])
+		}
+		p, err := parsePlaceholder(rest[open+1 : open+1+end])
+		if err != nil {
+			return nil, err
+		}
+		p.inQuery = inQuery
+		p.inPath = hostEnded(seen.String())
+		seen.WriteString("x")
+		t.addPlaceholder(p)
+		rest = rest[open+end+2:]
+	}
+	if _, err := url.Parse(t.Expand([]string{"x"})); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCmdTemplate, err)
+	}
+	return t, nil
+}
+
+// hostEnded reports whether the start of a URL includes all of its scheme and host.
+func hostEnded(start string) bool {
+	return strings.ContainsAny(anyScheme.ReplaceAllString(start, ""), "/?#")
+}
+
+func parsePlaceholder(s string) (*placeholder, error) {
+	key, def, _ := strings.Cut(s, ":")
+	p := &placeholder{key: key, def: def}
+	if key == CmdTemplateAllArgs || placeholderName.MatchString(key) {
+		return p, nil
+	}
+	if n, err := strconv.Atoi(key); err == nil && n > 0 {
+		return p, nil
+	}
+	return nil, fmt.Errorf("%w: bad placeholder {%s}", ErrInvalidCmdTemplate, s)
+}
+
+func (t *CmdTemplate) addLiteral(s string) {
+	if len(s) > 0 {
+		t.parts = append(t.parts, templatePart{literal: s})
+	}
+}
+
+func (t *CmdTemplate) addPlaceholder(p *placeholder) {
+	t.parts = append(t.parts, templatePart{placeholder: p})
+	if n, err := strconv.Atoi(p.key); err == nil {
+		t.offset = max(t.offset, n)
+		return
+	}
+	if p.key != CmdTemplateAllArgs && !slices.Contains(t.names, p.key) {
+		t.names = append(t.names, p.key)
+	}
+}
+
+// HasPlaceholders reports whether the template has any placeholders to fill in.
+func (t *CmdTemplate) HasPlaceholders() bool {
+	for _, p := range t.parts {
+		if p.placeholder != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand fills in the templates placeholders from args, the arguments given after the cmd.
+func (t *CmdTemplate) Expand(args []string) string {
+	named := make(map[string]string)
+	var positional []string
+	for _, arg := range args {
+		if k, v, ok := strings.Cut(arg, "="); ok && slices.Contains(t.names, k) {
+			named[k] = v
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	next := t.offset
+	for _, name := range t.names {
+		if _, ok := named[name]; !ok && next < len(positional) {
+			named[name] = positional[next]
+			next++
+		}
+	}
+	var sb strings.Builder
+	for _, part := range t.parts {
+		p := part.placeholder
+		if p == nil {
+			sb.WriteString(part.literal)
+			continue
+		}
+		var val string
+		var ok bool
+		switch n, err := strconv.Atoi(p.key); {
+		case p.key == CmdTemplateAllArgs:
+			val, ok = strings.Join(positional, " "), len(positional) > 0
+		case err == nil:
+			if ok = n <= len(positional); ok {
+				val = positional[n-1]
+			}
+		default:
+			val, ok = named[p.key]
+		}
+		if !ok {
+			val = p.def
+		}
+		sb.WriteString(escapeArg(val, p.inQuery, p.inPath))
+	}
+	return sb.String()
+}
+
+// escapeArg escapes an argument for the query string or the path, keeping any slashes if it is in
+// the path. Colons are always escaped so that an argument cannot be read as a scheme.
+func escapeArg(arg string, inQuery, inPath bool) string {
+	if inQuery {
+		return url.QueryEscape(arg)
+	}
+	if !inPath {
+		return escapePathSegment(arg)
+	}
+	segments := strings.Split(arg, "/")
+	for i, s := range segments {
+		segments[i] = escapePathSegment(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+func escapePathSegment(s string) string {
+	return strings.ReplaceAll(url.PathEscape(s), ":", "%3A")
+}
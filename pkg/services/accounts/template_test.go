@@ -0,0 +1,60 @@
+package accounts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCmdTemplateExpand(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		name     string
+		template string
+		args     []string
+		want     string
+	}{
+		{name: "no placeholders ignores args", template: "https://www.bbc.co.uk", args: []string{"news"}, want: "https://www.bbc.co.uk"},
+		{name: "positional keeps slashes in path", template: "https://github.com/{1}", args: []string{"conalli/bookshelf"}, want: "https://github.com/conalli/bookshelf"},
+		{name: "positional path escaped", template: "https://en.wikipedia.org/wiki/{1}", args: []string{"a b?c"}, want: "https://en.wikipedia.org/wiki/a%20b%3Fc"},
+		{name: "all args query escaped", template: "https://www.google.com/search?q={*}", args: []string{"go", "generics", "&", "maps"}, want: "https://www.google.com/search?q=go+generics+%26+maps"},
+		{name: "missing arg left empty", template: "https://github.com/{1}", want: "https://github.com/"},
+		{name: "default used for missing arg", template: "https://pkg.go.dev/{1:std}", want: "https://pkg.go.dev/std"},
+		{name: "named from key value", template: "https://github.com/{user}/{repo}", args: []string{"repo=bookshelf", "user=conalli"}, want: "https://github.com/conalli/bookshelf"},
+		{name: "named from positional", template: "https://github.com/{user}/{repo}", args: []string{"conalli", "bookshelf"}, want: "https://github.com/conalli/bookshelf"},
+		{name: "named after numbered", template: "https://example.com/{1}?sort={sort:new}&q={q}", args: []string{"shop", "top", "red shoes"}, want: "https://example.com/shop?sort=top&q=red+shoes"},
+		{name: "named default", template: "https://example.com/{1}?sort={sort:new}", args: []string{"shop"}, want: "https://example.com/shop?sort=new"},
+		{name: "fragment path escaped", template: "https://example.com/?a=b#{1}", args: []string{"x y"}, want: "https://example.com/?a=b#x%20y"},
+		{name: "scheme added before expanding", template: "example.com/{1}", args: []string{"go"}, want: "http://example.com/go"},
+		{name: "scheme in arg escaped", template: "{1}", args: []string{"http://evil.com"}, want: "http://http%3A%2F%2Fevil.com"},
+		{name: "host in arg escaped", template: "{1}.example.com/", args: []string{"evil.com/"}, want: "http://evil.com%2F.example.com/"},
+		{name: "scheme in path arg escaped", template: "example.com/{1}", args: []string{"http://evil.com"}, want: "http://example.com/http%3A//evil.com"},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			tmpl, err := ParseCmdTemplate(c.template)
+			if err != nil {
+				t.Fatalf("could not parse template %s: %v", c.template, err)
+			}
+			if got := tmpl.Expand(c.args); got != c.want {
+				t.Errorf("wanted %s: got %s", c.want, got)
+			}
+		})
+	}
+}
+
+func TestParseCmdTemplateInvalid(t *testing.T) {
+	t.Parallel()
+	for _, template := range []string{
+		"https://github.com/{1",
+		"https://github.com/1}",
+		"https://github.com/{}",
+		"https://github.com/{0}",
+		"https://github.com/{user name}",
+		"https://github.com/{{1}}",
+		"http://[::1/{1}",
+	} {
+		if _, err := ParseCmdTemplate(template); !errors.Is(err, ErrInvalidCmdTemplate) {
+			t.Errorf("expected %s to be an invalid template: got %v", template, err)
+		}
+	}
+}
@@ -3,42 +3,86 @@ package bookmarks
 import (
 	"errors"
 	"io"
-	"mime/multipart"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html"
 )
 
 const (
 	BookmarksFileKey     string = "bookmarks_file"
-	BookmarksFileMaxSize int64  = 204800
+	BookmarksFileMaxSize int64  = 50 << 20
 	BookmarksBasePath    string = ""
 )
 
-// Bookmark represents a web bookmark.
+// Bookmark represents a web bookmark. A bookmark is stored inside the folder ParentID, or at the top
+// level if it is empty, and Ancestors lists every folder it is inside from the top level down. Path
+// is the names of those folders, kept for display and search.
 type Bookmark struct {
-	ID       string `json:"id" bson:"_id,omitempty"`
-	APIKey   string `json:"api_key" bson:"api_key"`
-	Path     string `json:"path" bson:"path"`
-	Name     string `json:"name" bson:"name"`
-	URL      string `json:"url" bson:"url"`
-	IsFolder bool   `json:"is_folder" bson:"is_folder"`
+	ID           string      `json:"id" bson:"_id,omitempty"`
+	APIKey       string      `json:"api_key" bson:"api_key"`
+	ParentID     string      `json:"parent_id" bson:"parent_id"`
+	Ancestors    []string    `json:"ancestors,omitempty" bson:"ancestors,omitempty"`
+	Path         string      `json:"path" bson:"path"`
+	Name         string      `json:"name" bson:"name"`
+	URL          string      `json:"url" bson:"url"`
+	CanonicalURL string      `json:"canonical_url,omitempty" bson:"canonical_url,omitempty"`
+	IsFolder     bool        `json:"is_folder" bson:"is_folder"`
+	Position     int         `json:"position" bson:"position"`
+	CreatedAt    time.Time   `json:"created_at" bson:"created_at"`
+	ModifiedAt   time.Time   `json:"modified_at" bson:"modified_at"`
+	Description  string      `json:"description,omitempty" bson:"description,omitempty"`
+	Image        string      `json:"image,omitempty" bson:"image,omitempty"`
+	Icon         string      `json:"icon,omitempty" bson:"icon,omitempty"`
+	IconURI      string      `json:"icon_uri,omitempty" bson:"icon_uri,omitempty"`
+	Tags         []string    `json:"tags,omitempty" bson:"tags,omitempty"`
+	LinkStatus   *LinkStatus `json:"link_status,omitempty" bson:"link_status,omitempty"`
+	DeletedAt    *time.Time  `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	TrashID      string      `json:"trash_id,omitempty" bson:"trash_id,omitempty"`
+	// FileIndex is the position of a bookmark parsed from a file within it, counting from 1, and
+	// FileParent is the FileIndex of the folder it is in, or 0 at the top level. They link imported
+	// bookmarks to their folders and are not stored.
+	FileIndex  int `json:"-" bson:"-"`
+	FileParent int `json:"-" bson:"-"`
 }
 
+// HTMLBookmarkParser parses Netscape bookmark files exported by most browsers.
 type HTMLBookmarkParser struct {
 	tokenizer *html.Tokenizer
 	APIKey    string
 	bookmarks []Bookmark
+	emit      func(Bookmark) error
+	parsed    int
 }
 
-func NewHTMLBookmarkParser(file multipart.File, APIKey string) *HTMLBookmarkParser {
+func NewHTMLBookmarkParser(file io.Reader, APIKey string) *HTMLBookmarkParser {
 	tokenizer := html.NewTokenizer(file)
-	return &HTMLBookmarkParser{
+	h := &HTMLBookmarkParser{
 		tokenizer: tokenizer,
 		APIKey:    APIKey,
 		bookmarks: []Bookmark{},
 	}
+	h.emit = h.appendBookmark
+	return h
+}
+
+// Parse parses the bookmarks file.
+func (h *HTMLBookmarkParser) Parse() ([]Bookmark, error) {
+	return h.parseBookmarkFileHTML()
+}
+
+func (h *HTMLBookmarkParser) appendBookmark(b Bookmark) error {
+	h.bookmarks = append(h.bookmarks, b)
+	return nil
+}
+
+// Stream parses the bookmarks file, passing each bookmark to emit as soon as it is parsed.
+func (h *HTMLBookmarkParser) Stream(emit func(Bookmark) error) error {
+	h.emit = emit
+	_, err := h.parseBookmarkFileHTML()
+	return err
 }
 
 func (h *HTMLBookmarkParser) parseBookmarkFileHTML() ([]Bookmark, error) {
@@ -60,7 +104,7 @@ func (h *HTMLBookmarkParser) parseBookmarkFileHTML() ([]Bookmark, error) {
 		if tokenType == html.StartTagToken {
 			token := h.tokenizer.Token()
 			if token.Data == "dt" {
-				err := h.parseFolder(BookmarksBasePath)
+				err := h.parseFolder(BookmarksBasePath, 0)
 				if err != nil {
 					return nil, errors.New("failed to parse bookmarks")
 				}
@@ -70,7 +114,10 @@ func (h *HTMLBookmarkParser) parseBookmarkFileHTML() ([]Bookmark, error) {
 	return h.bookmarks, nil
 }
 
-func (h *HTMLBookmarkParser) parseFolder(path string) error {
+// parseFolder parses the contents of the folder parent, recording the position of each bookmark
+// and subfolder within it.
+func (h *HTMLBookmarkParser) parseFolder(path string, parent int) error {
+	position := 0
 	for {
 		tokenType := h.tokenizer.Next()
 		token := h.tokenizer.Token()
@@ -89,13 +136,18 @@ func (h *HTMLBookmarkParser) parseFolder(path string) error {
 		if tokenType == html.StartTagToken {
 			switch data {
 			case "h3":
-				f, err := h.createFolder(path)
+				f, err := h.createFolder(path, attr)
 				if err != nil {
 					return err
 				}
-				h.bookmarks = append(h.bookmarks, f)
+				f.Position = position
+				position++
+				h.setFileIndex(&f, parent)
+				if err = h.emit(f); err != nil {
+					return err
+				}
 				newPath := updatePath(path, f.Name)
-				if err = h.parseFolder(newPath); err != nil {
+				if err = h.parseFolder(newPath, f.FileIndex); err != nil {
 					return err
 				}
 			case "a":
@@ -103,24 +155,37 @@ func (h *HTMLBookmarkParser) parseFolder(path string) error {
 				if len(URL) == 0 {
 					break
 				}
-				b, err := h.createBookmark(path, URL)
+				b, err := h.createBookmark(path, URL, attr)
 				if err != nil {
 					return err
 				}
-				h.bookmarks = append(h.bookmarks, b)
+				b.Position = position
+				position++
+				h.setFileIndex(&b, parent)
+				if err = h.emit(b); err != nil {
+					return err
+				}
 			}
 		}
 	}
 	return nil
 }
 
-func (h *HTMLBookmarkParser) createFolder(path string) (Bookmark, error) {
+// setFileIndex records where a bookmark inside the folder parent is in the file.
+func (h *HTMLBookmarkParser) setFileIndex(b *Bookmark, parent int) {
+	h.parsed++
+	b.FileIndex, b.FileParent = h.parsed, parent
+}
+
+func (h *HTMLBookmarkParser) createFolder(path string, attr []html.Attribute) (Bookmark, error) {
 	b := Bookmark{
-		APIKey:   h.APIKey,
-		Path:     path,
-		URL:      "",
-		Name:     "",
-		IsFolder: true,
+		APIKey:     h.APIKey,
+		Path:       path,
+		URL:        "",
+		Name:       "",
+		IsFolder:   true,
+		CreatedAt:  findTimestamp(attr, "add_date"),
+		ModifiedAt: findTimestamp(attr, "last_modified"),
 	}
 	tokenType := h.tokenizer.Next()
 	if tokenType != html.TextToken {
@@ -130,13 +195,18 @@ func (h *HTMLBookmarkParser) createFolder(path string) (Bookmark, error) {
 	return b, nil
 }
 
-func (h *HTMLBookmarkParser) createBookmark(path string, URL string) (Bookmark, error) {
+func (h *HTMLBookmarkParser) createBookmark(path string, URL string, attr []html.Attribute) (Bookmark, error) {
 	b := Bookmark{
-		APIKey:   h.APIKey,
-		Path:     path,
-		URL:      URL,
-		Name:     "",
-		IsFolder: false,
+		APIKey:     h.APIKey,
+		Path:       path,
+		URL:        URL,
+		Name:       "",
+		IsFolder:   false,
+		CreatedAt:  findTimestamp(attr, "add_date"),
+		ModifiedAt: findTimestamp(attr, "last_modified"),
+		Icon:       findAttr(attr, "icon"),
+		IconURI:    findAttr(attr, "icon_uri"),
+		Tags:       splitTags(findAttr(attr, "tags")),
 	}
 	tokenType := h.tokenizer.Next()
 	if tokenType != html.TextToken {
@@ -161,8 +231,7 @@ func updatePath(currentPath, pathName string) string {
 func findURL(attr []html.Attribute) string {
 	for _, a := range attr {
 		if a.Key == "href" {
-			href, err := url.Parse(a.Val)
-			if err != nil || href.Host == "" || href.Scheme == "" {
+			if !isBookmarkURL(a.Val) {
 				break
 			}
 			return a.Val
@@ -170,3 +239,54 @@ func findURL(attr []html.Attribute) string {
 	}
 	return ""
 }
+
+// isBookmarkURL reports whether a URL can be stored as a bookmark, filtering out
+// browser specific URLs such as Firefox place: queries.
+func isBookmarkURL(URL string) bool {
+	href, err := url.Parse(URL)
+	return err == nil && href.Host != "" && href.Scheme != ""
+}
+
+func findAttr(attr []html.Attribute, key string) string {
+	for _, a := range attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// findTimestamp parses a Unix timestamp attribute such as ADD_DATE. Most browsers
+// use seconds, but some exports use milli or microseconds.
+func findTimestamp(attr []html.Attribute, key string) time.Time {
+	ts, err := strconv.ParseInt(findAttr(attr, key), 10, 64)
+	if err != nil || ts <= 0 {
+		return time.Time{}
+	}
+	switch {
+	case ts > 1e14:
+		return time.UnixMicro(ts).UTC()
+	case ts > 1e11:
+		return time.UnixMilli(ts).UTC()
+	default:
+		return time.Unix(ts, 0).UTC()
+	}
+}
+
+// splitTags splits a comma separated list of tags, as used by Firefox.
+func splitTags(tags string) []string {
+	return NormalizeTags(strings.Split(tags, ","))
+}
+
+// setDefaultTimestamps sets any missing timestamps to t, so that bookmarks from
+// files without dates are recorded as created at the time of import.
+func setDefaultTimestamps(bookmarks []Bookmark, t time.Time) {
+	for i := range bookmarks {
+		if bookmarks[i].CreatedAt.IsZero() {
+			bookmarks[i].CreatedAt = t
+		}
+		if bookmarks[i].ModifiedAt.IsZero() {
+			bookmarks[i].ModifiedAt = bookmarks[i].CreatedAt
+		}
+	}
+}
@@ -2,7 +2,9 @@ package bookmarks
 
 import (
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
@@ -23,26 +25,26 @@ func TestParseBookmarksHTMLSingleFolder(t *testing.T) {
 	want := []Bookmark{
 		{APIKey: APIKey, Name: "Favourites", IsFolder: true},
 		{APIKey: APIKey, Name: "Apple", Path: ",Favourites,", URL: "https://www.apple.com/jp/"},
-		{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/"},
-		{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm"},
-		{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/"},
-		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/"},
-		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/"},
-		{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/"},
-		{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/"},
-		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/"},
-		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page"},
-		{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/"},
-		{APIKey: APIKey, Name: "Bookmarks Menu", IsFolder: true},
-		{APIKey: APIKey, Name: "Tab Group Favourites", IsFolder: true},
-		{APIKey: APIKey, Name: "Reading List", IsFolder: true},
+		{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/", Position: 1},
+		{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm", Position: 2},
+		{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/", Position: 3},
+		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/", Position: 4},
+		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/", Position: 5},
+		{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/", Position: 6},
+		{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/", Position: 7},
+		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/", Position: 8},
+		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page", Position: 9},
+		{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/", Position: 10},
+		{APIKey: APIKey, Name: "Bookmarks Menu", IsFolder: true, Position: 1},
+		{APIKey: APIKey, Name: "Tab Group Favourites", IsFolder: true, Position: 2},
+		{APIKey: APIKey, Name: "Reading List", IsFolder: true, Position: 3},
 	}
 	if len(want) != len(got) {
 		t.Fatalf("want and got not same length, want: %d, got: %d\n", len(want), len(got))
 	}
 	for i := range want {
-		if !cmp.Equal(want[i], got[i]) {
-			t.Error(cmp.Diff(want, got))
+		if !cmp.Equal(want[i], got[i], ignoreFileIndexes) {
+			t.Error(cmp.Diff(want, got, ignoreFileIndexes))
 		}
 	}
 }
@@ -62,43 +64,43 @@ func TestParseBookmarksHTMLMultipleFolders(t *testing.T) {
 	want := []Bookmark{
 		{APIKey: APIKey, Name: "Favourites", IsFolder: true},
 		{APIKey: APIKey, Name: "Apple", Path: ",Favourites,", URL: "https://www.apple.com/jp/"},
-		{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/"},
-		{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm"},
-		{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/"},
-		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/"},
-		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/"},
-		{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/"},
-		{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/"},
-		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/"},
-		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page"},
-		{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/"},
-		{APIKey: APIKey, Name: "News", Path: ",Favourites,", IsFolder: true},
+		{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/", Position: 1},
+		{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm", Position: 2},
+		{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/", Position: 3},
+		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/", Position: 4},
+		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/", Position: 5},
+		{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/", Position: 6},
+		{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/", Position: 7},
+		{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/", Position: 8},
+		{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page", Position: 9},
+		{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/", Position: 10},
+		{APIKey: APIKey, Name: "News", Path: ",Favourites,", IsFolder: true, Position: 11},
 		{APIKey: APIKey, Name: "AllThingsD", Path: ",Favourites,News,", URL: "http://allthingsd.com/"},
-		{APIKey: APIKey, Name: "BBC", Path: ",Favourites,News,", URL: "http://www.bbc.co.uk/"},
-		{APIKey: APIKey, Name: "CNN", Path: ",Favourites,News,", URL: "http://www.cnn.com/"},
-		{APIKey: APIKey, Name: "ESPN", Path: ",Favourites,News,", URL: "http://espn.go.com/"},
-		{APIKey: APIKey, Name: "NPR", Path: ",Favourites,News,", URL: "http://www.npr.org/"},
-		{APIKey: APIKey, Name: "USA Today", Path: ",Favourites,News,", URL: "http://www.usatoday.com/"},
-		{APIKey: APIKey, Name: "The Wall Street Journal", Path: ",Favourites,News,", URL: "http://online.wsj.com/home-page"},
-		{APIKey: APIKey, Name: "Popular", Path: ",Favourites,", IsFolder: true},
+		{APIKey: APIKey, Name: "BBC", Path: ",Favourites,News,", URL: "http://www.bbc.co.uk/", Position: 1},
+		{APIKey: APIKey, Name: "CNN", Path: ",Favourites,News,", URL: "http://www.cnn.com/", Position: 2},
+		{APIKey: APIKey, Name: "ESPN", Path: ",Favourites,News,", URL: "http://espn.go.com/", Position: 3},
+		{APIKey: APIKey, Name: "NPR", Path: ",Favourites,News,", URL: "http://www.npr.org/", Position: 4},
+		{APIKey: APIKey, Name: "USA Today", Path: ",Favourites,News,", URL: "http://www.usatoday.com/", Position: 5},
+		{APIKey: APIKey, Name: "The Wall Street Journal", Path: ",Favourites,News,", URL: "http://online.wsj.com/home-page", Position: 6},
+		{APIKey: APIKey, Name: "Popular", Path: ",Favourites,", IsFolder: true, Position: 12},
 		{APIKey: APIKey, Name: "Amazon", Path: ",Favourites,Popular,", URL: "http://www.amazon.com/"},
-		{APIKey: APIKey, Name: "Disney", Path: ",Favourites,Popular,", URL: "http://disney.go.com/"},
-		{APIKey: APIKey, Name: "eBay", Path: ",Favourites,Popular,", URL: "http://www.ebay.com/"},
-		{APIKey: APIKey, Name: "Flickr", Path: ",Favourites,Popular,", URL: "http://www.flickr.com/"},
-		{APIKey: APIKey, Name: "Rotten Tomatoes", Path: ",Favourites,Popular,", URL: "http://www.rottentomatoes.com/"},
-		{APIKey: APIKey, Name: "The Weather Channel", Path: ",Favourites,Popular,", URL: "http://www.weather.com/"},
-		{APIKey: APIKey, Name: "Yelp", Path: ",Favourites,Popular,", URL: "http://www.yelp.com/"},
-		{APIKey: APIKey, Name: "Amazon.co.uk: Low Prices in Electronics, Books, Sports Equipment & more", Path: ",Favourites,Popular,", URL: "http://www.amazon.co.uk/"},
-		{APIKey: APIKey, Name: "Bookmarks Menu", IsFolder: true},
-		{APIKey: APIKey, Name: "Tab Group Favourites", IsFolder: true},
-		{APIKey: APIKey, Name: "Reading List", IsFolder: true},
+		{APIKey: APIKey, Name: "Disney", Path: ",Favourites,Popular,", URL: "http://disney.go.com/", Position: 1},
+		{APIKey: APIKey, Name: "eBay", Path: ",Favourites,Popular,", URL: "http://www.ebay.com/", Position: 2},
+		{APIKey: APIKey, Name: "Flickr", Path: ",Favourites,Popular,", URL: "http://www.flickr.com/", Position: 3},
+		{APIKey: APIKey, Name: "Rotten Tomatoes", Path: ",Favourites,Popular,", URL: "http://www.rottentomatoes.com/", Position: 4},
+		{APIKey: APIKey, Name: "The Weather Channel", Path: ",Favourites,Popular,", URL: "http://www.weather.com/", Position: 5},
+		{APIKey: APIKey, Name: "Yelp", Path: ",Favourites,Popular,", URL: "http://www.yelp.com/", Position: 6},
+		{APIKey: APIKey, Name: "Amazon.co.uk: Low Prices in Electronics, Books, Sports Equipment & more", Path: ",Favourites,Popular,", URL: "http://www.amazon.co.uk/", Position: 7},
+		{APIKey: APIKey, Name: "Bookmarks Menu", IsFolder: true, Position: 1},
+		{APIKey: APIKey, Name: "Tab Group Favourites", IsFolder: true, Position: 2},
+		{APIKey: APIKey, Name: "Reading List", IsFolder: true, Position: 3},
 	}
 	if len(want) != len(got) {
 		t.Fatalf("want and got not same length, want: %d, got: %d\n", len(want), len(got))
 	}
 	for i := range want {
-		if !cmp.Equal(want[i], got[i]) {
-			t.Error(cmp.Diff(want, got))
+		if !cmp.Equal(want[i], got[i], ignoreFileIndexes) {
+			t.Error(cmp.Diff(want, got, ignoreFileIndexes))
 		}
 	}
 }
@@ -119,3 +121,51 @@ func TestNonURLHREFsInFile(t *testing.T) {
 		t.Fatalf("want and got not same length, want: %d, got: %d\n", 29, len(got))
 	}
 }
+
+func TestParseBookmarksHTMLMetadata(t *testing.T) {
+	t.Parallel()
+	file := strings.NewReader(`<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><H3 ADD_DATE="1381935901" LAST_MODIFIED="1635340469">Dev</H3>
+    <DL><p>
+        <DT><A HREF="https://go.dev/" ADD_DATE="1635340468" LAST_MODIFIED="1635340469" ICON_URI="https://go.dev/favicon.ico" ICON="data:image/png;base64,iVBORw0KGgo=" TAGS="go, programming">Go</A>
+        <DT><A HREF="https://pkg.go.dev/" ADD_DATE="1635340468000">Packages</A>
+    </DL><p>
+</DL><p>`)
+	APIKey := uuid.New().String()
+	got, err := NewHTMLBookmarkParser(file, APIKey).parseBookmarkFileHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Bookmark{
+		{
+			APIKey:     APIKey,
+			Name:       "Dev",
+			IsFolder:   true,
+			CreatedAt:  time.Unix(1381935901, 0).UTC(),
+			ModifiedAt: time.Unix(1635340469, 0).UTC(),
+		},
+		{
+			APIKey:     APIKey,
+			Name:       "Go",
+			Path:       ",Dev,",
+			URL:        "https://go.dev/",
+			CreatedAt:  time.Unix(1635340468, 0).UTC(),
+			ModifiedAt: time.Unix(1635340469, 0).UTC(),
+			Icon:       "data:image/png;base64,iVBORw0KGgo=",
+			IconURI:    "https://go.dev/favicon.ico",
+			Tags:       []string{"go", "programming"},
+		},
+		{
+			APIKey:    APIKey,
+			Name:      "Packages",
+			Path:      ",Dev,",
+			URL:       "https://pkg.go.dev/",
+			Position:  1,
+			CreatedAt: time.Unix(1635340468, 0).UTC(),
+		},
+	}
+	if !cmp.Equal(want, got, ignoreFileIndexes) {
+		t.Error(cmp.Diff(want, got, ignoreFileIndexes))
+	}
+}
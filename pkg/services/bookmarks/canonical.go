@@ -0,0 +1,82 @@
+package bookmarks
+
+import (
+	"net/url"
+	"strings"
+)
+
+// trackingParams are query params added by analytics and advertising tools that do not change
+// the page a URL points to.
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"gbraid":  true,
+	"wbraid":  true,
+	"msclkid": true,
+	"yclid":   true,
+	"igshid":  true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"mkt_tok": true,
+	"_ga":     true,
+	"_gl":     true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// CanonicalURL returns the canonical form of a bookmark URL, used to find bookmarks that point to
+// the same page. The scheme and host are lowercased and default ports, fragments, trailing slashes
+// and tracking params are removed. The order of the remaining query params is kept. Sites serve the
+// same page over http and https, so http URLs are given the https scheme.
+func CanonicalURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = u.Hostname()
+	}
+	if u.Scheme == "http" {
+		u.Scheme = "https"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = strings.TrimSuffix(u.RawPath, "/")
+	u.RawQuery = stripTrackingParams(u.RawQuery)
+	u.ForceQuery = false
+	return u.String()
+}
+
+func stripTrackingParams(rawQuery string) string {
+	if len(rawQuery) == 0 {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, p := range params {
+		key, _, _ := strings.Cut(p, "=")
+		if key, err := url.QueryUnescape(key); err == nil {
+			key = strings.ToLower(key)
+			if trackingParams[key] || strings.HasPrefix(key, "utm_") {
+				continue
+			}
+		}
+		if len(p) > 0 {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// setCanonicalURLs sets the canonical URL of each bookmark.
+func setCanonicalURLs(bookmarks []Bookmark) {
+	for i := range bookmarks {
+		if !bookmarks[i].IsFolder {
+			bookmarks[i].CanonicalURL = CanonicalURL(bookmarks[i].URL)
+		}
+	}
+}
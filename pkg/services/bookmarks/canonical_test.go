@@ -0,0 +1,125 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		URL  string
+		want string
+	}{
+		{URL: "https://www.example.com/", want: "https://www.example.com"},
+		{URL: "HTTPS://WWW.Example.com:443/docs/#intro", want: "https://www.example.com/docs"},
+		{URL: "http://example.com:80/a/b/", want: "https://example.com/a/b"},
+		{URL: "http://example.com:8080/?q=Go", want: "https://example.com:8080?q=Go"},
+		{URL: "http://example.com", want: "https://example.com"},
+		{URL: "https://example.com/?utm_source=x", want: "https://example.com"},
+		{URL: "https://example.com/post?id=1&UTM_Medium=email&fbclid=abc&page=2", want: "https://example.com/post?id=1&page=2"},
+		{URL: "https://example.com/search?q=a%20b&gclid=1", want: "https://example.com/search?q=a%20b"},
+		{URL: " https://example.com/? ", want: "https://example.com"},
+	}
+	for _, c := range tc {
+		if got := CanonicalURL(c.URL); got != c.want {
+			t.Errorf("CanonicalURL(%s): wanted %s, got %s", c.URL, c.want, got)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	t.Parallel()
+	older := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	books := []Bookmark{
+		{ID: "1", Name: "Example", IsFolder: true},
+		{ID: "2", URL: "https://example.com/?utm_source=x", CanonicalURL: "https://example.com", CreatedAt: newer},
+		{ID: "3", URL: "HTTPS://example.com/", CreatedAt: older},
+		{ID: "4", URL: "https://example.org", CanonicalURL: "https://example.org"},
+		{ID: "5", URL: "https://a.example.com/#top"},
+		{ID: "6", URL: "https://a.example.com", CanonicalURL: "https://a.example.com"},
+		{ID: "7", URL: "http://example.net", CanonicalURL: "http://example.net"},
+		{ID: "8", URL: "https://example.net/"},
+	}
+	got := FindDuplicates(books)
+	want := []DuplicateGroup{
+		{CanonicalURL: "https://a.example.com", Bookmarks: []Bookmark{books[4], books[5]}},
+		{CanonicalURL: "https://example.com", Bookmarks: []Bookmark{books[2], books[1]}},
+		{CanonicalURL: "https://example.net", Bookmarks: []Bookmark{books[6], books[7]}},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestSelectDuplicates(t *testing.T) {
+	t.Parallel()
+	books := []Bookmark{
+		{ID: "1", URL: "https://example.com"},
+		{ID: "2", URL: "https://example.com/"},
+		{ID: "3", URL: "https://example.com/?utm_campaign=x"},
+		{ID: "4", URL: "https://example.org"},
+	}
+	tc := []struct {
+		name string
+		IDs  []string
+		want []string
+		err  error
+	}{
+		{name: "all duplicates", want: []string{"2", "3"}},
+		{name: "given duplicates", IDs: []string{"3", "3"}, want: []string{"3"}},
+		{name: "not a duplicate", IDs: []string{"4"}, err: ErrNotDuplicate},
+		{name: "missing bookmark", IDs: []string{"5"}, err: ErrNotDuplicate},
+		{name: "itself", IDs: []string{"1"}, err: ErrMergeIntoSelf},
+	}
+	for _, c := range tc {
+		got, err := selectDuplicates(books, books[0], c.IDs)
+		if err != c.err {
+			t.Errorf("%s: wanted error %v, got %v", c.name, c.err, err)
+			continue
+		}
+		var gotIDs []string
+		for _, b := range got {
+			gotIDs = append(gotIDs, b.ID)
+		}
+		if !cmp.Equal(c.want, gotIDs) {
+			t.Errorf("%s: %s", c.name, cmp.Diff(c.want, gotIDs))
+		}
+	}
+	if _, err := selectDuplicates(books, books[3], nil); err != ErrNoDuplicates {
+		t.Errorf("wanted error %v, got %v", ErrNoDuplicates, err)
+	}
+}
+
+func TestMergeDuplicates(t *testing.T) {
+	t.Parallel()
+	older := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	keep := Bookmark{ID: "1", Name: "Example", URL: "https://example.com/", Tags: []string{"a"}, CreatedAt: newer}
+	duplicates := []Bookmark{
+		{ID: "2", Name: "Other", URL: "https://example.com", Description: "An example", Tags: []string{"b", "a"}, CreatedAt: older},
+		{ID: "3", URL: "https://example.com/?utm_source=x", IconURI: "https://example.com/favicon.ico", Tags: []string{"c"}},
+	}
+	got := mergeDuplicates(keep, duplicates, now)
+	want := Bookmark{
+		ID:           "1",
+		Name:         "Example",
+		URL:          "https://example.com/",
+		CanonicalURL: "https://example.com",
+		Description:  "An example",
+		IconURI:      "https://example.com/favicon.ico",
+		Tags:         []string{"a", "b", "c"},
+		CreatedAt:    older,
+		ModifiedAt:   now,
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+	if len(keep.Tags) != 1 {
+		t.Errorf("wanted kept bookmark tags to be unchanged, got %v", keep.Tags)
+	}
+}
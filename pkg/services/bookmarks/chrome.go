@@ -0,0 +1,119 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// chromeEpochOffset is the number of microseconds between the Windows epoch
+// (1601-01-01) used by Chrome timestamps and the Unix epoch.
+const chromeEpochOffset int64 = 11644473600000000
+
+// chromeRootKeys are the keys of the top level folders in a Chrome Bookmarks file, in
+// the order they are shown in the browser.
+var chromeRootKeys = []string{"bookmark_bar", "other", "synced"}
+
+type chromeBookmarkFile struct {
+	Roots map[string]chromeBookmarkNode `json:"roots"`
+}
+
+type chromeBookmarkNode struct {
+	Name         string               `json:"name"`
+	Type         string               `json:"type"`
+	URL          string               `json:"url"`
+	DateAdded    string               `json:"date_added"`
+	DateModified string               `json:"date_modified"`
+	Children     []chromeBookmarkNode `json:"children"`
+}
+
+// ChromeBookmarkParser parses the JSON Bookmarks file found in a Chrome profile.
+type ChromeBookmarkParser struct {
+	file      io.Reader
+	APIKey    string
+	bookmarks []Bookmark
+}
+
+func NewChromeBookmarkParser(file io.Reader, APIKey string) *ChromeBookmarkParser {
+	return &ChromeBookmarkParser{
+		file:      file,
+		APIKey:    APIKey,
+		bookmarks: []Bookmark{},
+	}
+}
+
+// Parse parses the bookmarks file.
+func (c *ChromeBookmarkParser) Parse() ([]Bookmark, error) {
+	var data chromeBookmarkFile
+	if err := json.NewDecoder(c.file).Decode(&data); err != nil {
+		return nil, err
+	}
+	if data.Roots == nil {
+		return nil, errUnknownBookmarksFormat
+	}
+	position := 0
+	for _, key := range chromeRootKeys {
+		root, ok := data.Roots[key]
+		if !ok || len(root.Children) == 0 {
+			continue
+		}
+		if c.parseNode(BookmarksBasePath, 0, position, root) {
+			position++
+		}
+	}
+	return c.bookmarks, nil
+}
+
+// parseNode adds a node and its children to the parsed bookmarks at the given position in the
+// folder parent, reporting whether the node was added.
+func (c *ChromeBookmarkParser) parseNode(path string, parent, position int, node chromeBookmarkNode) bool {
+	switch node.Type {
+	case "folder":
+		c.bookmarks = append(c.bookmarks, Bookmark{
+			APIKey:     c.APIKey,
+			FileIndex:  len(c.bookmarks) + 1,
+			FileParent: parent,
+			Path:       path,
+			Name:       node.Name,
+			IsFolder:   true,
+			Position:   position,
+			CreatedAt:  chromeTimestamp(node.DateAdded),
+			ModifiedAt: chromeTimestamp(node.DateModified),
+		})
+		newPath := updatePath(path, node.Name)
+		folder := len(c.bookmarks)
+		childPosition := 0
+		for _, child := range node.Children {
+			if c.parseNode(newPath, folder, childPosition, child) {
+				childPosition++
+			}
+		}
+		return true
+	case "url":
+		if !isBookmarkURL(node.URL) {
+			return false
+		}
+		c.bookmarks = append(c.bookmarks, Bookmark{
+			APIKey:     c.APIKey,
+			FileIndex:  len(c.bookmarks) + 1,
+			FileParent: parent,
+			Path:       path,
+			Name:       node.Name,
+			URL:        node.URL,
+			Position:   position,
+			CreatedAt:  chromeTimestamp(node.DateAdded),
+			ModifiedAt: chromeTimestamp(node.DateModified),
+		})
+		return true
+	}
+	return false
+}
+
+func chromeTimestamp(ts string) time.Time {
+	micro, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || micro <= chromeEpochOffset {
+		return time.Time{}
+	}
+	return time.UnixMicro(micro - chromeEpochOffset).UTC()
+}
@@ -0,0 +1,113 @@
+package bookmarks
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNoDuplicates  = errors.New("bookmark has no duplicates to merge")
+	ErrNotDuplicate  = errors.New("bookmarks do not share a canonical url")
+	ErrMergeIntoSelf = errors.New("cannot merge a bookmark into itself")
+)
+
+// DuplicateGroup represents the bookmarks in an account that share a canonical URL, oldest first.
+type DuplicateGroup struct {
+	CanonicalURL string     `json:"canonical_url"`
+	Bookmarks    []Bookmark `json:"bookmarks"`
+}
+
+// MergeResult represents the bookmark that duplicates were merged into.
+type MergeResult struct {
+	Bookmark   Bookmark `json:"bookmark"`
+	NumDeleted int      `json:"num_deleted"`
+}
+
+// canonicalURLOf returns the canonical URL of a bookmark. It is worked out from the URL rather than
+// read from the stored canonical URL, which may have been saved in an older canonical form.
+func canonicalURLOf(b Bookmark) string {
+	return CanonicalURL(b.URL)
+}
+
+// FindDuplicates groups bookmarks that share a canonical URL, ordered by canonical URL. Folders and
+// bookmarks without a URL are ignored.
+func FindDuplicates(bookmarks []Bookmark) []DuplicateGroup {
+	byURL := make(map[string][]Bookmark)
+	for _, b := range bookmarks {
+		if b.IsFolder || len(b.URL) == 0 {
+			continue
+		}
+		c := canonicalURLOf(b)
+		byURL[c] = append(byURL[c], b)
+	}
+	groups := []DuplicateGroup{}
+	for c, books := range byURL {
+		if len(books) < 2 {
+			continue
+		}
+		sort.SliceStable(books, func(i, j int) bool {
+			if !books[i].CreatedAt.Equal(books[j].CreatedAt) {
+				return books[i].CreatedAt.Before(books[j].CreatedAt)
+			}
+			return books[i].ID < books[j].ID
+		})
+		groups = append(groups, DuplicateGroup{CanonicalURL: c, Bookmarks: books})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].CanonicalURL < groups[j].CanonicalURL
+	})
+	return groups
+}
+
+// selectDuplicates finds the bookmarks to merge into keep. When IDs is empty every other bookmark
+// sharing its canonical URL is selected, otherwise each of the given bookmarks must share it.
+func selectDuplicates(bookmarks []Bookmark, keep Bookmark, IDs []string) ([]Bookmark, error) {
+	c := canonicalURLOf(keep)
+	var duplicates []Bookmark
+	if len(IDs) == 0 {
+		for _, b := range bookmarks {
+			if b.ID != keep.ID && !b.IsFolder && canonicalURLOf(b) == c {
+				duplicates = append(duplicates, b)
+			}
+		}
+		if len(duplicates) == 0 {
+			return nil, ErrNoDuplicates
+		}
+		return duplicates, nil
+	}
+	for _, id := range IDs {
+		if id == keep.ID {
+			return nil, ErrMergeIntoSelf
+		}
+		idx := slices.IndexFunc(bookmarks, func(b Bookmark) bool { return b.ID == id })
+		if idx < 0 || bookmarks[idx].IsFolder || canonicalURLOf(bookmarks[idx]) != c {
+			return nil, ErrNotDuplicate
+		}
+		if !slices.ContainsFunc(duplicates, func(b Bookmark) bool { return b.ID == id }) {
+			duplicates = append(duplicates, bookmarks[idx])
+		}
+	}
+	return duplicates, nil
+}
+
+// mergeDuplicates combines duplicates into keep. Tags are combined, any of keep's missing
+// metadata is filled in from the duplicates and the earliest creation date is kept.
+func mergeDuplicates(keep Bookmark, duplicates []Bookmark, now time.Time) Bookmark {
+	merged := keep
+	merged.Tags = slices.Clone(keep.Tags)
+	merged.CanonicalURL = canonicalURLOf(keep)
+	for _, d := range duplicates {
+		ApplyPageMetadata(&merged, PageMetadata{Title: d.Name, Description: d.Description, Image: d.Image, Icon: d.IconURI})
+		if len(merged.Icon) == 0 {
+			merged.Icon = d.Icon
+		}
+		merged.Tags = NormalizeTags(append(merged.Tags, d.Tags...))
+		if !d.CreatedAt.IsZero() && d.CreatedAt.Before(merged.CreatedAt) {
+			merged.CreatedAt = d.CreatedAt
+		}
+	}
+	merged.ModifiedAt = now
+	return merged
+}
@@ -0,0 +1,242 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"golang.org/x/net/html"
+)
+
+const (
+	EnrichmentTimeout  time.Duration = 5 * time.Second
+	EnrichmentMaxBytes int64         = 512 * 1024
+
+	enrichmentMaxTitleLength       = 200
+	enrichmentMaxDescriptionLength = 500
+)
+
+var errNotHTML = errors.New("page is not html")
+
+// PageMetadata represents the metadata of a web page used to fill in missing bookmark fields.
+type PageMetadata struct {
+	Title       string
+	Description string
+	Image       string
+	Icon        string
+}
+
+// EnrichmentRepository stores page metadata on bookmarks.
+type EnrichmentRepository interface {
+	EnrichBookmark(ctx context.Context, bookmarkID, APIKey string, meta PageMetadata) (int, apierr.Error)
+}
+
+// Enricher fetches the pages that bookmarks point to in the background and fills in any missing
+// name, description, image and icon from the page metadata. Each fetch is limited to timeout and
+// at most maxBytes of the page are read.
+type Enricher struct {
+	log      logs.Logger
+	db       EnrichmentRepository
+	client   *http.Client
+	timeout  time.Duration
+	maxBytes int64
+}
+
+func NewEnricher(l logs.Logger, db EnrichmentRepository, client *http.Client, timeout time.Duration, maxBytes int64) *Enricher {
+	return &Enricher{log: l, db: db, client: client, timeout: timeout, maxBytes: maxBytes}
+}
+
+// NewDefaultEnricher returns an Enricher with the default budget, which only fetches pages on
+// public addresses.
+func NewDefaultEnricher(l logs.Logger, db EnrichmentRepository) *Enricher {
+	return NewEnricher(l, db, NewPublicClient(EnrichmentTimeout), EnrichmentTimeout, EnrichmentMaxBytes)
+}
+
+// Enqueue enriches a newly added bookmark in the background. Folders and bookmarks with URLs that
+// cannot be fetched are ignored.
+func (e *Enricher) Enqueue(b Bookmark) {
+	if b.IsFolder || len(b.ID) == 0 || !isCheckableURL(b.URL) {
+		return
+	}
+	go e.enrich(b)
+}
+
+func (e *Enricher) enrich(b Bookmark) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), e.timeout)
+	defer cancelFunc()
+	meta, err := e.Fetch(ctx, b.URL)
+	if err != nil {
+		e.log.Infof("could not fetch metadata for bookmark %s: %v", b.ID, err)
+		return
+	}
+	if _, err := e.db.EnrichBookmark(ctx, b.ID, b.APIKey, meta); err != nil {
+		e.log.Errorf("could not enrich bookmark %s: %v", b.ID, err)
+	}
+}
+
+// Fetch gets the metadata of the page at rawURL.
+func (e *Enricher) Fetch(ctx context.Context, rawURL string) (PageMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checkableURL(rawURL), nil)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	res, err := e.client.Do(req)
+	if err != nil {
+		return PageMetadata{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		return PageMetadata{}, errors.New(res.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return PageMetadata{}, errNotHTML
+	}
+	return parsePageMetadata(io.LimitReader(res.Body, e.maxBytes), res.Request.URL), nil
+}
+
+// parsePageMetadata reads metadata from the head of an html page, resolving any relative URLs
+// against base. If the page does not link a favicon the default /favicon.ico is used.
+func parsePageMetadata(r io.Reader, base *url.URL) PageMetadata {
+	var meta PageMetadata
+	var ogTitle, ogDescription string
+	tokenizer := html.NewTokenizer(r)
+	inTitle := false
+parse:
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			break parse
+		case html.TextToken:
+			if inTitle && len(meta.Title) == 0 {
+				meta.Title = strings.Join(strings.Fields(string(tokenizer.Text())), " ")
+			}
+		case html.EndTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = false
+			case "head":
+				break parse
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "title":
+				inTitle = tokenType == html.StartTagToken
+			case "body":
+				break parse
+			case "meta":
+				content := strings.TrimSpace(findAttr(token.Attr, "content"))
+				key := findAttr(token.Attr, "property")
+				if len(key) == 0 {
+					key = findAttr(token.Attr, "name")
+				}
+				switch strings.ToLower(key) {
+				case "description":
+					meta.Description = content
+				case "og:description":
+					ogDescription = content
+				case "og:title":
+					ogTitle = content
+				case "og:image", "og:image:url", "og:image:secure_url":
+					if len(meta.Image) == 0 {
+						meta.Image = resolveURL(base, content)
+					}
+				}
+			case "link":
+				if isIconRel(findAttr(token.Attr, "rel")) && len(meta.Icon) == 0 {
+					meta.Icon = resolveURL(base, findAttr(token.Attr, "href"))
+				}
+			}
+		}
+	}
+	if len(meta.Title) == 0 {
+		meta.Title = ogTitle
+	}
+	if len(meta.Description) == 0 {
+		meta.Description = ogDescription
+	}
+	if len(meta.Icon) == 0 && base != nil {
+		meta.Icon = resolveURL(base, "/favicon.ico")
+	}
+	meta.Title = truncate(meta.Title, enrichmentMaxTitleLength)
+	meta.Description = truncate(meta.Description, enrichmentMaxDescriptionLength)
+	return meta
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func isIconRel(rel string) bool {
+	for _, r := range strings.Fields(strings.ToLower(rel)) {
+		if r == "icon" {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveURL resolves ref against base, returning an empty string unless the result is an http or
+// https URL so that javascript: and data: links are never stored.
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if len(ref) == 0 {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if !isWebURL(u) {
+		return ""
+	}
+	return u.String()
+}
+
+// isWebURL reports whether u is an absolute http or https URL.
+func isWebURL(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// ApplyPageMetadata fills in any of the bookmarks empty fields from the page metadata, reporting
+// whether anything changed. Images and icons are only used if they are http or https URLs.
+func ApplyPageMetadata(b *Bookmark, meta PageMetadata) bool {
+	changed := false
+	fill := func(field *string, val string) {
+		if len(*field) == 0 && len(val) > 0 {
+			*field = val
+			changed = true
+		}
+	}
+	fill(&b.Name, meta.Title)
+	fill(&b.Description, meta.Description)
+	fill(&b.Image, webURL(meta.Image))
+	fill(&b.IconURI, webURL(meta.Icon))
+	return changed
+}
+
+// webURL returns rawURL if it is an http or https URL, or an empty string otherwise.
+func webURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || !isWebURL(u) {
+		return ""
+	}
+	return rawURL
+}
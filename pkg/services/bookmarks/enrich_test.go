@@ -0,0 +1,148 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParsePageMetadata(t *testing.T) {
+	t.Parallel()
+	base, _ := url.Parse("https://example.com/articles/go")
+	tc := []struct {
+		name string
+		page string
+		want PageMetadata
+	}{
+		{
+			name: "all metadata",
+			page: `<!DOCTYPE html><html><head>
+				<title>  Go &amp; You
+				</title>
+				<meta name="description" content="All about Go.">
+				<meta property="og:image" content="/img/go.png">
+				<link rel="shortcut icon" href="favicon.png">
+				</head><body><title>Ignored</title></body></html>`,
+			want: PageMetadata{
+				Title:       "Go & You",
+				Description: "All about Go.",
+				Image:       "https://example.com/img/go.png",
+				Icon:        "https://example.com/articles/favicon.png",
+			},
+		},
+		{
+			name: "open graph fallbacks and default favicon",
+			page: `<html><head>
+				<meta property="og:title" content="OG Title">
+				<meta property="og:description" content="OG description">
+				</head></html>`,
+			want: PageMetadata{
+				Title:       "OG Title",
+				Description: "OG description",
+				Icon:        "https://example.com/favicon.ico",
+			},
+		},
+		{
+			name: "stops at body",
+			page: `<html><head></head><body><meta name="description" content="Not metadata"></body></html>`,
+			want: PageMetadata{Icon: "https://example.com/favicon.ico"},
+		},
+		{
+			name: "ignores non web image and icon urls",
+			page: `<html><head>
+				<meta property="og:image" content="javascript:alert(1)">
+				<link rel="icon" href="data:image/png;base64,AAAA">
+				</head></html>`,
+			want: PageMetadata{Icon: "https://example.com/favicon.ico"},
+		},
+	}
+	for _, c := range tc {
+		got := parsePageMetadata(strings.NewReader(c.page), base)
+		if !cmp.Equal(c.want, got) {
+			t.Errorf("%s: %s", c.name, cmp.Diff(c.want, got))
+		}
+	}
+}
+
+func TestEnricherFetch(t *testing.T) {
+	t.Parallel()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Page</title></head></html>`))
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><!--" + strings.Repeat("x", 1024) + "--><title>Too far</title></head></html>"))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	e := NewEnricher(nil, nil, srv.Client(), 50*time.Millisecond, 512)
+	ctx := context.Background()
+
+	meta, err := e.Fetch(ctx, srv.URL+"/page")
+	if err != nil || meta.Title != "Page" || meta.Icon != srv.URL+"/favicon.ico" {
+		t.Errorf("wanted page metadata, got %+v (%v)", meta, err)
+	}
+	meta, err = e.Fetch(ctx, srv.URL+"/large")
+	if err != nil || len(meta.Title) > 0 {
+		t.Errorf("wanted metadata past the size budget to be ignored, got %+v (%v)", meta, err)
+	}
+	if _, err := e.Fetch(ctx, srv.URL+"/image"); err != errNotHTML {
+		t.Errorf("wanted %v, got %v", errNotHTML, err)
+	}
+	timeoutCtx, cancelFunc := context.WithTimeout(ctx, e.timeout)
+	defer cancelFunc()
+	if _, err := e.Fetch(timeoutCtx, srv.URL+"/slow"); err == nil {
+		t.Error("wanted fetch exceeding the time budget to fail")
+	}
+}
+
+func TestDefaultEnricherRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html><head><title>Internal</title></head></html>`))
+	}))
+	defer srv.Close()
+	e := NewDefaultEnricher(nil, nil)
+	for _, rawURL := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		meta, err := e.Fetch(context.Background(), rawURL)
+		if !errors.Is(err, ErrNonPublicAddress) || len(meta.Title) > 0 {
+			t.Errorf("Expected fetching %s to be refused: got %+v (%v)", rawURL, meta, err)
+		}
+	}
+}
+
+func TestApplyPageMetadata(t *testing.T) {
+	t.Parallel()
+	b := Bookmark{Name: "Mine", IconURI: "https://example.com/mine.ico"}
+	meta := PageMetadata{Title: "Page", Description: "About", Image: "https://example.com/og.png", Icon: "https://example.com/favicon.ico"}
+	if !ApplyPageMetadata(&b, meta) {
+		t.Error("wanted bookmark to change")
+	}
+	want := Bookmark{Name: "Mine", Description: "About", Image: "https://example.com/og.png", IconURI: "https://example.com/mine.ico"}
+	if !cmp.Equal(want, b) {
+		t.Error(cmp.Diff(want, b))
+	}
+	if ApplyPageMetadata(&b, meta) {
+		t.Error("wanted an enriched bookmark not to change")
+	}
+	b = Bookmark{}
+	if ApplyPageMetadata(&b, PageMetadata{Image: "javascript:alert(1)", Icon: "data:image/png;base64,AAAA"}) {
+		t.Errorf("wanted non web image and icon urls to be ignored, got %+v", b)
+	}
+}
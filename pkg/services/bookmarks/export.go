@@ -0,0 +1,83 @@
+package bookmarks
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	BookmarksExportFileName    string = "bookmarks.html"
+	BookmarksExportContentType string = "text/html; charset=UTF-8"
+)
+
+const netscapeBookmarkFileHeader = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<!-- This is an automatically generated file.
+     It will be read and overwritten.
+     DO NOT EDIT! -->
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Bookmarks</TITLE>
+<H1>Bookmarks</H1>
+`
+
+// HTMLBookmarkWriter writes a Folder tree as a Netscape bookmark file that can be
+// imported by Chrome, Firefox and Safari.
+type HTMLBookmarkWriter struct {
+	w *bufio.Writer
+}
+
+func NewHTMLBookmarkWriter(w io.Writer) *HTMLBookmarkWriter {
+	return &HTMLBookmarkWriter{w: bufio.NewWriter(w)}
+}
+
+func (h *HTMLBookmarkWriter) writeBookmarkFileHTML(root *Folder) error {
+	h.w.WriteString(netscapeBookmarkFileHeader)
+	h.writeFolderContents(root, 0)
+	return h.w.Flush()
+}
+
+func (h *HTMLBookmarkWriter) writeFolderContents(folder *Folder, depth int) {
+	indent := strings.Repeat("    ", depth)
+	h.w.WriteString(indent + "<DL><p>\n")
+	for _, b := range folder.Bookmarks {
+		name := b.Name
+		if len(name) == 0 {
+			name = b.URL
+		}
+		h.w.WriteString(indent + "    <DT><A HREF=\"" + html.EscapeString(b.URL) + "\"" + bookmarkAttrs(b) + ">" + html.EscapeString(name) + "</A>\n")
+	}
+	for i := range folder.Folders {
+		f := &folder.Folders[i]
+		h.w.WriteString(indent + "    <DT><H3>" + html.EscapeString(f.Name) + "</H3>\n")
+		h.writeFolderContents(f, depth+1)
+	}
+	h.w.WriteString(indent + "</DL><p>\n")
+}
+
+func bookmarkAttrs(b Bookmark) string {
+	var sb strings.Builder
+	writeTimestampAttr(&sb, "ADD_DATE", b.CreatedAt)
+	writeTimestampAttr(&sb, "LAST_MODIFIED", b.ModifiedAt)
+	writeAttr(&sb, "ICON_URI", b.IconURI)
+	writeAttr(&sb, "ICON", b.Icon)
+	writeAttr(&sb, "TAGS", strings.Join(b.Tags, ","))
+	return sb.String()
+}
+
+func writeTimestampAttr(sb *strings.Builder, key string, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	writeAttr(sb, key, strconv.FormatInt(t.Unix(), 10))
+}
+
+func writeAttr(sb *strings.Builder, key, val string) {
+	if len(val) == 0 {
+		return
+	}
+	sb.WriteString(" " + key + "=\"" + html.EscapeString(val) + "\"")
+}
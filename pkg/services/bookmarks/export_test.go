@@ -0,0 +1,83 @@
+package bookmarks
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/uuid"
+)
+
+func TestWriteBookmarksHTMLRoundTrip(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		name string
+		path string
+	}{
+		{name: "safari single folder", path: "../../../internal/testdata/bookmarks/safaribookmarks_basic.html"},
+		{name: "safari multiple folders", path: "../../../internal/testdata/bookmarks/safaribookmarks.html"},
+		{name: "firefox", path: "../../../internal/testdata/bookmarks/firefoxbookmarks.html"},
+	}
+	for _, c := range tc {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			file, err := os.Open(c.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			APIKey := uuid.New().String()
+			imported, err := NewHTMLBookmarkParser(file, APIKey).parseBookmarkFileHTML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			NewFolderIndex(nil, APIKey, time.Time{}).Link(imported)
+			want := organizeBookmarks(imported, "", BookmarksBasePath, BookmarksBasePath)
+			var buf bytes.Buffer
+			if err := NewHTMLBookmarkWriter(&buf).writeBookmarkFileHTML(want); err != nil {
+				t.Fatal(err)
+			}
+			reimported, err := NewHTMLBookmarkParser(&buf, APIKey).parseBookmarkFileHTML()
+			if err != nil {
+				t.Fatal(err)
+			}
+			NewFolderIndex(nil, APIKey, time.Time{}).Link(reimported)
+			got := organizeBookmarks(reimported, "", BookmarksBasePath, BookmarksBasePath)
+			if !cmp.Equal(want, got, ignoreFolderIDs) {
+				t.Error(cmp.Diff(want, got, ignoreFolderIDs))
+			}
+		})
+	}
+}
+
+func TestWriteBookmarksHTMLEscapesNames(t *testing.T) {
+	t.Parallel()
+	folder := &Folder{
+		Folders: []Folder{
+			{
+				Name: "Q&A <dev>",
+				Bookmarks: []Bookmark{
+					{Name: `"Quotes" & <tags>`, Path: ",Q&A <dev>,", URL: "https://example.com/?a=1&b=2"},
+				},
+			},
+		},
+	}
+	var buf bytes.Buffer
+	if err := NewHTMLBookmarkWriter(&buf).writeBookmarkFileHTML(folder); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewHTMLBookmarkParser(&buf, "").parseBookmarkFileHTML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Bookmark{
+		{Name: "Q&A <dev>", IsFolder: true, FileIndex: 1},
+		{Name: `"Quotes" & <tags>`, Path: ",Q&A <dev>,", URL: "https://example.com/?a=1&b=2", FileIndex: 2, FileParent: 1},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
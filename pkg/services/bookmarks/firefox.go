@@ -0,0 +1,128 @@
+package bookmarks
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	firefoxTypeBookmark = 1
+	firefoxTypeFolder   = 2
+)
+
+// firefoxRootNames maps the Firefox root folders to the names used in Firefox's
+// HTML export, as their JSON titles are internal identifiers.
+var firefoxRootNames = map[string]string{
+	"bookmarksMenuFolder":    "Bookmarks Menu",
+	"toolbarFolder":          "Bookmarks Toolbar",
+	"unfiledBookmarksFolder": "Other Bookmarks",
+	"mobileFolder":           "Mobile Bookmarks",
+}
+
+type firefoxBookmarkNode struct {
+	Title        string                `json:"title"`
+	TypeCode     int                   `json:"typeCode"`
+	Root         string                `json:"root"`
+	URI          string                `json:"uri"`
+	IconURI      string                `json:"iconuri"`
+	Tags         string                `json:"tags"`
+	DateAdded    int64                 `json:"dateAdded"`
+	LastModified int64                 `json:"lastModified"`
+	Children     []firefoxBookmarkNode `json:"children"`
+}
+
+// FirefoxBookmarkParser parses the JSON backups created by Firefox, e.g. bookmarks-YYYY-MM-DD.json.
+type FirefoxBookmarkParser struct {
+	file      io.Reader
+	APIKey    string
+	bookmarks []Bookmark
+}
+
+func NewFirefoxBookmarkParser(file io.Reader, APIKey string) *FirefoxBookmarkParser {
+	return &FirefoxBookmarkParser{
+		file:      file,
+		APIKey:    APIKey,
+		bookmarks: []Bookmark{},
+	}
+}
+
+// Parse parses the bookmarks file.
+func (f *FirefoxBookmarkParser) Parse() ([]Bookmark, error) {
+	var root firefoxBookmarkNode
+	if err := json.NewDecoder(f.file).Decode(&root); err != nil {
+		return nil, err
+	}
+	if root.TypeCode != firefoxTypeFolder {
+		return nil, errUnknownBookmarksFormat
+	}
+	position := 0
+	for _, child := range root.Children {
+		if child.TypeCode == firefoxTypeFolder && len(child.Root) > 0 && len(child.Children) == 0 {
+			continue
+		}
+		if f.parseNode(BookmarksBasePath, 0, position, child) {
+			position++
+		}
+	}
+	return f.bookmarks, nil
+}
+
+// parseNode adds a node and its children to the parsed bookmarks at the given position in the
+// folder parent, reporting whether the node was added.
+func (f *FirefoxBookmarkParser) parseNode(path string, parent, position int, node firefoxBookmarkNode) bool {
+	switch node.TypeCode {
+	case firefoxTypeFolder:
+		name := node.Title
+		if rootName, ok := firefoxRootNames[node.Root]; ok {
+			name = rootName
+		}
+		f.bookmarks = append(f.bookmarks, Bookmark{
+			APIKey:     f.APIKey,
+			FileIndex:  len(f.bookmarks) + 1,
+			FileParent: parent,
+			Path:       path,
+			Name:       name,
+			IsFolder:   true,
+			Position:   position,
+			CreatedAt:  firefoxTimestamp(node.DateAdded),
+			ModifiedAt: firefoxTimestamp(node.LastModified),
+		})
+		newPath := updatePath(path, name)
+		folder := len(f.bookmarks)
+		childPosition := 0
+		for _, child := range node.Children {
+			if f.parseNode(newPath, folder, childPosition, child) {
+				childPosition++
+			}
+		}
+		return true
+	case firefoxTypeBookmark:
+		if !isBookmarkURL(node.URI) {
+			return false
+		}
+		f.bookmarks = append(f.bookmarks, Bookmark{
+			APIKey:     f.APIKey,
+			FileIndex:  len(f.bookmarks) + 1,
+			FileParent: parent,
+			Path:       path,
+			Name:       node.Title,
+			URL:        node.URI,
+			Position:   position,
+			CreatedAt:  firefoxTimestamp(node.DateAdded),
+			ModifiedAt: firefoxTimestamp(node.LastModified),
+			IconURI:    node.IconURI,
+			Tags:       splitTags(node.Tags),
+		})
+		return true
+	}
+	return false
+}
+
+// firefoxTimestamp converts the microsecond timestamps used in Firefox backups.
+func firefoxTimestamp(ts int64) time.Time {
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(ts).UTC()
+}
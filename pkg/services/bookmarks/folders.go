@@ -1,29 +1,145 @@
 package bookmarks
 
+import (
+	"cmp"
+	"sort"
+	"strings"
+)
+
+const (
+	BookmarksFolderIDKey   string = "id"
+	BookmarksFolderPathKey string = "path"
+	BookmarksFolderNameKey string = "name"
+)
+
 type Folder struct {
-	ID        string     `json:"id,omitempty"`
-	Name      string     `json:"name"`
-	Path      string     `json:"path"`
-	Bookmarks []Bookmark `json:"bookmarks"`
-	Folders   []Folder   `json:"folders"`
+	ID         string      `json:"id,omitempty"`
+	Name       string      `json:"name"`
+	Path       string      `json:"path"`
+	Position   int         `json:"position"`
+	Breadcrumb []FolderRef `json:"breadcrumb,omitempty"`
+	Bookmarks  []Bookmark  `json:"bookmarks"`
+	Folders    []Folder    `json:"folders"`
+}
+
+// FolderRef represents one of the folders a folder is inside.
+type FolderRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// FolderQuery finds a single folder by its ID, by the full path of its contents e.g. ",News,World,",
+// or by its exact name ignoring case. Only one of them should be given. When more than one folder
+// matches, the one found is the first in CompareFolderMatches order.
+type FolderQuery struct {
+	ID   string `validate:"omitempty,len=24,hexadecimal"`
+	Path string `validate:"omitempty,startswith=0x2C,endswith=0x2C,min=3,max=500"`
+	Name string `validate:"omitempty,max=100"`
+}
+
+// keys returns the number of ways the query finds a folder.
+func (q FolderQuery) keys() int {
+	n := 0
+	for _, key := range []string{q.ID, q.Path, q.Name} {
+		if len(key) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// CompareFolderMatches compares folders matching a FolderQuery, so that the folder nested in the
+// fewest folders comes first, followed by folders ordered by path and then position.
+func CompareFolderMatches(a, b Bookmark) int {
+	if c := cmp.Compare(len(a.Ancestors), len(b.Ancestors)); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.Path, b.Path); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Position, b.Position)
 }
 
-func organizeBookmarks(bookmarks []Bookmark, folderID, folderName, folderPath, path string) *Folder {
-	length := len(bookmarks)
-	if length == 0 {
+// FolderLocation represents where a folder is stored, by the path it is in and its name.
+type FolderLocation struct {
+	Path string
+	Name string
+}
+
+// FolderLocations returns every location of a folder whose contents have the full path. Folder
+// names can contain commas, so e.g. ",News,World," is either the folder "World" in ",News," or the
+// folder "News,World" at the top level.
+func FolderLocations(path string) []FolderLocation {
+	trimmed := strings.TrimSuffix(strings.TrimPrefix(path, ","), ",")
+	locations := []FolderLocation{}
+	if len(trimmed) > 0 {
+		locations = append(locations, FolderLocation{Path: BookmarksBasePath, Name: trimmed})
+	}
+	for i, r := range trimmed {
+		if r == ',' && i > 0 && i < len(trimmed)-1 {
+			locations = append(locations, FolderLocation{Path: updatePath(BookmarksBasePath, trimmed[:i]), Name: trimmed[i+1:]})
+		}
+	}
+	return locations
+}
+
+// FolderContents is a folder found by a FolderQuery, along with every bookmark inside it and the
+// folders it is inside in any order.
+type FolderContents struct {
+	Folder    Bookmark
+	Ancestors []Bookmark
+	Contents  []Bookmark
+}
+
+// organizeBookmarks builds the tree of the folder folderID from a flat list of bookmarks, where an
+// empty folderID is the top level. Bookmarks are grouped by parent in a single pass so that each
+// bookmark is only visited once. The bookmarks and folders in each folder are ordered by position.
+func organizeBookmarks(bookmarks []Bookmark, folderID, folderName, folderPath string) *Folder {
+	if len(bookmarks) == 0 {
 		return &Folder{}
 	}
-	folder := &Folder{ID: folderID, Name: folderName, Path: folderPath}
+	byParent := make(map[string][]Bookmark)
 	for _, b := range bookmarks {
-		if b.Path != path {
-			continue
-		}
+		byParent[b.ParentID] = append(byParent[b.ParentID], b)
+	}
+	return buildFolder(byParent, folderID, folderName, folderPath)
+}
+
+func buildFolder(byParent map[string][]Bookmark, folderID, folderName, folderPath string) *Folder {
+	folder := &Folder{ID: folderID, Name: folderName, Path: folderPath}
+	children := byParent[folderID]
+	delete(byParent, folderID)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].Position < children[j].Position
+	})
+	for _, b := range children {
 		if b.IsFolder {
-			newPath := updatePath(path, b.Name)
-			folder.Folders = append(folder.Folders, *organizeBookmarks(bookmarks, b.ID, b.Name, path, newPath))
+			child := buildFolder(byParent, b.ID, b.Name, b.Path)
+			child.Position = b.Position
+			folder.Folders = append(folder.Folders, *child)
 		} else {
 			folder.Bookmarks = append(folder.Bookmarks, b)
 		}
 	}
 	return folder
 }
+
+// organizeFolder builds the tree of a folder found by a FolderQuery, along with the breadcrumb of the
+// folders it is inside ordered from the top level in.
+func organizeFolder(contents *FolderContents) *Folder {
+	f := contents.Folder
+	byParent := make(map[string][]Bookmark)
+	for _, b := range contents.Contents {
+		byParent[b.ParentID] = append(byParent[b.ParentID], b)
+	}
+	folder := buildFolder(byParent, f.ID, f.Name, f.Path)
+	folder.Position = f.Position
+	names := make(map[string]string, len(contents.Ancestors))
+	for _, a := range contents.Ancestors {
+		names[a.ID] = a.Name
+	}
+	for _, id := range f.Ancestors {
+		folder.Breadcrumb = append(folder.Breadcrumb, FolderRef{ID: id, Name: names[id]})
+	}
+	return folder
+}
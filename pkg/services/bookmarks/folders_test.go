@@ -2,9 +2,12 @@ package bookmarks
 
 import (
 	"os"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/uuid"
 )
 
@@ -20,62 +23,133 @@ func TestOrganizeBookmarks(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	got := organizeBookmarks(bookmarks, "id", BookmarksBasePath, BookmarksBasePath, BookmarksBasePath)
+	NewFolderIndex(nil, APIKey, time.Time{}).Link(bookmarks)
+	got := organizeBookmarks(bookmarks, "", BookmarksBasePath, BookmarksBasePath)
+	checkParents(t, *got, nil)
 	want := &Folder{
-		ID:   "id",
 		Name: BookmarksBasePath,
 		Folders: []Folder{
 			{
 				Name: "Favourites",
 				Bookmarks: []Bookmark{
 					{APIKey: APIKey, Name: "Apple", Path: ",Favourites,", URL: "https://www.apple.com/jp/"},
-					{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/"},
-					{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm"},
-					{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/"},
-					{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/"},
-					{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/"},
-					{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/"},
-					{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/"},
-					{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/"},
-					{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page"},
-					{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/"},
+					{APIKey: APIKey, Name: "iCloud", Path: ",Favourites,", URL: "https://www.icloud.com/", Position: 1},
+					{APIKey: APIKey, Name: "Google", Path: ",Favourites,", URL: "https://www.google.co.jp/?client=safari&channel=iphone_bm", Position: 2},
+					{APIKey: APIKey, Name: "Yahoo", Path: ",Favourites,", URL: "https://www.yahoo.co.jp/", Position: 3},
+					{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "https://ja.wikipedia.org/", Position: 4},
+					{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://facebook.com/", Position: 5},
+					{APIKey: APIKey, Name: "Twitter", Path: ",Favourites,", URL: "https://twitter.com/", Position: 6},
+					{APIKey: APIKey, Name: "Asahi Shimbun", Path: ",Favourites,", URL: "https://www.asahi.com/", Position: 7},
+					{APIKey: APIKey, Name: "Facebook", Path: ",Favourites,", URL: "https://www.facebook.com/", Position: 8},
+					{APIKey: APIKey, Name: "Wikipedia", Path: ",Favourites,", URL: "http://en.wikipedia.org/wiki/Main_Page", Position: 9},
+					{APIKey: APIKey, Name: "Yahoo!", Path: ",Favourites,", URL: "http://www.yahoo.com/", Position: 10},
 				},
 				Folders: []Folder{
 					{
-						Name: "News",
-						Path: ",Favourites,",
+						Name:     "News",
+						Path:     ",Favourites,",
+						Position: 11,
 						Bookmarks: []Bookmark{
 							{APIKey: APIKey, Name: "AllThingsD", Path: ",Favourites,News,", URL: "http://allthingsd.com/"},
-							{APIKey: APIKey, Name: "BBC", Path: ",Favourites,News,", URL: "http://www.bbc.co.uk/"},
-							{APIKey: APIKey, Name: "CNN", Path: ",Favourites,News,", URL: "http://www.cnn.com/"},
-							{APIKey: APIKey, Name: "ESPN", Path: ",Favourites,News,", URL: "http://espn.go.com/"},
-							{APIKey: APIKey, Name: "NPR", Path: ",Favourites,News,", URL: "http://www.npr.org/"},
-							{APIKey: APIKey, Name: "USA Today", Path: ",Favourites,News,", URL: "http://www.usatoday.com/"},
-							{APIKey: APIKey, Name: "The Wall Street Journal", Path: ",Favourites,News,", URL: "http://online.wsj.com/home-page"},
+							{APIKey: APIKey, Name: "BBC", Path: ",Favourites,News,", URL: "http://www.bbc.co.uk/", Position: 1},
+							{APIKey: APIKey, Name: "CNN", Path: ",Favourites,News,", URL: "http://www.cnn.com/", Position: 2},
+							{APIKey: APIKey, Name: "ESPN", Path: ",Favourites,News,", URL: "http://espn.go.com/", Position: 3},
+							{APIKey: APIKey, Name: "NPR", Path: ",Favourites,News,", URL: "http://www.npr.org/", Position: 4},
+							{APIKey: APIKey, Name: "USA Today", Path: ",Favourites,News,", URL: "http://www.usatoday.com/", Position: 5},
+							{APIKey: APIKey, Name: "The Wall Street Journal", Path: ",Favourites,News,", URL: "http://online.wsj.com/home-page", Position: 6},
 						},
 					},
 					{
-						Name: "Popular",
-						Path: ",Favourites,",
+						Name:     "Popular",
+						Path:     ",Favourites,",
+						Position: 12,
 						Bookmarks: []Bookmark{
 							{APIKey: APIKey, Name: "Amazon", Path: ",Favourites,Popular,", URL: "http://www.amazon.com/"},
-							{APIKey: APIKey, Name: "Disney", Path: ",Favourites,Popular,", URL: "http://disney.go.com/"},
-							{APIKey: APIKey, Name: "eBay", Path: ",Favourites,Popular,", URL: "http://www.ebay.com/"},
-							{APIKey: APIKey, Name: "Flickr", Path: ",Favourites,Popular,", URL: "http://www.flickr.com/"},
-							{APIKey: APIKey, Name: "Rotten Tomatoes", Path: ",Favourites,Popular,", URL: "http://www.rottentomatoes.com/"},
-							{APIKey: APIKey, Name: "The Weather Channel", Path: ",Favourites,Popular,", URL: "http://www.weather.com/"},
-							{APIKey: APIKey, Name: "Yelp", Path: ",Favourites,Popular,", URL: "http://www.yelp.com/"},
-							{APIKey: APIKey, Name: "Amazon.co.uk: Low Prices in Electronics, Books, Sports Equipment & more", Path: ",Favourites,Popular,", URL: "http://www.amazon.co.uk/"},
+							{APIKey: APIKey, Name: "Disney", Path: ",Favourites,Popular,", URL: "http://disney.go.com/", Position: 1},
+							{APIKey: APIKey, Name: "eBay", Path: ",Favourites,Popular,", URL: "http://www.ebay.com/", Position: 2},
+							{APIKey: APIKey, Name: "Flickr", Path: ",Favourites,Popular,", URL: "http://www.flickr.com/", Position: 3},
+							{APIKey: APIKey, Name: "Rotten Tomatoes", Path: ",Favourites,Popular,", URL: "http://www.rottentomatoes.com/", Position: 4},
+							{APIKey: APIKey, Name: "The Weather Channel", Path: ",Favourites,Popular,", URL: "http://www.weather.com/", Position: 5},
+							{APIKey: APIKey, Name: "Yelp", Path: ",Favourites,Popular,", URL: "http://www.yelp.com/", Position: 6},
+							{APIKey: APIKey, Name: "Amazon.co.uk: Low Prices in Electronics, Books, Sports Equipment & more", Path: ",Favourites,Popular,", URL: "http://www.amazon.co.uk/", Position: 7},
 						},
 					},
 				},
 			},
-			{Name: "Bookmarks Menu"},
-			{Name: "Tab Group Favourites"},
-			{Name: "Reading List"},
+			{Name: "Bookmarks Menu", Position: 1},
+			{Name: "Tab Group Favourites", Position: 2},
+			{Name: "Reading List", Position: 3},
 		},
 	}
-	if !cmp.Equal(want, got) {
+	if !cmp.Equal(want, got, ignoreFolderIDs) {
+		t.Error(cmp.Diff(want, got, ignoreFolderIDs))
+	}
+}
+
+// ignoreFolderIDs ignores the IDs generated when linking bookmarks to their folders.
+var ignoreFolderIDs = cmp.Options{
+	cmpopts.IgnoreFields(Folder{}, "ID"),
+	cmpopts.IgnoreFields(Bookmark{}, "ID", "ParentID", "Ancestors"),
+	ignoreFileIndexes,
+}
+
+// checkParents checks that everything in a folder tree is linked to the folder it is in.
+func checkParents(t *testing.T, folder Folder, ancestors []string) {
+	t.Helper()
+	for _, b := range folder.Bookmarks {
+		if b.ParentID != folder.ID || !slices.Equal(b.Ancestors, ancestors) {
+			t.Errorf("wanted bookmark %s to be in folder %s with ancestors %v, got %s with %v", b.Name, folder.ID, ancestors, b.ParentID, b.Ancestors)
+		}
+	}
+	for _, f := range folder.Folders {
+		checkParents(t, f, append(slices.Clone(ancestors), f.ID))
+	}
+}
+
+func TestFolderLocations(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		path string
+		want []FolderLocation
+	}{
+		{path: ",News,", want: []FolderLocation{{Path: BookmarksBasePath, Name: "News"}}},
+		{path: ",Dev,C++,", want: []FolderLocation{{Path: BookmarksBasePath, Name: "Dev,C++"}, {Path: ",Dev,", Name: "C++"}}},
+		{path: ",News,World,UK,", want: []FolderLocation{
+			{Path: BookmarksBasePath, Name: "News,World,UK"},
+			{Path: ",News,", Name: "World,UK"},
+			{Path: ",News,World,", Name: "UK"},
+		}},
+	}
+	for _, c := range tc {
+		got := FolderLocations(c.path)
+		if !cmp.Equal(c.want, got) {
+			t.Errorf("%s: %s", c.path, cmp.Diff(c.want, got))
+		}
+		for _, l := range got {
+			if path := (Bookmark{Path: l.Path, Name: l.Name}).ChildPath(); path != c.path {
+				t.Errorf("%s: wanted folder %+v to have contents path %s, got %s", c.path, l, c.path, path)
+			}
+		}
+	}
+}
+
+func TestOrganizeFolder(t *testing.T) {
+	t.Parallel()
+	contents := &FolderContents{
+		Folder: Bookmark{ID: "3", Name: "Go", ParentID: "2", Ancestors: []string{"1", "2"}, Path: ",Dev,Code,", IsFolder: true, Position: 4},
+		Ancestors: []Bookmark{
+			{ID: "2", Name: "Code", ParentID: "1", Ancestors: []string{"1"}, Path: ",Dev,", IsFolder: true},
+			{ID: "1", Name: "Dev", Path: BookmarksBasePath, IsFolder: true},
+		},
+	}
+	want := &Folder{
+		ID:         "3",
+		Name:       "Go",
+		Path:       ",Dev,Code,",
+		Position:   4,
+		Breadcrumb: []FolderRef{{ID: "1", Name: "Dev"}, {ID: "2", Name: "Code"}},
+	}
+	if got := organizeFolder(contents); !cmp.Equal(want, got) {
 		t.Error(cmp.Diff(want, got))
 	}
 }
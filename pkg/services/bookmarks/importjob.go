@@ -0,0 +1,227 @@
+package bookmarks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	BookmarksImportJobTTL      time.Duration = 24 * time.Hour
+	BookmarksImportMaxDuration time.Duration = 30 * time.Minute
+	BookmarksImportBatchSize   int           = 500
+	bookmarksImportMaxErrors   int           = 100
+	bookmarksImportMaxURLLen   int           = 2048
+)
+
+const (
+	ImportStatusQueued  string = "queued"
+	ImportStatusRunning string = "running"
+	ImportStatusDone    string = "done"
+	ImportStatusFailed  string = "failed"
+)
+
+var (
+	ErrImportJobNotFound = errors.New("import job not found")
+	errImportURLTooLong  = errors.New("url too long")
+)
+
+// ImportJob represents the progress of importing a bookmarks file in the background. Parsed counts
+// the bookmarks read from the file so far, and the summary counts what happened to them. Error is set
+// if the job could not finish.
+type ImportJob struct {
+	ID     string `json:"id"`
+	APIKey string `json:"-"`
+	Status string `json:"status"`
+	ImportSummary
+	Parsed     int           `json:"parsed"`
+	Errors     []ImportError `json:"errors,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// ImportError represents an imported bookmark that could not be saved.
+type ImportError struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error"`
+}
+
+// addErrors records why books could not be saved, keeping at most bookmarksImportMaxErrors of them.
+func (j *ImportJob) addErrors(books []Bookmark, reason string) {
+	for _, b := range books {
+		if len(j.Errors) == bookmarksImportMaxErrors {
+			return
+		}
+		j.Errors = append(j.Errors, ImportError{Name: b.Name, Path: b.Path, URL: b.URL, Error: reason})
+	}
+}
+
+// checkImported reports why an imported bookmark cannot be saved, if it cannot be.
+func checkImported(b Bookmark) error {
+	if !b.IsFolder && len(b.URL) > bookmarksImportMaxURLLen {
+		return errImportURLTooLong
+	}
+	return nil
+}
+
+// copyUpload copies an uploaded file to a temporary file, returning it ready to be read.
+func copyUpload(header *multipart.FileHeader) (*os.File, error) {
+	upload, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer upload.Close()
+	file, err := os.CreateTemp("", "bookmarks-import-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(file, upload); err != nil {
+		removeUpload(file)
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		removeUpload(file)
+		return nil, err
+	}
+	return file, nil
+}
+
+func removeUpload(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// importer adds the bookmarks from a file to a users account one batch at a time, updating the
+// counts of its job as it goes. When replacing the users bookmarks, each batch is staged in the db
+// under the job ID instead and the staged bookmarks are swapped in when the whole file has been
+// read, so that the users bookmarks are left as they were if the file cannot be read.
+type importer struct {
+	db       Repository
+	job      *ImportJob
+	now      time.Time
+	existing []Bookmark
+	index    *FolderIndex
+	merger   *importMerger
+	staged   int
+}
+
+// newImporter returns an importer for job, getting the users existing bookmarks if the import
+// mode keeps them.
+func newImporter(ctx context.Context, db Repository, job *ImportJob, now time.Time) (*importer, error) {
+	i := &importer{db: db, job: job, now: now}
+	if job.Mode != BookmarksImportModeReplace {
+		existing, err := db.GetAllBookmarks(ctx, job.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		i.existing = existing
+	}
+	i.index = NewFolderIndex(i.existing, job.APIKey, now)
+	if job.Mode == BookmarksImportModeMerge {
+		i.merger = newImportMerger(i.existing)
+		i.merger.index = i.index
+	}
+	return i, nil
+}
+
+// importBatch saves a batch of imported bookmarks. Folders must come before their contents,
+// either earlier in the batch or in an earlier batch.
+func (i *importer) importBatch(ctx context.Context, batch []Bookmark) {
+	valid := make([]Bookmark, 0, len(batch))
+	for _, b := range batch {
+		if err := checkImported(b); err != nil {
+			i.job.Failed++
+			i.job.addErrors([]Bookmark{b}, err.Error())
+			continue
+		}
+		valid = append(valid, b)
+	}
+	setDefaultTimestamps(valid, i.now)
+	setCanonicalURLs(valid)
+	switch i.job.Mode {
+	case BookmarksImportModeAppend:
+		i.index.Link(valid)
+		appendPositions(i.existing, valid)
+		i.add(ctx, valid)
+	case BookmarksImportModeReplace:
+		i.index.Link(valid)
+		i.stage(ctx, valid)
+	case BookmarksImportModeMerge:
+		toAdd, toUpdate, skipped := i.merger.merge(valid)
+		n := len(i.index.Created())
+		i.index.Link(toAdd)
+		toAdd = append(slices.Clone(i.index.Created()[n:]), toAdd...)
+		appendPositions(i.existing, toAdd)
+		i.add(ctx, toAdd)
+		i.update(ctx, toUpdate)
+		i.job.Skipped += skipped
+	}
+}
+
+// finish replaces the users bookmarks with the staged bookmarks once the whole file has been read.
+func (i *importer) finish(ctx context.Context) error {
+	if i.job.Mode != BookmarksImportModeReplace {
+		return nil
+	}
+	numAdded, err := i.db.ReplaceWithStagedBookmarks(ctx, i.job.ID, i.job.APIKey)
+	if err != nil {
+		return err
+	}
+	i.job.Added += numAdded
+	i.job.Failed += i.staged - numAdded
+	return nil
+}
+
+// discard removes the staged bookmarks of an import that could not finish.
+func (i *importer) discard(ctx context.Context) error {
+	if i.job.Mode != BookmarksImportModeReplace {
+		return nil
+	}
+	if _, err := i.db.DeleteStagedBookmarks(ctx, i.job.ID); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (i *importer) add(ctx context.Context, books []Bookmark) {
+	if len(books) == 0 {
+		return
+	}
+	numAdded, err := i.db.AddManyBookmarks(ctx, books)
+	i.record(books, numAdded, err, &i.job.Added)
+}
+
+func (i *importer) update(ctx context.Context, books []Bookmark) {
+	if len(books) == 0 {
+		return
+	}
+	numUpdated, err := i.db.UpdateManyBookmarks(ctx, books)
+	i.record(books, numUpdated, err, &i.job.Updated)
+}
+
+func (i *importer) stage(ctx context.Context, books []Bookmark) {
+	if len(books) == 0 {
+		return
+	}
+	numStaged, err := i.db.StageBookmarks(ctx, i.job.ID, books)
+	i.record(books, numStaged, err, &i.staged)
+}
+
+// record adds the outcome of saving books to the job counts.
+func (i *importer) record(books []Bookmark, numSaved int, err error, count *int) {
+	if err != nil {
+		i.job.Failed += len(books)
+		i.job.addErrors(books, "could not save bookmark")
+		return
+	}
+	*count += numSaved
+	i.job.Failed += len(books) - numSaved
+}
@@ -0,0 +1,198 @@
+package bookmarks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LinkCheckDefaultConcurrency  int           = 8
+	LinkCheckDefaultHostInterval time.Duration = time.Second
+	LinkCheckDefaultTimeout      time.Duration = 10 * time.Second
+	LinkCheckMaxDuration         time.Duration = 30 * time.Minute
+	linkCheckBatchSize           int           = 100
+	linkCheckMaxBodyRead         int64         = 4096
+	linkCheckMaxHosts            int           = 10000
+)
+
+// LinkStatus represents the outcome of the last time a bookmark URL was checked.
+type LinkStatus struct {
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	FinalURL   string    `json:"final_url,omitempty" bson:"final_url,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	CheckedAt  time.Time `json:"checked_at" bson:"checked_at"`
+}
+
+// IsBroken reports whether the URL could not be reached or responded with an error status.
+func (l LinkStatus) IsBroken() bool {
+	return len(l.Error) > 0 || l.StatusCode >= http.StatusBadRequest
+}
+
+// LinkResult represents the link status of a single bookmark.
+type LinkResult struct {
+	ID     string
+	Status LinkStatus
+}
+
+// LinkCheckSummary represents a link check that has been started.
+type LinkCheckSummary struct {
+	Queued int `json:"queued"`
+}
+
+// LinkChecker checks whether bookmark URLs still resolve. At most concurrency URLs are checked at
+// once, and requests to the same host are spaced at least hostInterval apart.
+type LinkChecker struct {
+	client       *http.Client
+	concurrency  int
+	hostInterval time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]time.Time
+}
+
+func NewLinkChecker(client *http.Client, concurrency int, hostInterval time.Duration) *LinkChecker {
+	return &LinkChecker{
+		client:       client,
+		concurrency:  max(concurrency, 1),
+		hostInterval: hostInterval,
+		hosts:        make(map[string]time.Time),
+	}
+}
+
+// NewDefaultLinkChecker returns a LinkChecker with the default limits, which only checks URLs on
+// public addresses.
+func NewDefaultLinkChecker() *LinkChecker {
+	client := NewPublicClient(LinkCheckDefaultTimeout)
+	return NewLinkChecker(client, LinkCheckDefaultConcurrency, LinkCheckDefaultHostInterval)
+}
+
+// Check checks each bookmark URL, sending the results on the returned channel, which is closed once
+// every bookmark has been checked or ctx is done.
+func (l *LinkChecker) Check(ctx context.Context, bookmarks []Bookmark) <-chan LinkResult {
+	jobs := make(chan Bookmark)
+	results := make(chan LinkResult)
+	var wg sync.WaitGroup
+	for i := 0; i < l.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				status := l.checkURL(ctx, b.URL)
+				select {
+				case results <- LinkResult{ID: b.ID, Status: status}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, b := range bookmarks {
+			select {
+			case jobs <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
+
+func (l *LinkChecker) checkURL(ctx context.Context, rawURL string) LinkStatus {
+	target := checkableURL(rawURL)
+	u, err := url.Parse(target)
+	if err != nil {
+		return LinkStatus{Error: err.Error(), CheckedAt: time.Now().UTC()}
+	}
+	if err := l.waitForHost(ctx, u.Host); err != nil {
+		return LinkStatus{Error: err.Error(), CheckedAt: time.Now().UTC()}
+	}
+	res, err := l.request(ctx, http.MethodHead, target)
+	if err == nil && res.StatusCode >= http.StatusBadRequest {
+		// Some servers do not support HEAD requests, so confirm errors with a GET.
+		if err := l.waitForHost(ctx, u.Host); err != nil {
+			return LinkStatus{Error: err.Error(), CheckedAt: time.Now().UTC()}
+		}
+		res, err = l.request(ctx, http.MethodGet, target)
+	}
+	status := LinkStatus{CheckedAt: time.Now().UTC()}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.StatusCode = res.StatusCode
+	if final := res.Request.URL.String(); final != target {
+		status.FinalURL = final
+	}
+	return status
+}
+
+func (l *LinkChecker) request(ctx context.Context, method, target string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, io.LimitReader(res.Body, linkCheckMaxBodyRead))
+	res.Body.Close()
+	return res, nil
+}
+
+// waitForHost blocks until a request can be made to host without exceeding the per host rate limit.
+func (l *LinkChecker) waitForHost(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	if len(l.hosts) > linkCheckMaxHosts {
+		for h, t := range l.hosts {
+			if t.Before(now) {
+				delete(l.hosts, h)
+			}
+		}
+	}
+	next := l.hosts[host]
+	if next.Before(now) {
+		next = now
+	}
+	l.hosts[host] = next.Add(l.hostInterval)
+	l.mu.Unlock()
+	wait := time.Until(next)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isCheckableURL reports whether a bookmark URL can be checked over HTTP.
+func isCheckableURL(rawURL string) bool {
+	u, err := url.Parse(checkableURL(rawURL))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0
+}
+
+// checkableURL adds a scheme to bookmark URLs saved without one.
+func checkableURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, ":") {
+		return "http://" + rawURL
+	}
+	return rawURL
+}
@@ -0,0 +1,148 @@
+package bookmarks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newLinkCheckServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/getonly", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestLinkCheckerCheck(t *testing.T) {
+	t.Parallel()
+	srv := newLinkCheckServer()
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	books := []Bookmark{
+		{ID: "ok", URL: srv.URL + "/ok"},
+		{ID: "missing", URL: srv.URL + "/missing"},
+		{ID: "moved", URL: srv.URL + "/moved"},
+		{ID: "getonly", URL: srv.URL + "/getonly"},
+		{ID: "unreachable", URL: closed.URL},
+	}
+	checker := NewLinkChecker(srv.Client(), 2, time.Millisecond)
+	got := map[string]LinkStatus{}
+	for r := range checker.Check(context.Background(), books) {
+		got[r.ID] = r.Status
+	}
+	if len(got) != len(books) {
+		t.Fatalf("wanted %d results, got %d", len(books), len(got))
+	}
+	tc := []struct {
+		id         string
+		statusCode int
+		finalURL   string
+		broken     bool
+	}{
+		{id: "ok", statusCode: http.StatusOK},
+		{id: "missing", statusCode: http.StatusNotFound, broken: true},
+		{id: "moved", statusCode: http.StatusOK, finalURL: srv.URL + "/ok"},
+		{id: "getonly", statusCode: http.StatusOK},
+		{id: "unreachable", broken: true},
+	}
+	for _, c := range tc {
+		status := got[c.id]
+		if status.StatusCode != c.statusCode {
+			t.Errorf("%s: wanted status code %d, got %d", c.id, c.statusCode, status.StatusCode)
+		}
+		if status.FinalURL != c.finalURL {
+			t.Errorf("%s: wanted final url %q, got %q", c.id, c.finalURL, status.FinalURL)
+		}
+		if status.IsBroken() != c.broken {
+			t.Errorf("%s: wanted broken %t, got %t (%s)", c.id, c.broken, status.IsBroken(), status.Error)
+		}
+		if status.CheckedAt.IsZero() {
+			t.Errorf("%s: wanted checked at to be set", c.id)
+		}
+	}
+}
+
+func TestLinkCheckerLimits(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	books := make([]Bookmark, 6)
+	for i := range books {
+		books[i] = Bookmark{URL: srv.URL}
+	}
+
+	checker := NewLinkChecker(srv.Client(), 2, 0)
+	for range checker.Check(context.Background(), books) {
+	}
+	if maxInFlight > 2 {
+		t.Errorf("wanted at most 2 concurrent requests, got %d", maxInFlight)
+	}
+
+	interval := 30 * time.Millisecond
+	checker = NewLinkChecker(srv.Client(), len(books), interval)
+	start := time.Now()
+	for range checker.Check(context.Background(), books) {
+	}
+	if elapsed, want := time.Since(start), time.Duration(len(books)-1)*interval; elapsed < want {
+		t.Errorf("wanted requests to one host to take at least %v, took %v", want, elapsed)
+	}
+}
+
+func TestIsCheckableURL(t *testing.T) {
+	t.Parallel()
+	tc := map[string]bool{
+		"https://go.dev/":    true,
+		"bbc.co.uk":          true,
+		"javascript:void(0)": false,
+		"place:sort=8":       false,
+		"ftp://example.com/": false,
+		"":                   false,
+	}
+	for u, want := range tc {
+		if got := isCheckableURL(u); got != want {
+			t.Errorf("%q: wanted %t, got %t", u, want, got)
+		}
+	}
+}
+
+func TestLinkCheckerRefusesNonPublicAddresses(t *testing.T) {
+	t.Parallel()
+	srv := newLinkCheckServer()
+	defer srv.Close()
+	books := []Bookmark{
+		{ID: "loopback", URL: srv.URL + "/ok"},
+		{ID: "metadata", URL: "http://169.254.169.254/latest/meta-data/"},
+	}
+	checker := NewDefaultLinkChecker()
+	for r := range checker.Check(context.Background(), books) {
+		if r.Status.StatusCode != 0 || !strings.Contains(r.Status.Error, ErrNonPublicAddress.Error()) {
+			t.Errorf("Expected %s to be refused: got %+v", r.ID, r.Status)
+		}
+	}
+}
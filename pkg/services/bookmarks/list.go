@@ -0,0 +1,105 @@
+package bookmarks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	BookmarksListSortKey      string = "sort"
+	BookmarksListLimitKey     string = "limit"
+	BookmarksListAfterKey     string = "after"
+	BookmarksListSortName     string = "name"
+	BookmarksListSortCreated  string = "created"
+	BookmarksListSortURL      string = "url"
+	BookmarksListDefaultLimit int    = 50
+)
+
+// BookmarksListTimeLayout formats times with a fixed width so that they sort correctly as strings.
+const BookmarksListTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+var errInvalidListCursor = errors.New("invalid list cursor")
+
+// ListQuery represents a request for a page of a users bookmarks in a given order. After, when
+// set, is the position of the last bookmark of the previous page.
+type ListQuery struct {
+	Sort  string `validate:"oneof=name created url"`
+	Limit int    `validate:"min=1,max=500"`
+	After *ListCursor
+}
+
+// ListCursor represents a position in a sorted list of bookmarks.
+type ListCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// BookmarkPage represents a page of bookmarks. Next is the cursor for the following page and is
+// empty on the last page.
+type BookmarkPage struct {
+	Bookmarks []Bookmark `json:"bookmarks"`
+	Next      string     `json:"next,omitempty"`
+}
+
+// ListSortValue returns the value a bookmark is sorted by, ties are broken by ID.
+func ListSortValue(b Bookmark, sort string) string {
+	switch sort {
+	case BookmarksListSortCreated:
+		return b.CreatedAt.UTC().Format(BookmarksListTimeLayout)
+	case BookmarksListSortURL:
+		return b.URL
+	default:
+		return b.Name
+	}
+}
+
+// CompareListBookmarks compares bookmarks in list order.
+func CompareListBookmarks(a, b Bookmark, sort string) int {
+	if c := strings.Compare(ListSortValue(a, sort), ListSortValue(b, sort)); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// IsAfter reports whether a bookmark comes after the cursor in list order.
+func (c ListCursor) IsAfter(b Bookmark) bool {
+	if cmp := strings.Compare(ListSortValue(b, c.Sort), c.Value); cmp != 0 {
+		return cmp > 0
+	}
+	return b.ID > c.ID
+}
+
+// Time returns the cursor value as a time when sorting by creation date.
+func (c ListCursor) Time() (time.Time, error) {
+	return time.Parse(BookmarksListTimeLayout, c.Value)
+}
+
+func newListCursor(b Bookmark, sort string) ListCursor {
+	return ListCursor{Sort: sort, Value: ListSortValue(b, sort), ID: b.ID}
+}
+
+func (c ListCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(s, sort string) (*ListCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidListCursor
+	}
+	var c ListCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != sort || len(c.ID) == 0 {
+		return nil, errInvalidListCursor
+	}
+	if sort == BookmarksListSortCreated {
+		if _, err := c.Time(); err != nil {
+			return nil, errInvalidListCursor
+		}
+	}
+	return &c, nil
+}
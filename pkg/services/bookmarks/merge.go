@@ -0,0 +1,176 @@
+package bookmarks
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	BookmarksImportModeKey     string = "mode"
+	BookmarksImportModeMerge   string = "merge"
+	BookmarksImportModeAppend  string = "append"
+	BookmarksImportModeReplace string = "replace"
+)
+
+// ImportSummary represents the outcome of importing a bookmarks file.
+type ImportSummary struct {
+	Mode    string `json:"mode"`
+	Added   int    `json:"added"`
+	Updated int    `json:"updated"`
+	Skipped int    `json:"skipped"`
+	Failed  int    `json:"failed"`
+}
+
+// mergeBookmarks compares imported bookmarks against those already stored, returning
+// the bookmarks that need to be added, the existing bookmarks that need to be updated
+// and the number of imported bookmarks that were already stored.
+func mergeBookmarks(existing, imported []Bookmark) (toAdd, toUpdate []Bookmark, skipped int) {
+	return newImportMerger(existing).merge(imported)
+}
+
+// importMerger merges imported bookmarks into those already stored one batch at a time,
+// remembering the bookmarks seen in earlier batches so that duplicates are only added once. If index
+// is set, the contents of imported folders that are merged are linked to the folder they are merged
+// into.
+type importMerger struct {
+	existing []Bookmark
+	stored   map[string]int
+	seen     map[string]bool
+	folders  map[string]Bookmark // the folder each imported folder key is merged into
+	updated  map[int]bool
+	index    *FolderIndex
+	byID     map[string]Bookmark
+	idKeys   map[string]string
+	fileKeys map[int]string
+}
+
+func newImportMerger(existing []Bookmark) *importMerger {
+	m := &importMerger{
+		existing: existing,
+		stored:   make(map[string]int, len(existing)),
+		seen:     make(map[string]bool),
+		folders:  make(map[string]Bookmark),
+		updated:  make(map[int]bool),
+		byID:     make(map[string]Bookmark),
+		idKeys:   make(map[string]string),
+		fileKeys: make(map[int]string),
+	}
+	for _, b := range existing {
+		if b.IsFolder {
+			m.byID[b.ID] = b
+		}
+	}
+	for i, b := range existing {
+		m.stored[m.bookmarkKey(b)] = i
+	}
+	return m
+}
+
+// merge compares a batch of imported bookmarks against those already stored and imported.
+func (m *importMerger) merge(imported []Bookmark) (toAdd, toUpdate []Bookmark, skipped int) {
+	for _, b := range imported {
+		key := m.bookmarkKey(b)
+		if b.IsFolder && b.FileIndex > 0 {
+			m.fileKeys[b.FileIndex] = m.folderKey(b)
+		}
+		if m.seen[key] {
+			m.mergeFolder(b, m.folders[key])
+			skipped++
+			continue
+		}
+		m.seen[key] = true
+		idx, ok := m.stored[key]
+		if !ok {
+			if b.IsFolder {
+				m.folders[key] = b
+			}
+			toAdd = append(toAdd, b)
+			continue
+		}
+		if b.IsFolder {
+			m.folders[key] = m.existing[idx]
+		}
+		m.mergeFolder(b, m.existing[idx])
+		merged, changed := mergeBookmark(m.existing[idx], b)
+		if !changed || m.updated[idx] {
+			skipped++
+			continue
+		}
+		m.updated[idx] = true
+		toUpdate = append(toUpdate, merged)
+	}
+	return toAdd, toUpdate, skipped
+}
+
+// mergeFolder links the contents of an imported folder to the folder it is merged into.
+func (m *importMerger) mergeFolder(imported, into Bookmark) {
+	if m.index != nil && imported.IsFolder && imported.FileIndex > 0 {
+		m.index.MergeFolder(imported.FileIndex, into)
+	}
+}
+
+// mergeBookmark copies the imported name and metadata onto an existing bookmark,
+// reporting whether anything changed.
+func mergeBookmark(existing, imported Bookmark) (Bookmark, bool) {
+	merged := existing
+	if !imported.IsFolder && len(imported.Name) > 0 {
+		merged.Name = imported.Name
+	}
+	if len(imported.Icon) > 0 {
+		merged.Icon = imported.Icon
+	}
+	if len(imported.IconURI) > 0 {
+		merged.IconURI = imported.IconURI
+	}
+	for _, t := range imported.Tags {
+		if !slices.Contains(merged.Tags, t) {
+			merged.Tags = append(merged.Tags, t)
+		}
+	}
+	changed := merged.Name != existing.Name || merged.Icon != existing.Icon ||
+		merged.IconURI != existing.IconURI || len(merged.Tags) != len(existing.Tags)
+	if changed && imported.ModifiedAt.After(merged.ModifiedAt) {
+		merged.ModifiedAt = imported.ModifiedAt
+	}
+	return merged, changed
+}
+
+// bookmarkKey identifies a bookmark for deduplication. Folders are identified by the folder
+// they are in and their name, bookmarks by the folder they are in and their canonical URL.
+func (m *importMerger) bookmarkKey(b Bookmark) string {
+	if b.IsFolder {
+		return "folder:" + m.folderKey(b)
+	}
+	return "bookmark:" + m.parentKey(b) + CanonicalURL(b.URL)
+}
+
+// folderKey returns the key of a folder, which is made from the names of the folders it is inside
+// with any commas escaped, so that a folder with a comma in its name is not mistaken for a folder
+// inside another.
+func (m *importMerger) folderKey(folder Bookmark) string {
+	return updatePath(m.parentKey(folder), strings.ReplaceAll(folder.Name, ",", `\,`))
+}
+
+// parentKey returns the key of the folder a bookmark is in, found from the folder it was in within
+// its file or the folder it is stored in. Bookmarks that are not linked to a folder fall back to
+// their path.
+func (m *importMerger) parentKey(b Bookmark) string {
+	if key, ok := m.fileKeys[b.FileParent]; ok && b.FileParent > 0 {
+		return key
+	}
+	if len(b.ParentID) == 0 {
+		return b.Path
+	}
+	if key, ok := m.idKeys[b.ParentID]; ok {
+		return key
+	}
+	parent, ok := m.byID[b.ParentID]
+	if !ok {
+		return b.Path
+	}
+	// The path stands in while the key is found, in case the stored folders are linked in a loop.
+	m.idKeys[b.ParentID] = b.Path
+	key := m.folderKey(parent)
+	m.idKeys[b.ParentID] = key
+	return key
+}
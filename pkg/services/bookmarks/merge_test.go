@@ -0,0 +1,97 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMergeBookmarks(t *testing.T) {
+	t.Parallel()
+	older := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC", Path: ",News,", URL: "https://www.bbc.co.uk/", ModifiedAt: older},
+		{ID: "3", Name: "CNN", Path: ",News,", URL: "http://www.cnn.com/", ModifiedAt: older},
+	}
+	imported := []Bookmark{
+		{Name: "News", IsFolder: true},
+		{Name: "BBC", Path: ",News,", URL: "https://WWW.BBC.CO.UK", ModifiedAt: newer},
+		{Name: "CNN News", Path: ",News,", URL: "http://www.cnn.com/", ModifiedAt: newer, Tags: []string{"news"}},
+		{Name: "CNN News", Path: ",News,", URL: "http://www.cnn.com", ModifiedAt: newer},
+		{Name: "CNN", Path: ",Popular,", URL: "http://www.cnn.com/", ModifiedAt: newer},
+	}
+	toAdd, toUpdate, skipped := mergeBookmarks(existing, imported)
+	wantAdd := []Bookmark{
+		{Name: "CNN", Path: ",Popular,", URL: "http://www.cnn.com/", ModifiedAt: newer},
+	}
+	wantUpdate := []Bookmark{
+		{ID: "3", Name: "CNN News", Path: ",News,", URL: "http://www.cnn.com/", ModifiedAt: newer, Tags: []string{"news"}},
+	}
+	if !cmp.Equal(wantAdd, toAdd) {
+		t.Error(cmp.Diff(wantAdd, toAdd))
+	}
+	if !cmp.Equal(wantUpdate, toUpdate) {
+		t.Error(cmp.Diff(wantUpdate, toUpdate))
+	}
+	if skipped != 3 {
+		t.Errorf("wanted 3 skipped bookmarks, got %d", skipped)
+	}
+}
+
+func TestImportMergerBatches(t *testing.T) {
+	t.Parallel()
+	existing := []Bookmark{
+		{ID: "1", Name: "BBC", URL: "https://www.bbc.co.uk/"},
+	}
+	merger := newImportMerger(existing)
+	toAdd, toUpdate, skipped := merger.merge([]Bookmark{
+		{Name: "BBC", URL: "https://www.bbc.co.uk/"},
+		{Name: "Go", URL: "https://go.dev/"},
+	})
+	if len(toAdd) != 1 || len(toUpdate) != 0 || skipped != 1 {
+		t.Errorf("wanted Go added and BBC skipped in first batch, got %+v, %+v, %d", toAdd, toUpdate, skipped)
+	}
+	toAdd, toUpdate, skipped = merger.merge([]Bookmark{
+		{Name: "Go", URL: "https://go.dev"},
+		{Name: "Rust", URL: "https://www.rust-lang.org/"},
+	})
+	if len(toAdd) != 1 || toAdd[0].Name != "Rust" || len(toUpdate) != 0 || skipped != 1 {
+		t.Errorf("wanted bookmark from earlier batch skipped, got %+v, %+v, %d", toAdd, toUpdate, skipped)
+	}
+}
+
+func TestImportMergerLinksMergedFolders(t *testing.T) {
+	t.Parallel()
+	existing := []Bookmark{
+		{ID: "1", Name: "Q", IsFolder: true},
+		{ID: "2", Name: "A", ParentID: "1", Ancestors: []string{"1"}, Path: ",Q,", IsFolder: true},
+	}
+	imported := []Bookmark{
+		{Name: "Q", IsFolder: true, FileIndex: 1},
+		{Name: "A", Path: ",Q,", IsFolder: true, FileIndex: 2, FileParent: 1},
+		{Name: "Go", Path: ",Q,A,", URL: "https://go.dev/", FileIndex: 3, FileParent: 2},
+		{Name: "Q,A", IsFolder: true, FileIndex: 4},
+		{Name: "Stack Overflow", Path: ",Q,A,", URL: "https://stackoverflow.com/", FileIndex: 5, FileParent: 4},
+		{Name: "Q", IsFolder: true, FileIndex: 6},
+		{Name: "Rust", Path: ",Q,", URL: "https://www.rust-lang.org/", FileIndex: 7, FileParent: 6},
+	}
+	index := NewFolderIndex(existing, "key", time.Time{})
+	merger := newImportMerger(existing)
+	merger.index = index
+	toAdd, _, skipped := merger.merge(imported)
+	index.Link(toAdd)
+	if len(toAdd) != 4 || skipped != 3 {
+		t.Fatalf("wanted the folders already stored and the repeated folder to be merged, got %+v, %d skipped", toAdd, skipped)
+	}
+	parents := map[string]string{}
+	for _, b := range toAdd {
+		parents[b.Name] = b.ParentID
+	}
+	want := map[string]string{"Go": "2", "Q,A": "", "Stack Overflow": toAdd[1].ID, "Rust": "1"}
+	if !cmp.Equal(want, parents) {
+		t.Error(cmp.Diff(want, parents))
+	}
+}
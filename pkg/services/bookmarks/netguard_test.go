@@ -0,0 +1,38 @@
+package bookmarks
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "100.64.0.1"},
+		{addr: "0.0.0.0"},
+		{addr: "255.255.255.255"},
+		{addr: "224.0.0.1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+		{addr: "fd00:ec2::254"},
+		{addr: "fe80::1"},
+		{addr: "64:ff9b::a9fe:a9fe"},
+		{addr: "2002:a9fe:a9fe::1"},
+	}
+	for _, c := range tc {
+		if got := isPublicAddr(netip.MustParseAddr(c.addr)); got != c.public {
+			t.Errorf("wanted %s public to be %t: got %t", c.addr, c.public, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package bookmarks
+
+import (
+	"errors"
+)
+
+var ErrReorderIDs = errors.New("ids must list each bookmark in the folder once")
+
+// CheckOrder reports whether IDs lists each of a folder's children exactly once.
+func CheckOrder(children []Bookmark, IDs []string) error {
+	if len(children) != len(IDs) {
+		return ErrReorderIDs
+	}
+	remaining := make(map[string]bool, len(children))
+	for _, b := range children {
+		remaining[b.ID] = true
+	}
+	for _, id := range IDs {
+		if !remaining[id] {
+			return ErrReorderIDs
+		}
+		delete(remaining, id)
+	}
+	return nil
+}
+
+// appendPositions moves bookmarks being added to folders that already have contents after the
+// existing bookmarks, keeping the order they were given in.
+func appendPositions(existing, toAdd []Bookmark) {
+	next := make(map[string]int)
+	for _, b := range existing {
+		if b.Position >= next[b.ParentID] {
+			next[b.ParentID] = b.Position + 1
+		}
+	}
+	for i := range toAdd {
+		toAdd[i].Position += next[toAdd[i].ParentID]
+	}
+}
+
+// NextPosition returns the position after the last of a folder's children.
+func NextPosition(children []Bookmark) int {
+	next := 0
+	for _, b := range children {
+		if b.Position >= next {
+			next = b.Position + 1
+		}
+	}
+	return next
+}
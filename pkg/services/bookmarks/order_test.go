@@ -0,0 +1,83 @@
+package bookmarks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckOrder(t *testing.T) {
+	t.Parallel()
+	children := []Bookmark{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	tc := []struct {
+		name string
+		IDs  []string
+		err  error
+	}{
+		{name: "reordered", IDs: []string{"3", "1", "2"}},
+		{name: "missing child", IDs: []string{"3", "1"}, err: ErrReorderIDs},
+		{name: "repeated child", IDs: []string{"3", "1", "1"}, err: ErrReorderIDs},
+		{name: "unknown child", IDs: []string{"3", "1", "4"}, err: ErrReorderIDs},
+	}
+	for _, c := range tc {
+		if err := CheckOrder(children, c.IDs); err != c.err {
+			t.Errorf("%s: wanted error %v, got %v", c.name, c.err, err)
+		}
+	}
+}
+
+func TestAppendPositions(t *testing.T) {
+	t.Parallel()
+	existing := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC", ParentID: "1"},
+		{ID: "3", Name: "CNN", ParentID: "1", Position: 1},
+	}
+	toAdd := []Bookmark{
+		{Name: "NPR", ParentID: "1"},
+		{Name: "ESPN", ParentID: "1", Position: 1},
+		{Name: "Go", ParentID: "4"},
+	}
+	appendPositions(existing, toAdd)
+	want := []Bookmark{
+		{Name: "NPR", ParentID: "1", Position: 2},
+		{Name: "ESPN", ParentID: "1", Position: 3},
+		{Name: "Go", ParentID: "4"},
+	}
+	if !cmp.Equal(want, toAdd) {
+		t.Error(cmp.Diff(want, toAdd))
+	}
+	if got := NextPosition(existing[1:]); got != 2 {
+		t.Errorf("wanted next position 2, got %d", got)
+	}
+	if got := NextPosition(nil); got != 0 {
+		t.Errorf("wanted next position of an empty folder to be 0, got %d", got)
+	}
+}
+
+func TestOrganizeBookmarksByPosition(t *testing.T) {
+	t.Parallel()
+	books := []Bookmark{
+		{ID: "1", Name: "CNN", ParentID: "2", Path: ",News,", Position: 2},
+		{ID: "2", Name: "News", IsFolder: true, Position: 1},
+		{ID: "3", Name: "BBC", ParentID: "2", Path: ",News,", Position: 0},
+		{ID: "4", Name: "World", ParentID: "2", Path: ",News,", IsFolder: true, Position: 1},
+		{ID: "5", Name: "Dev", IsFolder: true, Position: 0},
+	}
+	got := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath)
+	want := &Folder{
+		Folders: []Folder{
+			{ID: "5", Name: "Dev", Position: 0},
+			{
+				ID:        "2",
+				Name:      "News",
+				Position:  1,
+				Bookmarks: []Bookmark{books[2], books[0]},
+				Folders:   []Folder{{ID: "4", Name: "World", Path: ",News,", Position: 1}},
+			},
+		},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
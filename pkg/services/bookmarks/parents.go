@@ -0,0 +1,279 @@
+package bookmarks
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+)
+
+var ErrFolderNotFound = errors.New("folder not found")
+
+// NewBookmarkID returns a new bookmark ID in the same format as a Mongo ObjectID, so that new folders
+// can be referenced by the bookmarks inside them before they are stored.
+func NewBookmarkID() string {
+	var id [12]byte
+	binary.BigEndian.PutUint32(id[:4], uint32(time.Now().Unix()))
+	rand.Read(id[4:])
+	return hex.EncodeToString(id[:])
+}
+
+// ChildAncestors returns the ancestors of the bookmarks stored inside a folder.
+func (b Bookmark) ChildAncestors() []string {
+	return append(slices.Clone(b.Ancestors), b.ID)
+}
+
+// IsInside reports whether a bookmark is stored anywhere inside the folder folderID.
+func (b Bookmark) IsInside(folderID string) bool {
+	return slices.Contains(b.Ancestors, folderID)
+}
+
+// setParent moves a bookmark into parent, or to the top level if parent is nil.
+func setParent(b *Bookmark, parent *Bookmark) {
+	if parent == nil {
+		b.ParentID, b.Ancestors, b.Path = "", nil, BookmarksBasePath
+		return
+	}
+	b.ParentID, b.Ancestors, b.Path = parent.ID, parent.ChildAncestors(), parent.ChildPath()
+}
+
+// ReplaceAncestorsPrefix moves the ancestors of a bookmark from inside a folder with n ancestors
+// to inside a folder whose contents have the ancestors prefix.
+func ReplaceAncestorsPrefix(ancestors []string, n int, prefix []string) []string {
+	replaced := slices.Clone(prefix)
+	if n < len(ancestors) {
+		replaced = append(replaced, ancestors[n:]...)
+	}
+	if len(replaced) == 0 {
+		return nil
+	}
+	return replaced
+}
+
+// Destination is the folder a bookmark is added or moved to, along with any missing folders that
+// have to be created first. A nil Parent is the top level.
+type Destination struct {
+	Parent  *Bookmark
+	Created []Bookmark
+}
+
+// Move moves a bookmark into the destination folder.
+func (d *Destination) Move(b *Bookmark) {
+	setParent(b, d.Parent)
+}
+
+// FolderIndex finds a users folders by ID, or by the path of their contents so that bookmarks
+// addressed by path can be linked to the folder they are in. Folders missing from a path are
+// created, and when more than one folder has the same path the last one added is used. Bookmarks
+// parsed from a file are linked to the folder they were in within the file, so that folders with
+// the same path keep their own contents.
+type FolderIndex struct {
+	APIKey  string
+	now     time.Time
+	byID    map[string]Bookmark
+	byPath  map[string]Bookmark
+	byFile  map[int]Bookmark
+	merged  map[int]Bookmark
+	created []Bookmark
+}
+
+// NewFolderIndex returns an index of the folders in books. Any folders it creates are owned by
+// APIKey and created at now.
+func NewFolderIndex(books []Bookmark, APIKey string, now time.Time) *FolderIndex {
+	f := &FolderIndex{
+		APIKey: APIKey,
+		now:    now,
+		byID:   make(map[string]Bookmark),
+		byPath: make(map[string]Bookmark),
+		byFile: make(map[int]Bookmark),
+		merged: make(map[int]Bookmark),
+	}
+	for _, b := range books {
+		if b.IsFolder {
+			f.Add(b)
+		}
+	}
+	return f
+}
+
+// Add adds a folder to the index.
+func (f *FolderIndex) Add(folder Bookmark) {
+	f.byID[folder.ID] = folder
+	f.byPath[folder.ChildPath()] = folder
+	if folder.FileIndex > 0 {
+		f.byFile[folder.FileIndex] = folder
+	}
+}
+
+// MergeFolder links the contents of the folder at fileIndex in a file to the folder it was merged
+// into, which is either already stored or was imported earlier in the file.
+func (f *FolderIndex) MergeFolder(fileIndex int, into Bookmark) {
+	f.merged[fileIndex] = into
+}
+
+// Created returns the folders created while finding paths, ordered from the outermost folder in.
+func (f *FolderIndex) Created() []Bookmark {
+	return f.created
+}
+
+// Resolve finds the folder parentID or, if no ID is given, the folder with the contents path,
+// returning ErrFolderNotFound if there is no folder with the ID.
+func (f *FolderIndex) Resolve(parentID, path string) (*Destination, error) {
+	if len(parentID) > 0 {
+		parent, ok := f.byID[parentID]
+		if !ok {
+			return nil, ErrFolderNotFound
+		}
+		return &Destination{Parent: &parent}, nil
+	}
+	n := len(f.created)
+	parent := f.folder(path)
+	return &Destination{Parent: parent, Created: slices.Clone(f.created[n:])}, nil
+}
+
+// Link links each bookmark to the folder it was in within its file, or the folder that was merged
+// into, falling back to the folder its path points to for bookmarks not parsed from a file. New
+// folders are given an ID so that the bookmarks inside them can be linked in turn. Folders must come
+// before their contents.
+func (f *FolderIndex) Link(books []Bookmark) {
+	for i := range books {
+		setParent(&books[i], f.parentOf(books[i]))
+		if books[i].IsFolder {
+			if len(books[i].ID) == 0 {
+				books[i].ID = NewBookmarkID()
+			}
+			f.Add(books[i])
+		}
+	}
+}
+
+// parentOf finds the folder a bookmark is in.
+func (f *FolderIndex) parentOf(b Bookmark) *Bookmark {
+	if b.FileParent > 0 {
+		if into, ok := f.merged[b.FileParent]; ok {
+			if parent, ok := f.byID[into.ID]; ok {
+				return &parent
+			}
+			b.FileParent = into.FileIndex
+		}
+		if parent, ok := f.byFile[b.FileParent]; ok {
+			return &parent
+		}
+	}
+	return f.folder(b.Path)
+}
+
+// folder finds the folder with the contents path, creating any folders missing from the path. The
+// longest start of the path that is the contents path of a folder is found first, so that only the
+// names of missing folders are split on commas.
+func (f *FolderIndex) folder(path string) *Bookmark {
+	var parent *Bookmark
+	rest := strings.Trim(path, ",")
+	for i := len(rest); i > 0; i = strings.LastIndex(rest[:i], ",") {
+		if folder, ok := f.byPath[updatePath(BookmarksBasePath, rest[:i])]; ok {
+			parent, rest = &folder, rest[i:]
+			break
+		}
+	}
+	for _, name := range strings.Split(rest, ",") {
+		if len(name) == 0 {
+			continue
+		}
+		folder := Bookmark{
+			ID:         NewBookmarkID(),
+			APIKey:     f.APIKey,
+			Name:       name,
+			IsFolder:   true,
+			CreatedAt:  f.now,
+			ModifiedAt: f.now,
+		}
+		setParent(&folder, parent)
+		f.Add(folder)
+		f.created = append(f.created, folder)
+		parent = &folder
+	}
+	return parent
+}
+
+// AddDestination finds the folder a new bookmark is added to.
+func AddDestination(folders []Bookmark, requestData request.AddBookmark, APIKey string, now time.Time) (*Destination, error) {
+	return NewFolderIndex(folders, APIKey, now).Resolve(requestData.ParentID, requestData.Path)
+}
+
+// UpdateDestination finds the folder an update moves a bookmark to, returning nil if the update does
+// not move the bookmark.
+func UpdateDestination(folders []Bookmark, requestData request.UpdateBookmark, APIKey string, now time.Time) (*Destination, error) {
+	if requestData.ParentID == nil && requestData.Path == nil {
+		return nil, nil
+	}
+	var parentID, path string
+	if requestData.ParentID != nil {
+		parentID = *requestData.ParentID
+	} else {
+		path = *requestData.Path
+	}
+	return NewFolderIndex(folders, APIKey, now).Resolve(parentID, path)
+}
+
+// MigrateParents links bookmarks stored before folders were referenced by ID to the folder their
+// path points to, returning any folders that had to be created. The bookmarks are sorted so that
+// each comes after the folder it is in.
+func MigrateParents(books []Bookmark, APIKey string, now time.Time) []Bookmark {
+	sort.SliceStable(books, func(i, j int) bool {
+		return strings.Count(books[i].Path, ",") < strings.Count(books[j].Path, ",")
+	})
+	index := NewFolderIndex(nil, APIKey, now)
+	index.Link(books)
+	return index.Created()
+}
+
+// MigrateTrashParents links bookmarks deleted before folders were referenced by ID, returning any
+// folders that had to be created in the trash. A directly deleted bookmark is linked to the folder in
+// books its path points to if the folder still exists, and is otherwise restored by its path. The
+// bookmarks deleted along with a folder are linked to the folders they were deleted with.
+func MigrateTrashParents(trashed, books []Bookmark, APIKey string, now time.Time) []Bookmark {
+	live := NewFolderIndex(books, APIKey, now)
+	groups := make(map[string][]Bookmark)
+	for _, b := range trashed {
+		groups[b.TrashID] = append(groups[b.TrashID], b)
+	}
+	linked := make(map[string]Bookmark, len(trashed))
+	var created []Bookmark
+	for trashID, group := range groups {
+		root := slices.IndexFunc(group, Bookmark.IsTrashRoot)
+		if root < 0 {
+			continue
+		}
+		deleted := group[root]
+		deleted.ParentID, deleted.Ancestors = "", nil
+		if folder, ok := live.byPath[deleted.Path]; ok && deleted.Path != BookmarksBasePath {
+			setParent(&deleted, &folder)
+		}
+		linked[deleted.ID] = deleted
+		contents := slices.Delete(slices.Clone(group), root, root+1)
+		sort.SliceStable(contents, func(i, j int) bool {
+			return strings.Count(contents[i].Path, ",") < strings.Count(contents[j].Path, ",")
+		})
+		index := NewFolderIndex([]Bookmark{deleted}, APIKey, now)
+		index.Link(contents)
+		for _, b := range contents {
+			linked[b.ID] = b
+		}
+		for _, f := range index.Created() {
+			f.TrashID, f.DeletedAt = trashID, deleted.DeletedAt
+			created = append(created, f)
+		}
+	}
+	for i, b := range trashed {
+		if l, ok := linked[b.ID]; ok {
+			trashed[i] = l
+		}
+	}
+	return created
+}
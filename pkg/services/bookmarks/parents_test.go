@@ -0,0 +1,228 @@
+package bookmarks
+
+import (
+	"encoding/hex"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewBookmarkID(t *testing.T) {
+	t.Parallel()
+	id := NewBookmarkID()
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 24 {
+		t.Errorf("wanted 24 character hex id, got %s", id)
+	}
+	if id == NewBookmarkID() {
+		t.Errorf("wanted new ids to be unique, got %s twice", id)
+	}
+}
+
+func TestFolderIndexLink(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	books := []Bookmark{
+		{Name: "Dev", IsFolder: true},
+		{Name: "Go", Path: ",Dev,", URL: "https://go.dev/"},
+		{Name: "Dev", IsFolder: true},
+		{Name: "Rust", Path: ",Dev,", URL: "https://www.rust-lang.org/"},
+		{Name: "Q,A", IsFolder: true},
+		{Name: "Stack Overflow", Path: ",Q,A,", URL: "https://stackoverflow.com/"},
+		{Name: "BBC", Path: ",News,UK,", URL: "https://www.bbc.co.uk/"},
+	}
+	index := NewFolderIndex(nil, "key", now)
+	index.Link(books)
+	if books[1].ParentID != books[0].ID || books[3].ParentID != books[2].ID || books[0].ID == books[2].ID {
+		t.Errorf("wanted folders with the same name to keep their own bookmarks, got %+v", books[:4])
+	}
+	if books[5].ParentID != books[4].ID || books[5].Path != ",Q,A," {
+		t.Errorf("wanted bookmark to be linked to folder with a comma in its name, got %+v", books[5])
+	}
+	created := index.Created()
+	if len(created) != 2 {
+		t.Fatalf("wanted 2 folders to be created for the missing path, got %+v", created)
+	}
+	news, uk := created[0], created[1]
+	want := []Bookmark{
+		{ID: news.ID, APIKey: "key", Name: "News", IsFolder: true, CreatedAt: now, ModifiedAt: now},
+		{ID: uk.ID, APIKey: "key", Name: "UK", ParentID: news.ID, Ancestors: []string{news.ID}, Path: ",News,", IsFolder: true, CreatedAt: now, ModifiedAt: now},
+	}
+	if !cmp.Equal(want, created) {
+		t.Error(cmp.Diff(want, created))
+	}
+	if books[6].ParentID != uk.ID || !slices.Equal(books[6].Ancestors, []string{news.ID, uk.ID}) {
+		t.Errorf("wanted bookmark to be linked to the created folders, got %+v", books[6])
+	}
+}
+
+func TestFolderIndexLinkFileIndexes(t *testing.T) {
+	t.Parallel()
+	books := []Bookmark{
+		{Name: "Dev", IsFolder: true, FileIndex: 1},
+		{Name: "Dev", IsFolder: true, FileIndex: 2},
+		{Name: "Go", Path: ",Dev,", URL: "https://go.dev/", FileIndex: 3, FileParent: 1},
+		{Name: "Rust", Path: ",Dev,", URL: "https://www.rust-lang.org/", FileIndex: 4, FileParent: 2},
+		{Name: "Q,A", IsFolder: true, FileIndex: 5},
+		{Name: "Q", IsFolder: true, FileIndex: 6},
+		{Name: "A", Path: ",Q,", IsFolder: true, FileIndex: 7, FileParent: 6},
+		{Name: "Stack Overflow", Path: ",Q,A,", URL: "https://stackoverflow.com/", FileIndex: 8, FileParent: 5},
+		{Name: "Go", Path: ",Dev,", IsFolder: true, FileIndex: 9, FileParent: 1},
+		{Name: "Go blog", Path: ",Dev,Go,", URL: "https://go.dev/blog/", FileIndex: 10, FileParent: 9},
+	}
+	index := NewFolderIndex(nil, "key", time.Time{})
+	index.Link(books)
+	if books[0].ID == books[1].ID || books[2].ParentID != books[0].ID || books[3].ParentID != books[1].ID {
+		t.Errorf("wanted folders with the same name to keep their own bookmarks, got %+v", books[:4])
+	}
+	if books[7].ParentID != books[4].ID || books[6].ParentID != books[5].ID {
+		t.Errorf("wanted folder with a comma in its name not to be confused with nested folders, got %+v", books[4:8])
+	}
+	if !slices.Equal(books[9].Ancestors, []string{books[0].ID, books[8].ID}) {
+		t.Errorf("wanted bookmark to be linked through its file parents, got %+v", books[9])
+	}
+	if created := index.Created(); len(created) != 0 {
+		t.Errorf("wanted no folders to be created, got %+v", created)
+	}
+}
+
+func TestFolderIndexResolveCommaName(t *testing.T) {
+	t.Parallel()
+	books := []Bookmark{{ID: "1", Name: "Q,A", IsFolder: true}}
+	index := NewFolderIndex(books, "key", time.Time{})
+	dest, err := index.Resolve("", ",Q,A,")
+	if err != nil || dest.Parent == nil || dest.Parent.ID != "1" || len(dest.Created) != 0 {
+		t.Errorf("wanted folder with a comma in its name found by path, got %+v, %v", dest, err)
+	}
+	dest, err = index.Resolve("", ",Q,A,Sub,")
+	if err != nil || len(dest.Created) != 1 || dest.Parent.Name != "Sub" || dest.Parent.ParentID != "1" {
+		t.Errorf("wanted only missing folder created inside folder with a comma in its name, got %+v, %v", dest, err)
+	}
+}
+
+func TestFolderIndexResolve(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	books := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC", ParentID: "1", Ancestors: []string{"1"}, Path: ",News,", URL: "https://bbc.co.uk"},
+	}
+	index := NewFolderIndex(books, "key", now)
+	dest, err := index.Resolve("1", ",Ignored,")
+	if err != nil || dest.Parent == nil || dest.Parent.ID != "1" || len(dest.Created) != 0 {
+		t.Errorf("wanted folder found by id, got %+v, %v", dest, err)
+	}
+	if _, err := index.Resolve("2", ""); err != ErrFolderNotFound {
+		t.Errorf("wanted bookmark id not to be found as a folder, got %v", err)
+	}
+	dest, err = index.Resolve("", BookmarksBasePath)
+	if err != nil || dest.Parent != nil || len(dest.Created) != 0 {
+		t.Errorf("wanted top level for the base path, got %+v, %v", dest, err)
+	}
+	dest, err = index.Resolve("", "News")
+	if err != nil || dest.Parent == nil || dest.Parent.ID != "1" || len(dest.Created) != 0 {
+		t.Errorf("wanted folder found by path without surrounding commas, got %+v, %v", dest, err)
+	}
+	dest, err = index.Resolve("", ",News,World,")
+	if err != nil || len(dest.Created) != 1 || dest.Parent.ID != dest.Created[0].ID || dest.Parent.ParentID != "1" {
+		t.Fatalf("wanted missing folder created inside existing folder, got %+v, %v", dest, err)
+	}
+	var b Bookmark
+	dest.Move(&b)
+	if b.ParentID != dest.Parent.ID || b.Path != ",News,World," || !slices.Equal(b.Ancestors, []string{"1", dest.Parent.ID}) {
+		t.Errorf("wanted bookmark moved into created folder, got %+v", b)
+	}
+	again, _ := index.Resolve("", ",News,World,")
+	if len(again.Created) != 0 || again.Parent.ID != dest.Parent.ID {
+		t.Errorf("wanted created folder to be reused, got %+v", again)
+	}
+}
+
+func TestMigrateParents(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	books := []Bookmark{
+		{ID: "3", Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+		{ID: "2", Name: "Go", Path: ",Dev,", IsFolder: true},
+		{ID: "1", Name: "Dev", IsFolder: true},
+		{ID: "4", Name: "BBC", Path: ",News,", URL: "https://bbc.co.uk"},
+		{ID: "5", Name: "Q,A", IsFolder: true},
+		{ID: "6", Name: "Stack Overflow", Path: ",Q,A,", URL: "https://stackoverflow.com/"},
+	}
+	created := MigrateParents(books, "key", now)
+	if len(created) != 1 || created[0].Name != "News" || len(created[0].ParentID) > 0 {
+		t.Fatalf("wanted missing News folder to be created, got %+v", created)
+	}
+	parents := map[string][]string{}
+	for _, b := range books {
+		parents[b.ID] = append([]string{b.ParentID}, b.Ancestors...)
+	}
+	want := map[string][]string{
+		"1": {""},
+		"2": {"1", "1"},
+		"3": {"2", "1", "2"},
+		"4": {created[0].ID, created[0].ID},
+		"5": {""},
+		"6": {"5", "5"},
+	}
+	if !cmp.Equal(want, parents) {
+		t.Error(cmp.Diff(want, parents))
+	}
+}
+
+func TestMigrateTrashParents(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	deletedAt := now.Add(-time.Hour)
+	books := []Bookmark{{ID: "1", Name: "Dev", IsFolder: true}}
+	trashed := []Bookmark{
+		{ID: "a4", Name: "Post", Path: ",Dev,Go,Blog,", URL: "https://go.dev/blog/", TrashID: "a1", DeletedAt: &deletedAt},
+		{ID: "a2", Name: "Go docs", Path: ",Dev,Go,", URL: "https://go.dev/doc/", TrashID: "a1", DeletedAt: &deletedAt},
+		{ID: "a1", Name: "Go", Path: ",Dev,", IsFolder: true, TrashID: "a1", DeletedAt: &deletedAt},
+		{ID: "a3", Name: "Blog", Path: ",Dev,Go,", IsFolder: true, TrashID: "a1", DeletedAt: &deletedAt},
+		{ID: "b1", Name: "BBC", Path: ",News,", URL: "https://bbc.co.uk", TrashID: "b1", DeletedAt: &deletedAt},
+		{ID: "c1", Name: "Q,A", IsFolder: true, TrashID: "c1", DeletedAt: &deletedAt},
+		{ID: "c2", Name: "Stack Overflow", Path: ",Q,A,Sub,", URL: "https://stackoverflow.com/", TrashID: "c1", DeletedAt: &deletedAt},
+	}
+	created := MigrateTrashParents(trashed, books, "key", now)
+	if len(created) != 1 || created[0].Name != "Sub" || created[0].ParentID != "c1" || created[0].TrashID != "c1" || created[0].DeletedAt != &deletedAt {
+		t.Fatalf("wanted missing folder to be created in the trash inside the deleted folder, got %+v", created)
+	}
+	parents := map[string][]string{}
+	for _, b := range trashed {
+		parents[b.ID] = append([]string{b.ParentID, b.Path}, b.Ancestors...)
+	}
+	want := map[string][]string{
+		"a1": {"1", ",Dev,", "1"},
+		"a2": {"a1", ",Dev,Go,", "1", "a1"},
+		"a3": {"a1", ",Dev,Go,", "1", "a1"},
+		"a4": {"a3", ",Dev,Go,Blog,", "1", "a1", "a3"},
+		"b1": {"", ",News,"},
+		"c1": {"", ""},
+		"c2": {created[0].ID, ",Q,A,Sub,", "c1", created[0].ID},
+	}
+	if !cmp.Equal(want, parents) {
+		t.Error(cmp.Diff(want, parents))
+	}
+}
+
+func TestReplaceAncestorsPrefix(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		name      string
+		ancestors []string
+		n         int
+		prefix    []string
+		want      []string
+	}{
+		{name: "move folder", ancestors: []string{"1", "2", "3"}, n: 1, prefix: []string{"4"}, want: []string{"4", "2", "3"}},
+		{name: "move up into parent", ancestors: []string{"1", "2", "3"}, n: 2, prefix: []string{"1"}, want: []string{"1", "3"}},
+		{name: "move to top level", ancestors: []string{"1"}, n: 1, want: nil},
+	}
+	for _, c := range tc {
+		if got := ReplaceAncestorsPrefix(c.ancestors, c.n, c.prefix); !cmp.Equal(c.want, got) {
+			t.Errorf("%s: %s", c.name, cmp.Diff(c.want, got))
+		}
+	}
+}
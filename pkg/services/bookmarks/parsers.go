@@ -0,0 +1,103 @@
+package bookmarks
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	BookmarksFormatKey         string = "format"
+	BookmarksFormatHTML        string = "html"
+	BookmarksFormatChromeJSON  string = "chrome"
+	BookmarksFormatFirefoxJSON string = "firefox"
+	bookmarksSniffLen          int    = 1024
+)
+
+var errUnknownBookmarksFormat = errors.New("unknown bookmark file format")
+
+// BookmarkParser parses a bookmarks file into a flat list of Bookmarks, with each
+// Bookmark's Path holding the comma separated names of its parent folders and its
+// FileParent the FileIndex of the folder it is in.
+type BookmarkParser interface {
+	Parse() ([]Bookmark, error)
+}
+
+// BookmarkStreamer is implemented by BookmarkParsers that can pass on each Bookmark as soon as it
+// is parsed, so that large files are not held in memory all at once. Folders come before the
+// bookmarks inside them.
+type BookmarkStreamer interface {
+	Stream(emit func(Bookmark) error) error
+}
+
+// StreamBookmarks passes each Bookmark parsed by parser to emit, streaming them if the parser
+// supports it.
+func StreamBookmarks(parser BookmarkParser, emit func(Bookmark) error) error {
+	if streamer, ok := parser.(BookmarkStreamer); ok {
+		return streamer.Stream(emit)
+	}
+	books, err := parser.Parse()
+	if err != nil {
+		return err
+	}
+	for _, b := range books {
+		if err := emit(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BookmarkParserFunc creates a BookmarkParser for a given bookmarks file and user.
+type BookmarkParserFunc func(file io.Reader, APIKey string) BookmarkParser
+
+var bookmarkParsers = map[string]BookmarkParserFunc{
+	BookmarksFormatHTML: func(file io.Reader, APIKey string) BookmarkParser {
+		return NewHTMLBookmarkParser(file, APIKey)
+	},
+	BookmarksFormatChromeJSON: func(file io.Reader, APIKey string) BookmarkParser {
+		return NewChromeBookmarkParser(file, APIKey)
+	},
+	BookmarksFormatFirefoxJSON: func(file io.Reader, APIKey string) BookmarkParser {
+		return NewFirefoxBookmarkParser(file, APIKey)
+	},
+}
+
+// NewBookmarkParser returns the BookmarkParser for the given format. If format is empty
+// the format is detected from the start of the file.
+func NewBookmarkParser(file io.Reader, format, APIKey string) (BookmarkParser, error) {
+	br := bufio.NewReader(file)
+	if len(format) == 0 {
+		peek, err := br.Peek(bookmarksSniffLen)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		format = sniffBookmarksFormat(peek)
+	}
+	newParser, ok := bookmarkParsers[format]
+	if !ok {
+		return nil, errUnknownBookmarksFormat
+	}
+	return newParser(br, APIKey), nil
+}
+
+func sniffBookmarksFormat(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return ""
+	}
+	switch data[0] {
+	case '<':
+		return BookmarksFormatHTML
+	case '{':
+		if bytes.Contains(data, []byte(`"roots"`)) {
+			return BookmarksFormatChromeJSON
+		}
+		if bytes.Contains(data, []byte(`"typeCode"`)) || bytes.Contains(data, []byte(`"placesRoot"`)) {
+			return BookmarksFormatFirefoxJSON
+		}
+	}
+	return ""
+}
@@ -0,0 +1,150 @@
+package bookmarks
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/google/uuid"
+)
+
+// ignoreFileIndexes ignores where parsed bookmarks were in the file, which TestParseFileIndexes checks.
+var ignoreFileIndexes = cmpopts.IgnoreFields(Bookmark{}, "FileIndex", "FileParent")
+
+func TestSniffBookmarksFormat(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "netscape html", data: "<!DOCTYPE NETSCAPE-Bookmark-file-1>", want: BookmarksFormatHTML},
+		{name: "html with BOM and whitespace", data: "\xef\xbb\xbf\n  <!DOCTYPE NETSCAPE-Bookmark-file-1>", want: BookmarksFormatHTML},
+		{name: "chrome json", data: `{"checksum": "abc", "roots": {}}`, want: BookmarksFormatChromeJSON},
+		{name: "firefox json", data: `{"guid":"root________","typeCode":2,"root":"placesRoot"}`, want: BookmarksFormatFirefoxJSON},
+		{name: "unknown json", data: `{"bookmarks": []}`, want: ""},
+		{name: "empty", data: "", want: ""},
+	}
+	for _, c := range tc {
+		if got := sniffBookmarksFormat([]byte(c.data)); got != c.want {
+			t.Errorf("%s: wanted format %q, got %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestNewBookmarkParserUnknownFormat(t *testing.T) {
+	t.Parallel()
+	_, err := NewBookmarkParser(strings.NewReader(`{"bookmarks": []}`), "", uuid.New().String())
+	if err != errUnknownBookmarksFormat {
+		t.Errorf("wanted %v, got %v", errUnknownBookmarksFormat, err)
+	}
+	_, err = NewBookmarkParser(strings.NewReader("<!DOCTYPE NETSCAPE-Bookmark-file-1>"), "opera", uuid.New().String())
+	if err != errUnknownBookmarksFormat {
+		t.Errorf("wanted %v, got %v", errUnknownBookmarksFormat, err)
+	}
+}
+
+func TestParseBookmarksChromeJSON(t *testing.T) {
+	t.Parallel()
+	file, err := os.Open("../../../internal/testdata/bookmarks/chromebookmarks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	APIKey := uuid.New().String()
+	parser, err := NewBookmarkParser(file, "", APIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(2021, 11, 15, 7, 31, 3, 0, time.UTC)
+	want := []Bookmark{
+		{APIKey: APIKey, Name: "Bookmarks bar", IsFolder: true, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Apple", Path: ",Bookmarks bar,", URL: "https://www.apple.com/jp/", CreatedAt: added},
+		{APIKey: APIKey, Name: "iCloud", Path: ",Bookmarks bar,", URL: "https://www.icloud.com/", Position: 1, CreatedAt: added},
+		{APIKey: APIKey, Name: "Twitter", Path: ",Bookmarks bar,", URL: "https://twitter.com/", Position: 2, CreatedAt: added},
+		{APIKey: APIKey, Name: "News", Path: ",Bookmarks bar,", IsFolder: true, Position: 3, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "BBC", Path: ",Bookmarks bar,News,", URL: "http://www.bbc.co.uk/", CreatedAt: added},
+		{APIKey: APIKey, Name: "CNN", Path: ",Bookmarks bar,News,", URL: "http://www.cnn.com/", Position: 1, CreatedAt: added},
+		{APIKey: APIKey, Name: "Empty", Path: ",Bookmarks bar,", IsFolder: true, Position: 4, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Other bookmarks", IsFolder: true, Position: 1, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Go", Path: ",Other bookmarks,", URL: "https://go.dev/", CreatedAt: added},
+	}
+	if !cmp.Equal(want, got, ignoreFileIndexes) {
+		t.Error(cmp.Diff(want, got, ignoreFileIndexes))
+	}
+}
+
+func TestParseBookmarksFirefoxJSON(t *testing.T) {
+	t.Parallel()
+	file, err := os.Open("../../../internal/testdata/bookmarks/firefoxbookmarks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	APIKey := uuid.New().String()
+	parser, err := NewBookmarkParser(file, "", APIKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parser.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := time.Date(2013, 10, 16, 15, 5, 1, 0, time.UTC)
+	want := []Bookmark{
+		{APIKey: APIKey, Name: "Bookmarks Menu", IsFolder: true, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Mozilla Firefox", Path: ",Bookmarks Menu,", IsFolder: true, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Help and Tutorials", Path: ",Bookmarks Menu,Mozilla Firefox,", URL: "https://www.mozilla.org/en-US/firefox/help/", CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Bookmarks Toolbar", IsFolder: true, Position: 1, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Apple", Path: ",Bookmarks Toolbar,", URL: "https://www.apple.com/jp/", CreatedAt: added, ModifiedAt: added, IconURI: "https://www.apple.com/favicon.ico", Tags: []string{"apple", "shopping"}},
+		{APIKey: APIKey, Name: "iCloud", Path: ",Bookmarks Toolbar,", URL: "https://www.icloud.com/", Position: 1, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "Twitter", Path: ",Bookmarks Toolbar,", URL: "https://twitter.com/", Position: 2, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "News", Path: ",Bookmarks Toolbar,", IsFolder: true, Position: 3, CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "BBC", Path: ",Bookmarks Toolbar,News,", URL: "http://www.bbc.co.uk/", CreatedAt: added, ModifiedAt: added},
+		{APIKey: APIKey, Name: "CNN", Path: ",Bookmarks Toolbar,News,", URL: "http://www.cnn.com/", Position: 1, CreatedAt: added, ModifiedAt: added},
+	}
+	if !cmp.Equal(want, got, ignoreFileIndexes) {
+		t.Error(cmp.Diff(want, got, ignoreFileIndexes))
+	}
+}
+
+func TestParseFileIndexes(t *testing.T) {
+	t.Parallel()
+	files := []string{"safaribookmarks.html", "chromebookmarks.json", "firefoxbookmarks.json"}
+	for _, name := range files {
+		file, err := os.Open("../../../internal/testdata/bookmarks/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		parser, err := NewBookmarkParser(file, "", uuid.New().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := parser.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, b := range got {
+			if b.FileIndex != i+1 {
+				t.Errorf("%s: wanted %s to have file index %d, got %d", name, b.Name, i+1, b.FileIndex)
+			}
+			if b.FileParent == 0 {
+				if b.Path != BookmarksBasePath {
+					t.Errorf("%s: wanted %s without a parent to be at the top level, got %s", name, b.Name, b.Path)
+				}
+				continue
+			}
+			if parent := got[b.FileParent-1]; !parent.IsFolder || parent.ChildPath() != b.Path {
+				t.Errorf("%s: wanted %s to be inside its parent, got %+v", name, b.Name, parent)
+			}
+		}
+	}
+}
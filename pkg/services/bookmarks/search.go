@@ -0,0 +1,105 @@
+package bookmarks
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	BookmarksSearchQueryKey     string = "q"
+	BookmarksSearchFolderKey    string = "folder"
+	BookmarksSearchPageKey      string = "page"
+	BookmarksSearchLimitKey     string = "limit"
+	BookmarksSearchDefaultLimit int    = 20
+)
+
+// Search weights for each searchable field, shared by the db text index so that results
+// are ranked the same way whichever repository is used.
+const (
+	BookmarksSearchNameWeight int = 10
+	BookmarksSearchURLWeight  int = 5
+	BookmarksSearchPathWeight int = 2
+)
+
+const searchExactMatchBonus = 2
+
+// SearchQuery represents a search over a users bookmarks. Folder optionally limits the
+// search to the bookmarks inside the folder with the given path, e.g. ",News,".
+type SearchQuery struct {
+	Query  string `validate:"min=1,max=100"`
+	Folder string `validate:"omitempty,startswith=0x2C,endswith=0x2C,max=100"`
+	Page   int    `validate:"min=1"`
+	Limit  int    `validate:"min=1,max=100"`
+}
+
+// Skip returns the number of results before the requested page.
+func (q SearchQuery) Skip() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// SearchResult represents a bookmark matching a search along with its relevance score.
+type SearchResult struct {
+	Bookmark `bson:",inline"`
+	Score    float64 `json:"score" bson:"score"`
+}
+
+// SearchResults represents a page of search results ordered by relevance.
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+	Total   int            `json:"total"`
+	Results []SearchResult `json:"results"`
+}
+
+// SearchTerms splits a search query into lowercase terms.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !(r == '-' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r > 127)
+	})
+}
+
+// ScoreBookmark scores how well a bookmark matches the given search terms. Matches in the
+// bookmark name rank above matches in the URL host and path, which rank above matches in
+// the folder path. A score of 0 means the bookmark does not match.
+func ScoreBookmark(b Bookmark, terms []string) float64 {
+	name := SearchTerms(b.Name)
+	var location []string
+	if u, err := url.Parse(b.URL); err == nil {
+		location = SearchTerms(u.Host + " " + u.Path)
+	} else {
+		location = SearchTerms(b.URL)
+	}
+	path := SearchTerms(b.Path)
+	var score float64
+	for _, t := range terms {
+		score += float64(BookmarksSearchNameWeight) * termScore(name, t)
+		score += float64(BookmarksSearchURLWeight) * termScore(location, t)
+		score += float64(BookmarksSearchPathWeight) * termScore(path, t)
+	}
+	return score
+}
+
+func termScore(words []string, term string) float64 {
+	var score float64
+	for _, w := range words {
+		switch {
+		case w == term:
+			score += searchExactMatchBonus
+		case strings.HasPrefix(w, term):
+			score++
+		}
+	}
+	return score
+}
+
+// SortSearchResults orders results by descending score, then by name.
+func SortSearchResults(results []SearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return strings.ToLower(results[i].Name) < strings.ToLower(results[j].Name)
+	})
+}
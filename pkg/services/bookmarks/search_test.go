@@ -0,0 +1,38 @@
+package bookmarks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchTerms(t *testing.T) {
+	t.Parallel()
+	got := SearchTerms("  Go, BBC.co.uk/news  effective_go ")
+	want := []string{"go", "bbc", "co", "uk", "news", "effective_go"}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
+
+func TestScoreBookmark(t *testing.T) {
+	t.Parallel()
+	name := Bookmark{Name: "Go", Path: ",Dev,", URL: "https://example.com/"}
+	url := Bookmark{Name: "Docs", Path: ",Dev,", URL: "https://go.dev/"}
+	path := Bookmark{Name: "Docs", Path: ",Go,", URL: "https://example.com/"}
+	prefix := Bookmark{Name: "Golang", Path: ",Dev,", URL: "https://example.com/"}
+	none := Bookmark{Name: "Docs", Path: ",Dev,", URL: "https://example.com/"}
+	terms := SearchTerms("go")
+	if !(ScoreBookmark(name, terms) > ScoreBookmark(url, terms)) {
+		t.Error("expected a name match to rank above a URL match")
+	}
+	if !(ScoreBookmark(url, terms) > ScoreBookmark(path, terms)) {
+		t.Error("expected a URL match to rank above a path match")
+	}
+	if !(ScoreBookmark(name, terms) > ScoreBookmark(prefix, terms)) {
+		t.Error("expected an exact match to rank above a prefix match")
+	}
+	if score := ScoreBookmark(none, terms); score != 0 {
+		t.Errorf("expected no match to score 0, got %f", score)
+	}
+}
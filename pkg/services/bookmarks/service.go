@@ -2,39 +2,107 @@ package bookmarks
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
-	"regexp"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/conalli/bookshelf-backend/pkg/apierr"
 	"github.com/conalli/bookshelf-backend/pkg/http/request"
 	"github.com/conalli/bookshelf-backend/pkg/logs"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type Service interface {
 	GetAllBookmarks(ctx context.Context, APIKey string) (*Folder, apierr.Error)
-	GetBookmarksFolder(ctx context.Context, path, APIKey string) (*Folder, apierr.Error)
-	AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error)
-	AddBookmarksFromFile(ctx context.Context, r *http.Request, APIKey string) (int, apierr.Error)
-	DeleteBookmark(ctx context.Context, bookmarkID, APIKey string) (int, apierr.Error)
+	GetBookmarksFolder(ctx context.Context, query FolderQuery, APIKey string) (*Folder, apierr.Error)
+	ListBookmarks(ctx context.Context, sort string, limit int, after, APIKey string) (*BookmarkPage, apierr.Error)
+	SearchBookmarks(ctx context.Context, query SearchQuery, APIKey string) (*SearchResults, apierr.Error)
+	GetBookmarksByTags(ctx context.Context, tags []string, match, APIKey string) (*Folder, apierr.Error)
+	GetTags(ctx context.Context, APIKey string) ([]TagCount, apierr.Error)
+	GetBrokenBookmarks(ctx context.Context, APIKey string) ([]Bookmark, apierr.Error)
+	CheckLinks(ctx context.Context, APIKey string) (*LinkCheckSummary, apierr.Error)
+	GetDuplicates(ctx context.Context, APIKey string) ([]DuplicateGroup, apierr.Error)
+	MergeDuplicates(ctx context.Context, requestData request.MergeBookmarks, APIKey string) (*MergeResult, apierr.Error)
+	AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (*Bookmark, apierr.Error)
+	AddBookmarksFromFile(ctx context.Context, r *http.Request, APIKey string) (*ImportJob, apierr.Error)
+	GetImportJob(ctx context.Context, jobID, APIKey string) (*ImportJob, apierr.Error)
+	ExportBookmarks(ctx context.Context, w io.Writer, APIKey string) apierr.Error
+	UpdateBookmark(ctx context.Context, bookmarkID string, requestData request.UpdateBookmark, APIKey string) (int, apierr.Error)
+	AddTags(ctx context.Context, bookmarkID string, requestData request.BookmarkTags, APIKey string) (int, apierr.Error)
+	RemoveTags(ctx context.Context, bookmarkID string, requestData request.BookmarkTags, APIKey string) (int, apierr.Error)
+	ReorderBookmarks(ctx context.Context, requestData request.ReorderBookmarks, APIKey string) (int, apierr.Error)
+	DeleteBookmark(ctx context.Context, bookmarkID, mode, APIKey string) (*DeleteResult, apierr.Error)
+	GetTrash(ctx context.Context, APIKey string) ([]TrashItem, apierr.Error)
+	RestoreBookmark(ctx context.Context, bookmarkID, APIKey string) (*RestoreResult, apierr.Error)
+	CreateSnapshot(ctx context.Context, requestData request.CreateSnapshot, APIKey string) (*Snapshot, apierr.Error)
+	GetSnapshots(ctx context.Context, APIKey string) ([]Snapshot, apierr.Error)
+	DiffSnapshot(ctx context.Context, snapshotID, APIKey string) (*SnapshotDiff, apierr.Error)
+	RestoreSnapshot(ctx context.Context, snapshotID, APIKey string) (int, apierr.Error)
+	ShareFolder(ctx context.Context, folderID string, requestData request.ShareFolder, APIKey string) (*Share, apierr.Error)
+	GetShares(ctx context.Context, APIKey string) ([]Share, apierr.Error)
+	RevokeShare(ctx context.Context, slug, APIKey string) (int, apierr.Error)
+	GetSharedFolder(ctx context.Context, slug string) (*SharedFolder, apierr.Error)
+	ExportSharedFolder(ctx context.Context, w io.Writer, slug string) apierr.Error
 }
 
 type Repository interface {
 	GetAllBookmarks(ctx context.Context, APIKey string) ([]Bookmark, apierr.Error)
-	GetBookmarksFolder(ctx context.Context, path, APIKey string) ([]Bookmark, apierr.Error)
-	AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error)
+	GetBookmarksFolder(ctx context.Context, query FolderQuery, APIKey string) (*FolderContents, apierr.Error)
+	ListBookmarks(ctx context.Context, query ListQuery, APIKey string) ([]Bookmark, apierr.Error)
+	SearchBookmarks(ctx context.Context, query SearchQuery, APIKey string) (*SearchResults, apierr.Error)
+	GetBookmarksByTags(ctx context.Context, tags []string, matchAll bool, APIKey string) ([]Bookmark, apierr.Error)
+	GetTagCounts(ctx context.Context, APIKey string) ([]TagCount, apierr.Error)
+	GetBrokenBookmarks(ctx context.Context, APIKey string) ([]Bookmark, apierr.Error)
+	UpdateLinkStatuses(ctx context.Context, APIKey string, results []LinkResult) (int, apierr.Error)
+	AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (*Bookmark, apierr.Error)
 	AddManyBookmarks(ctx context.Context, bookmarks []Bookmark) (int, apierr.Error)
-	DeleteBookmark(ctx context.Context, bookmarkID, APIKey string) (int, apierr.Error)
+	UpdateManyBookmarks(ctx context.Context, bookmarks []Bookmark) (int, apierr.Error)
+	ReplaceAllBookmarks(ctx context.Context, APIKey string, bookmarks []Bookmark) (int, apierr.Error)
+	StageBookmarks(ctx context.Context, importID string, bookmarks []Bookmark) (int, apierr.Error)
+	ReplaceWithStagedBookmarks(ctx context.Context, importID, APIKey string) (int, apierr.Error)
+	DeleteStagedBookmarks(ctx context.Context, importID string) (int, apierr.Error)
+	UpdateBookmark(ctx context.Context, bookmarkID string, requestData request.UpdateBookmark, APIKey string) (int, apierr.Error)
+	AddTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error)
+	RemoveTags(ctx context.Context, bookmarkID string, tags []string, APIKey string) (int, apierr.Error)
+	MergeBookmarks(ctx context.Context, keep Bookmark, duplicateIDs []string, APIKey string) (int, apierr.Error)
+	UpdatePositions(ctx context.Context, folderID string, IDs []string, APIKey string) (int, apierr.Error)
+	DeleteBookmark(ctx context.Context, bookmarkID, mode, APIKey string) (*DeleteResult, apierr.Error)
+	GetTrash(ctx context.Context, APIKey string) ([]Bookmark, apierr.Error)
+	RestoreBookmark(ctx context.Context, trashID string, dest *Destination, APIKey string) (int, apierr.Error)
+	AddSnapshot(ctx context.Context, snapshot Snapshot) (string, apierr.Error)
+	GetSnapshots(ctx context.Context, APIKey string) ([]Snapshot, apierr.Error)
+	GetSnapshot(ctx context.Context, snapshotID, APIKey string) (*Snapshot, apierr.Error)
+	AddShare(ctx context.Context, share Share) apierr.Error
+	GetShare(ctx context.Context, slug string) (*Share, apierr.Error)
+	GetShares(ctx context.Context, APIKey string) ([]Share, apierr.Error)
+	DeleteShare(ctx context.Context, slug, APIKey string) (int, apierr.Error)
+	EnrichmentRepository
+}
+
+// Cache stores the progress of bookmark import jobs.
+type Cache interface {
+	GetImportJob(ctx context.Context, APIKey, jobID string) (*ImportJob, error)
+	SetImportJob(ctx context.Context, job *ImportJob, ttl time.Duration) error
 }
 
 type service struct {
 	log      logs.Logger
 	validate *validator.Validate
 	db       Repository
+	cache    Cache
+	checker  *LinkChecker
+	checking sync.Map
+	enricher *Enricher
 }
 
-func NewService(l logs.Logger, v *validator.Validate, db Repository) *service {
-	return &service{l, v, db}
+func NewService(l logs.Logger, v *validator.Validate, db Repository, cache Cache) *service {
+	return &service{log: l, validate: v, db: db, cache: cache, checker: NewDefaultLinkChecker(), enricher: NewDefaultEnricher(l, db)}
 }
 
 func (s *service) GetAllBookmarks(ctx context.Context, APIKey string) (*Folder, apierr.Error) {
@@ -46,96 +114,792 @@ func (s *service) GetAllBookmarks(ctx context.Context, APIKey string) (*Folder,
 		return nil, apierr.NewBadRequestError("request format incorrect.")
 	}
 	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
-	folder := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath, BookmarksBasePath)
+	folder := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath)
 	return folder, err
 }
 
-func (s *service) GetBookmarksFolder(ctx context.Context, folderName, APIKey string) (*Folder, apierr.Error) {
+// GetBookmarksFolder gets the folder found by query with all the bookmarks inside it, returning
+// a not found error if there is no such folder.
+func (s *service) GetBookmarksFolder(ctx context.Context, query FolderQuery, APIKey string) (*Folder, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Struct(query)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil || query.keys() != 1 {
+		s.log.Errorf("Could not validate GET BOOKMARKS FOLDER request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	contents, err := s.db.GetBookmarksFolder(reqCtx, query, APIKey)
+	if err != nil {
+		s.log.Errorf("could not get bookmarks folder %+v: %v", query, err)
+		return nil, err
+	}
+	return organizeFolder(contents), nil
+}
+
+// ListBookmarks gets a page of a users bookmarks as a flat list ordered by sort. The after cursor
+// comes from the previous page and is empty for the first page.
+func (s *service) ListBookmarks(ctx context.Context, sort string, limit int, after, APIKey string) (*BookmarkPage, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	query := ListQuery{Sort: sort, Limit: limit}
+	if len(query.Sort) == 0 {
+		query.Sort = BookmarksListSortName
+	}
+	if query.Limit == 0 {
+		query.Limit = BookmarksListDefaultLimit
+	}
+	validateReqErr := s.validate.Struct(query)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate LIST BOOKMARKS request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	if len(after) > 0 {
+		cursor, err := decodeListCursor(after, query.Sort)
+		if err != nil {
+			s.log.Errorf("Could not validate LIST BOOKMARKS request: %v", err)
+			return nil, apierr.NewBadRequestError("invalid cursor")
+		}
+		query.After = cursor
+	}
+	limit = query.Limit
+	query.Limit++
+	books, err := s.db.ListBookmarks(reqCtx, query, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	page := &BookmarkPage{Bookmarks: books}
+	if len(books) > limit {
+		page.Bookmarks = books[:limit]
+		page.Next = newListCursor(books[limit-1], query.Sort).encode()
+	}
+	return page, nil
+}
+
+// SearchBookmarks searches the names, URLs and folder paths of a users bookmarks, returning
+// a page of results ordered by relevance.
+func (s *service) SearchBookmarks(ctx context.Context, query SearchQuery, APIKey string) (*SearchResults, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	query.Query = strings.TrimSpace(query.Query)
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.Limit == 0 {
+		query.Limit = BookmarksSearchDefaultLimit
+	}
+	validateReqErr := s.validate.Struct(query)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil || len(SearchTerms(query.Query)) == 0 {
+		s.log.Errorf("Could not validate SEARCH BOOKMARKS request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	results, err := s.db.SearchBookmarks(reqCtx, query, APIKey)
+	return results, err
+}
+
+// GetBookmarksByTags gets the bookmarks with all of the given tags, or any of them when match is any,
+// organized into their folders. Folders without any matching bookmarks are left out.
+func (s *service) GetBookmarksByTags(ctx context.Context, tags []string, match, APIKey string) (*Folder, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	tags = NormalizeTags(tags)
+	if len(match) == 0 {
+		match = BookmarksTagMatchAll
+	}
+	validateTagsErr := s.validate.Var(tags, "min=1,max=20,dive,max=30")
+	validateMatchErr := s.validate.Var(match, "oneof="+BookmarksTagMatchAll+" "+BookmarksTagMatchAny)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateTagsErr != nil || validateMatchErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate GET BOOKMARKS BY TAGS request: %v - %v - %v", validateTagsErr, validateMatchErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	books, err := s.db.GetBookmarksByTags(reqCtx, tags, match == BookmarksTagMatchAll, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	folder := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath)
+	pruneEmptyFolders(folder)
+	return folder, nil
+}
+
+// GetTags gets every tag used by an account along with the number of bookmarks using it.
+func (s *service) GetTags(ctx context.Context, APIKey string) ([]TagCount, apierr.Error) {
 	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
 	defer cancelFunc()
 	validateErr := s.validate.Var(APIKey, "uuid")
 	if validateErr != nil {
-		s.log.Errorf("Could not validate GET BOOKMARKS FOLDER request: %v", validateErr)
+		s.log.Errorf("Could not validate GET TAGS request: %v", validateErr)
 		return nil, apierr.NewBadRequestError("request format incorrect.")
 	}
-	books, err := s.db.GetBookmarksFolder(reqCtx, folderName, APIKey)
+	counts, err := s.db.GetTagCounts(reqCtx, APIKey)
 	if err != nil {
-		s.log.Error("could not get bookmarks from folder %s", folderName)
-		return nil, apierr.NewInternalServerError()
+		return nil, err
 	}
+	SortTagCounts(counts)
+	return counts, nil
+}
 
-	var f Bookmark
-	for idx, b := range books {
-		if b.IsFolder {
-			match, err := regexp.MatchString(`(?i)`+folderName, b.Name)
-			if err != nil {
-				s.log.Errorf("error matching string in regex")
-				return nil, apierr.NewInternalServerError()
-			}
-			if match {
-				f = b
-				end := len(books) - 1
-				books[idx], books[end] = books[end], books[idx]
-				books = books[:end]
-				break
-			}
+// GetBrokenBookmarks gets the bookmarks that could not be reached the last time their links were checked.
+func (s *service) GetBrokenBookmarks(ctx context.Context, APIKey string) ([]Bookmark, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET BROKEN BOOKMARKS request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	books, err := s.db.GetBrokenBookmarks(reqCtx, APIKey)
+	return books, err
+}
+
+// CheckLinks starts checking the links of all of an accounts bookmarks in the background. Only one
+// check can run for an account at a time.
+func (s *service) CheckLinks(ctx context.Context, APIKey string) (*LinkCheckSummary, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate CHECK LINKS request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	if _, running := s.checking.LoadOrStore(APIKey, true); running {
+		return nil, apierr.NewConflictError("a link check is already running")
+	}
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		s.checking.Delete(APIKey)
+		return nil, err
+	}
+	toCheck := []Bookmark{}
+	for _, b := range books {
+		if !b.IsFolder && isCheckableURL(b.URL) {
+			toCheck = append(toCheck, b)
 		}
 	}
-	folder := organizeBookmarks(books, f.ID, f.Name, f.Path, updatePath(f.Path, f.Name))
-	return folder, err
+	go s.checkLinks(APIKey, toCheck)
+	return &LinkCheckSummary{Queued: len(toCheck)}, nil
+}
+
+func (s *service) checkLinks(APIKey string, books []Bookmark) {
+	defer s.checking.Delete(APIKey)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), LinkCheckMaxDuration)
+	defer cancelFunc()
+	numUpdated := 0
+	batch := make([]LinkResult, 0, linkCheckBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		n, err := s.db.UpdateLinkStatuses(ctx, APIKey, batch)
+		if err != nil {
+			s.log.Errorf("could not update link statuses: %v", err)
+		}
+		numUpdated += n
+		batch = batch[:0]
+	}
+	for result := range s.checker.Check(ctx, books) {
+		batch = append(batch, result)
+		if len(batch) == linkCheckBatchSize {
+			flush()
+		}
+	}
+	flush()
+	s.log.Infof("checked links of %d bookmarks", numUpdated)
 }
 
-// AddBookmark adds a bookmark for an account.
-func (s *service) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error) {
+// GetDuplicates gets the groups of an accounts bookmarks that point to the same page.
+func (s *service) GetDuplicates(ctx context.Context, APIKey string) ([]DuplicateGroup, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET DUPLICATES request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	return FindDuplicates(books), nil
+}
+
+// MergeDuplicates merges bookmarks that share a canonical URL into the bookmark to keep, moving
+// the rest to the trash. If no IDs are given every duplicate of the kept bookmark is merged.
+func (s *service) MergeDuplicates(ctx context.Context, requestData request.MergeBookmarks, APIKey string) (*MergeResult, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Struct(requestData)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate MERGE DUPLICATES request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(books, func(b Bookmark) bool { return b.ID == requestData.Keep && !b.IsFolder })
+	if idx < 0 {
+		return nil, apierr.NewNotFoundError("bookmark not found")
+	}
+	duplicates, dupErr := selectDuplicates(books, books[idx], requestData.IDs)
+	if dupErr != nil {
+		s.log.Errorf("Could not merge duplicates of bookmark %s: %v", requestData.Keep, dupErr)
+		return nil, apierr.NewBadRequestError(dupErr.Error())
+	}
+	merged := mergeDuplicates(books[idx], duplicates, time.Now().UTC())
+	duplicateIDs := make([]string, len(duplicates))
+	for i, d := range duplicates {
+		duplicateIDs[i] = d.ID
+	}
+	numDeleted, err := s.db.MergeBookmarks(reqCtx, merged, duplicateIDs, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	return &MergeResult{Bookmark: merged, NumDeleted: numDeleted}, nil
+}
+
+// AddBookmark adds a bookmark for an account. Unless the request skips enrichment, any missing name,
+// description and icons are filled in from the bookmarked page in the background.
+func (s *service) AddBookmark(ctx context.Context, requestData request.AddBookmark, APIKey string) (*Bookmark, apierr.Error) {
 	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
 	defer cancelFunc()
 	validateReqErr := s.validate.Struct(requestData)
 	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
 	if validateReqErr != nil || validateAPIKeyErr != nil {
 		s.log.Errorf("Could not validate ADD BOOKMARK request: %v - %v", validateReqErr, validateAPIKeyErr)
-		return 0, apierr.NewBadRequestError("request format incorrect.")
+		return nil, apierr.NewBadRequestError("request format incorrect.")
 	}
-	numUpdated, err := s.db.AddBookmark(reqCtx, requestData, APIKey)
-	return numUpdated, err
+	bookmark, err := s.db.AddBookmark(reqCtx, requestData, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if !requestData.SkipEnrichment {
+		s.enricher.Enqueue(*bookmark)
+	}
+	return bookmark, nil
 }
 
-func (s *service) AddBookmarksFromFile(ctx context.Context, r *http.Request, APIKey string) (int, apierr.Error) {
+// AddBookmarksFromFile starts importing the bookmarks file in the request in the background, returning
+// the queued job. Depending on the import mode the bookmarks are merged with, appended to or replace
+// the bookmarks already in the account. The file is copied so that it can still be read once the
+// request has finished.
+func (s *service) AddBookmarksFromFile(ctx context.Context, r *http.Request, APIKey string) (*ImportJob, apierr.Error) {
 	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
 	defer cancelFunc()
+	mode := r.FormValue(BookmarksImportModeKey)
+	if len(mode) == 0 {
+		mode = BookmarksImportModeMerge
+	}
+	validateModeErr := s.validate.Var(mode, "oneof="+BookmarksImportModeMerge+" "+BookmarksImportModeAppend+" "+BookmarksImportModeReplace)
+	if validateModeErr != nil {
+		s.log.Errorf("Could not validate ADD BOOKMARKS FILE request: %v", validateModeErr)
+		return nil, apierr.NewBadRequestError("invalid import mode")
+	}
 	header, ok := r.MultipartForm.File[BookmarksFileKey]
 	if !ok || len(header) != 1 {
 		s.log.Error("Could not find bookmarks_file in request")
-		return 0, apierr.NewBadRequestError("no bookmark file in request")
+		return nil, apierr.NewBadRequestError("no bookmark file in request")
+	}
+	file, err := copyUpload(header[0])
+	if err != nil {
+		s.log.Errorf("Could not copy bookmarks_file: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	parser, err := NewBookmarkParser(file, r.FormValue(BookmarksFormatKey), APIKey)
+	if err != nil {
+		removeUpload(file)
+		s.log.Errorf("Could not find parser for bookmarks_file: %v", err)
+		return nil, apierr.NewBadRequestError("unsupported bookmark file format")
+	}
+	now := time.Now().UTC()
+	job := &ImportJob{
+		ID:            uuid.New().String(),
+		APIKey:        APIKey,
+		Status:        ImportStatusQueued,
+		ImportSummary: ImportSummary{Mode: mode},
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.cache.SetImportJob(reqCtx, job, BookmarksImportJobTTL); err != nil {
+		removeUpload(file)
+		s.log.Errorf("Could not save import job: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	queued := *job
+	go s.importBookmarks(job, file, parser)
+	return &queued, nil
+}
+
+// importBookmarks parses the copied file and saves its bookmarks in batches, saving the progress of
+// the job after each batch. Replacing the users bookmarks only happens once the whole file has been
+// parsed, so a file that cannot be parsed leaves them unchanged.
+func (s *service) importBookmarks(job *ImportJob, file *os.File, parser BookmarkParser) {
+	defer removeUpload(file)
+	ctx, cancelFunc := context.WithTimeout(context.Background(), BookmarksImportMaxDuration)
+	defer cancelFunc()
+	job.Status = ImportStatusRunning
+	s.saveImportJob(ctx, job)
+	imp, err := newImporter(ctx, s.db, job, job.CreatedAt)
+	if err != nil {
+		s.log.Errorf("Could not get existing bookmarks for import: %v", err)
+		s.failImportJob(ctx, job, "could not get existing bookmarks")
+		return
+	}
+	batch := make([]Bookmark, 0, BookmarksImportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		imp.importBatch(ctx, batch)
+		batch = batch[:0]
+		s.saveImportJob(ctx, job)
 	}
-	file, err := header[0].Open()
+	err = StreamBookmarks(parser, func(b Bookmark) error {
+		job.Parsed++
+		batch = append(batch, b)
+		if len(batch) == BookmarksImportBatchSize {
+			flush()
+		}
+		return ctx.Err()
+	})
 	if err != nil {
-		s.log.Error("Could not open open bookmarks_file")
-		return 0, apierr.NewInternalServerError()
+		s.log.Errorf("Could not parse bookmarks_file: %v", err)
+		s.discardImport(ctx, imp)
+		s.failImportJob(ctx, job, "could not parse bookmark file")
+		return
+	}
+	flush()
+	if err := imp.finish(ctx); err != nil {
+		s.log.Errorf("Could not replace bookmarks with bookmarks_file: %v", err)
+		s.discardImport(ctx, imp)
+		s.failImportJob(ctx, job, "could not replace bookmarks")
+		return
+	}
+	finished := time.Now().UTC()
+	job.Status, job.FinishedAt = ImportStatusDone, &finished
+	s.saveImportJob(ctx, job)
+	s.log.Infof("imported bookmarks file: %+v", job.ImportSummary)
+}
+
+// discardImport removes anything staged by a failed import. It is given its own deadline so that
+// imports that failed by running out of time are still cleaned up.
+func (s *service) discardImport(ctx context.Context, imp *importer) {
+	discardCtx, cancelFunc := request.CtxWithDefaultTimeout(context.WithoutCancel(ctx))
+	defer cancelFunc()
+	if err := imp.discard(discardCtx); err != nil {
+		s.log.Errorf("Could not discard staged bookmarks for import %s: %v", imp.job.ID, err)
+	}
+}
+
+func (s *service) failImportJob(ctx context.Context, job *ImportJob, reason string) {
+	finished := time.Now().UTC()
+	job.Status, job.Error, job.FinishedAt = ImportStatusFailed, reason, &finished
+	s.saveImportJob(ctx, job)
+}
+
+func (s *service) saveImportJob(ctx context.Context, job *ImportJob) {
+	job.UpdatedAt = time.Now().UTC()
+	if err := s.cache.SetImportJob(ctx, job, BookmarksImportJobTTL); err != nil {
+		s.log.Errorf("Could not save import job %s: %v", job.ID, err)
+	}
+}
+
+// GetImportJob gets the progress of one of the users import jobs.
+func (s *service) GetImportJob(ctx context.Context, jobID, APIKey string) (*ImportJob, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateIDErr := s.validate.Var(jobID, "uuid")
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateIDErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate GET IMPORT JOB request: %v - %v", validateIDErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
 	}
-	defer file.Close()
-	bookmarks, err := NewHTMLBookmarkParser(file, APIKey).parseBookmarkFileHTML()
+	job, err := s.cache.GetImportJob(reqCtx, APIKey, jobID)
 	if err != nil {
-		s.log.Error("Could not parse bookmarks_file")
-		return 0, apierr.NewBadRequestError("could not parse bookmark file")
+		if errors.Is(err, ErrImportJobNotFound) {
+			return nil, apierr.NewNotFoundError("import job not found")
+		}
+		s.log.Errorf("Could not get import job %s: %v", jobID, err)
+		return nil, apierr.NewInternalServerError()
+	}
+	return job, nil
+}
+
+// ExportBookmarks writes all of an account's bookmarks to w as a Netscape bookmark file.
+func (s *service) ExportBookmarks(ctx context.Context, w io.Writer, APIKey string) apierr.Error {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate EXPORT BOOKMARKS request: %v", validateErr)
+		return apierr.NewBadRequestError("request format incorrect.")
 	}
-	numAdded, apierr := s.db.AddManyBookmarks(reqCtx, bookmarks)
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
 	if err != nil {
-		s.log.Error("Could not add bookmarks to db")
-		return 0, apierr
+		s.log.Errorf("Could not get bookmarks to export: %v", err)
+		return err
+	}
+	folder := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath)
+	if err := NewHTMLBookmarkWriter(w).writeBookmarkFileHTML(folder); err != nil {
+		s.log.Errorf("Could not write bookmarks file: %v", err)
+		return apierr.NewInternalServerError()
 	}
-	return numAdded, nil
+	return nil
 }
 
-// DeleteBookmark removes a bookmark from an account.
-func (s *service) DeleteBookmark(ctx context.Context, bookmarkID, APIKey string) (int, apierr.Error) {
+// UpdateBookmark changes the name, url or path of a bookmark, returning the number of bookmarks
+// updated. Renaming or moving a folder also updates every bookmark inside of it.
+func (s *service) UpdateBookmark(ctx context.Context, bookmarkID string, requestData request.UpdateBookmark, APIKey string) (int, apierr.Error) {
 	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
 	defer cancelFunc()
-	validateReqErr := s.validate.Var(bookmarkID, "len=24,hexadecimal")
+	validateIDErr := s.validate.Var(bookmarkID, "len=24,hexadecimal")
+	validateReqErr := s.validate.Struct(requestData)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateIDErr != nil || validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate UPDATE BOOKMARK request: %v - %v - %v", validateIDErr, validateReqErr, validateAPIKeyErr)
+		return 0, apierr.NewBadRequestError("request format incorrect")
+	}
+	if requestData.Name == nil && requestData.ParentID == nil && requestData.Path == nil && requestData.URL == nil {
+		s.log.Error("Could not update bookmark: no fields to update")
+		return 0, apierr.NewBadRequestError("no fields to update")
+	}
+	if p := requestData.Path; p != nil && *p != BookmarksBasePath && (!strings.HasPrefix(*p, ",") || !strings.HasSuffix(*p, ",")) {
+		s.log.Errorf("Could not update bookmark: invalid path %s", *p)
+		return 0, apierr.NewBadRequestError("invalid bookmark path")
+	}
+	numUpdated, err := s.db.UpdateBookmark(reqCtx, bookmarkID, requestData, APIKey)
+	return numUpdated, err
+}
+
+// AddTags adds tags to a bookmark, ignoring any tags it already has.
+func (s *service) AddTags(ctx context.Context, bookmarkID string, requestData request.BookmarkTags, APIKey string) (int, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	tags, apiErr := s.validateTagsRequest("ADD TAGS", bookmarkID, requestData, APIKey)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	numUpdated, err := s.db.AddTags(reqCtx, bookmarkID, tags, APIKey)
+	return numUpdated, err
+}
+
+// RemoveTags removes tags from a bookmark.
+func (s *service) RemoveTags(ctx context.Context, bookmarkID string, requestData request.BookmarkTags, APIKey string) (int, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	tags, apiErr := s.validateTagsRequest("REMOVE TAGS", bookmarkID, requestData, APIKey)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	numUpdated, err := s.db.RemoveTags(reqCtx, bookmarkID, tags, APIKey)
+	return numUpdated, err
+}
+
+func (s *service) validateTagsRequest(name, bookmarkID string, requestData request.BookmarkTags, APIKey string) ([]string, apierr.Error) {
+	requestData.Tags = NormalizeTags(requestData.Tags)
+	validateIDErr := s.validate.Var(bookmarkID, "len=24,hexadecimal")
+	validateReqErr := s.validate.Struct(requestData)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateIDErr != nil || validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate %s request: %v - %v - %v", name, validateIDErr, validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	return requestData.Tags, nil
+}
+
+// ReorderBookmarks changes the order of the bookmarks and folders in a folder to the order of the
+// given IDs, which must list every child of the folder. An empty folder ID reorders the top level.
+func (s *service) ReorderBookmarks(ctx context.Context, requestData request.ReorderBookmarks, APIKey string) (int, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Struct(requestData)
 	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
 	if validateReqErr != nil || validateAPIKeyErr != nil {
-		s.log.Errorf("Could not validate DELETE BOOKMARK request: %v - %v", validateReqErr, validateAPIKeyErr)
+		s.log.Errorf("Could not validate REORDER BOOKMARKS request: %v - %v", validateReqErr, validateAPIKeyErr)
 		return 0, apierr.NewBadRequestError("request format incorrect")
 	}
-	numUpdated, err := s.db.DeleteBookmark(reqCtx, bookmarkID, APIKey)
+	numUpdated, err := s.db.UpdatePositions(reqCtx, requestData.FolderID, requestData.IDs, APIKey)
 	return numUpdated, err
 }
+
+// DeleteBookmark moves a bookmark to the trash. Deleting a folder also deletes its contents,
+// unless mode is reparent, in which case they are moved into the folder's parent.
+func (s *service) DeleteBookmark(ctx context.Context, bookmarkID, mode, APIKey string) (*DeleteResult, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	if len(mode) == 0 {
+		mode = BookmarksDeleteModeCascade
+	}
+	validateReqErr := s.validate.Var(bookmarkID, "len=24,hexadecimal")
+	validateModeErr := s.validate.Var(mode, "oneof="+BookmarksDeleteModeCascade+" "+BookmarksDeleteModeReparent)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateModeErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate DELETE BOOKMARK request: %v - %v - %v", validateReqErr, validateModeErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect")
+	}
+	result, err := s.db.DeleteBookmark(reqCtx, bookmarkID, mode, APIKey)
+	return result, err
+}
+
+// GetTrash gets the bookmarks and folders an account has deleted, most recently deleted first.
+func (s *service) GetTrash(ctx context.Context, APIKey string) ([]TrashItem, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET TRASH request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	trashed, err := s.db.GetTrash(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	return groupTrash(trashed), nil
+}
+
+// RestoreBookmark moves a deleted bookmark, along with the contents of a deleted folder, out of the
+// trash and back to its original folder. If that folder no longer exists the bookmark is restored to
+// its original path instead, recreating any folders that are missing.
+func (s *service) RestoreBookmark(ctx context.Context, bookmarkID, APIKey string) (*RestoreResult, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Var(bookmarkID, "len=24,hexadecimal")
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate RESTORE BOOKMARK request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect")
+	}
+	trashed, err := s.db.GetTrash(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	idx := slices.IndexFunc(trashed, func(b Bookmark) bool { return b.ID == bookmarkID && b.IsTrashRoot() })
+	if idx < 0 {
+		return nil, apierr.NewNotFoundError("bookmark not found in trash")
+	}
+	deleted := trashed[idx]
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	var dest *Destination
+	if !hasParent(deleted, books) {
+		var resolveErr error
+		dest, resolveErr = NewFolderIndex(books, APIKey, time.Now().UTC()).Resolve("", deleted.Path)
+		if resolveErr != nil {
+			s.log.Errorf("Could not find folder to restore bookmark %s into: %v", bookmarkID, resolveErr)
+			if errors.Is(resolveErr, ErrFolderNotFound) {
+				return nil, apierr.NewNotFoundError("folder to restore into not found")
+			}
+			return nil, apierr.NewInternalServerError()
+		}
+	}
+	numRestored, err := s.db.RestoreBookmark(reqCtx, bookmarkID, dest, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	if dest == nil {
+		return &RestoreResult{Restored: numRestored}, nil
+	}
+	return &RestoreResult{Restored: numRestored, Created: len(dest.Created)}, nil
+}
+
+// CreateSnapshot saves a copy of all of the users bookmarks that can be restored later.
+func (s *service) CreateSnapshot(ctx context.Context, requestData request.CreateSnapshot, APIKey string) (*Snapshot, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateReqErr := s.validate.Struct(requestData)
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate CREATE SNAPSHOT request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, snapshotErr := NewSnapshot(books, requestData.Name, APIKey, time.Now().UTC())
+	if snapshotErr != nil {
+		s.log.Errorf("Could not compress bookmarks for snapshot: %v", snapshotErr)
+		return nil, apierr.NewInternalServerError()
+	}
+	snapshot.ID, err = s.db.AddSnapshot(reqCtx, *snapshot)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Data = nil
+	return snapshot, nil
+}
+
+// GetSnapshots gets the users snapshots, newest first, without the bookmarks in them.
+func (s *service) GetSnapshots(ctx context.Context, APIKey string) ([]Snapshot, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET SNAPSHOTS request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	return s.db.GetSnapshots(reqCtx, APIKey)
+}
+
+// DiffSnapshot compares one of the users snapshots with their current bookmarks.
+func (s *service) DiffSnapshot(ctx context.Context, snapshotID, APIKey string) (*SnapshotDiff, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	snapshot, snapshotBooks, err := s.getSnapshotBookmarks(reqCtx, snapshotID, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	books, err := s.db.GetAllBookmarks(reqCtx, APIKey)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffSnapshot(snapshotBooks, books)
+	diff.SnapshotID = snapshot.ID
+	return diff, nil
+}
+
+// RestoreSnapshot replaces all of the users bookmarks with those in one of their snapshots in a
+// single transaction, returning the number of bookmarks restored. The transaction is given longer
+// than the default request timeout the more bookmarks there are in the snapshot.
+func (s *service) RestoreSnapshot(ctx context.Context, snapshotID, APIKey string) (int, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	_, books, err := s.getSnapshotBookmarks(reqCtx, snapshotID, APIKey)
+	if err != nil {
+		return 0, err
+	}
+	restoreCtx, cancelRestore := context.WithTimeout(ctx, SnapshotRestoreTimeout(len(books)))
+	defer cancelRestore()
+	return s.db.ReplaceAllBookmarks(restoreCtx, APIKey, books)
+}
+
+func (s *service) getSnapshotBookmarks(ctx context.Context, snapshotID, APIKey string) (*Snapshot, []Bookmark, apierr.Error) {
+	validateReqErr := s.validate.Var(snapshotID, "len=24,hexadecimal")
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateReqErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate SNAPSHOT request: %v - %v", validateReqErr, validateAPIKeyErr)
+		return nil, nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	snapshot, err := s.db.GetSnapshot(ctx, snapshotID, APIKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	books, decodeErr := snapshot.Bookmarks()
+	if decodeErr != nil {
+		s.log.Errorf("Could not decompress snapshot %s: %v", snapshotID, decodeErr)
+		return nil, nil, apierr.NewInternalServerError()
+	}
+	return snapshot, books, nil
+}
+
+// ShareFolder publishes one of the users folders under a new slug so that it can be read by anyone
+// without logging in, until the share is revoked or expires.
+func (s *service) ShareFolder(ctx context.Context, folderID string, requestData request.ShareFolder, APIKey string) (*Share, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	now := time.Now().UTC()
+	validateIDErr := s.validate.Var(folderID, "len=24,hexadecimal")
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateIDErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate SHARE FOLDER request: %v - %v", validateIDErr, validateAPIKeyErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	if requestData.ExpiresAt != nil && !requestData.ExpiresAt.After(now) {
+		s.log.Errorf("Could not validate SHARE FOLDER request: expiry %v is in the past", requestData.ExpiresAt)
+		return nil, apierr.NewBadRequestError("expires_at must be in the future")
+	}
+	if _, err := s.db.GetBookmarksFolder(reqCtx, FolderQuery{ID: folderID}, APIKey); err != nil {
+		return nil, err
+	}
+	share, shareErr := NewShare(folderID, APIKey, requestData.ExpiresAt, now)
+	if shareErr != nil {
+		s.log.Errorf("Could not create share slug: %v", shareErr)
+		return nil, apierr.NewInternalServerError()
+	}
+	if err := s.db.AddShare(reqCtx, *share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+// GetShares gets all of the folders the user has shared, including expired shares that have not
+// been removed yet.
+func (s *service) GetShares(ctx context.Context, APIKey string) ([]Share, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateErr := s.validate.Var(APIKey, "uuid")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET SHARES request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	return s.db.GetShares(reqCtx, APIKey)
+}
+
+// RevokeShare stops a shared folder being readable by its slug.
+func (s *service) RevokeShare(ctx context.Context, slug, APIKey string) (int, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	validateSlugErr := s.validate.Var(slug, "len=32,hexadecimal")
+	validateAPIKeyErr := s.validate.Var(APIKey, "uuid")
+	if validateSlugErr != nil || validateAPIKeyErr != nil {
+		s.log.Errorf("Could not validate REVOKE SHARE request: %v - %v", validateSlugErr, validateAPIKeyErr)
+		return 0, apierr.NewBadRequestError("request format incorrect.")
+	}
+	return s.db.DeleteShare(reqCtx, slug, APIKey)
+}
+
+// GetSharedFolder gets the folder shared under slug without anything that identifies its owner.
+func (s *service) GetSharedFolder(ctx context.Context, slug string) (*SharedFolder, apierr.Error) {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	folder, err := s.sharedFolder(reqCtx, slug)
+	if err != nil {
+		return nil, err
+	}
+	shared := newSharedFolder(folder)
+	return &shared, nil
+}
+
+// ExportSharedFolder writes the folder shared under slug to w as a Netscape bookmark file.
+func (s *service) ExportSharedFolder(ctx context.Context, w io.Writer, slug string) apierr.Error {
+	reqCtx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	folder, err := s.sharedFolder(reqCtx, slug)
+	if err != nil {
+		return err
+	}
+	if err := NewHTMLBookmarkWriter(w).writeBookmarkFileHTML(folder); err != nil {
+		s.log.Errorf("Could not write shared bookmarks file: %v", err)
+		return apierr.NewInternalServerError()
+	}
+	return nil
+}
+
+// sharedFolder gets the tree of the folder shared under slug, treating an expired share as not found.
+func (s *service) sharedFolder(ctx context.Context, slug string) (*Folder, apierr.Error) {
+	validateErr := s.validate.Var(slug, "len=32,hexadecimal")
+	if validateErr != nil {
+		s.log.Errorf("Could not validate GET SHARED FOLDER request: %v", validateErr)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	share, err := s.db.GetShare(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if share.Expired(time.Now()) {
+		return nil, apierr.NewNotFoundError("share not found")
+	}
+	contents, err := s.db.GetBookmarksFolder(ctx, FolderQuery{ID: share.FolderID}, share.APIKey)
+	if err != nil {
+		s.log.Errorf("could not get shared folder %s: %v", share.FolderID, err)
+		return nil, err
+	}
+	folder := organizeFolder(contents)
+	folder.Breadcrumb = nil
+	return folder, nil
+}
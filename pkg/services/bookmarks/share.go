@@ -0,0 +1,92 @@
+package bookmarks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Query keys and formats for the share GET endpoint.
+const (
+	ShareFormatKey  string = "format"
+	ShareFormatJSON string = "json"
+	ShareFormatHTML string = "html"
+)
+
+// shareSlugBytes is the number of random bytes in a share slug, which is hex encoded.
+const shareSlugBytes = 16
+
+// Share represents a folder published so that anyone with its slug can read it without logging in.
+// A share without ExpiresAt lasts until it is revoked.
+type Share struct {
+	Slug      string     `json:"slug" bson:"_id"`
+	APIKey    string     `json:"-" bson:"api_key"`
+	FolderID  string     `json:"folder_id" bson:"folder_id"`
+	CreatedAt time.Time  `json:"created_at" bson:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+}
+
+// NewShare returns a share of the folder folderID with a new unguessable slug.
+func NewShare(folderID, APIKey string, expiresAt *time.Time, now time.Time) (*Share, error) {
+	b := make([]byte, shareSlugBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &Share{
+		Slug:      hex.EncodeToString(b),
+		APIKey:    APIKey,
+		FolderID:  folderID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Expired reports whether the share has expired at now.
+func (s Share) Expired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// SharedFolder represents a read-only copy of a shared folder, leaving out anything that identifies
+// the user that shared it.
+type SharedFolder struct {
+	Name      string           `json:"name"`
+	Bookmarks []SharedBookmark `json:"bookmarks"`
+	Folders   []SharedFolder   `json:"folders"`
+}
+
+// SharedBookmark represents a bookmark inside a shared folder.
+type SharedBookmark struct {
+	Name        string    `json:"name"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Icon        string    `json:"icon,omitempty"`
+	IconURI     string    `json:"icon_uri,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ModifiedAt  time.Time `json:"modified_at"`
+}
+
+// newSharedFolder copies folder and everything inside it into a SharedFolder.
+func newSharedFolder(folder *Folder) SharedFolder {
+	shared := SharedFolder{
+		Name:      folder.Name,
+		Bookmarks: make([]SharedBookmark, 0, len(folder.Bookmarks)),
+		Folders:   make([]SharedFolder, 0, len(folder.Folders)),
+	}
+	for _, b := range folder.Bookmarks {
+		shared.Bookmarks = append(shared.Bookmarks, SharedBookmark{
+			Name:        b.Name,
+			URL:         b.URL,
+			Description: b.Description,
+			Icon:        b.Icon,
+			IconURI:     b.IconURI,
+			Tags:        b.Tags,
+			CreatedAt:   b.CreatedAt,
+			ModifiedAt:  b.ModifiedAt,
+		})
+	}
+	for i := range folder.Folders {
+		shared.Folders = append(shared.Folders, newSharedFolder(&folder.Folders[i]))
+	}
+	return shared
+}
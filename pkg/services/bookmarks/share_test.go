@@ -0,0 +1,26 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShareExpired(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 7, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+	share, err := NewShare("62c7e0a1f1d2b3a4c5d6e7f0", "key", &later, now)
+	if err != nil {
+		t.Fatalf("could not create share: %v", err)
+	}
+	other, _ := NewShare("62c7e0a1f1d2b3a4c5d6e7f0", "key", nil, now)
+	if len(share.Slug) != 32 || share.Slug == other.Slug {
+		t.Errorf("expected unique 32 character slugs: got %s and %s", share.Slug, other.Slug)
+	}
+	if share.Expired(now) || !share.Expired(later) {
+		t.Errorf("expected share to expire at %v", later)
+	}
+	if other.Expired(later.Add(24 * time.Hour)) {
+		t.Error("expected share without expiry never to expire")
+	}
+}
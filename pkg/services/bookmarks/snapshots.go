@@ -0,0 +1,151 @@
+package bookmarks
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Restoring a snapshot replaces every one of the users bookmarks, so it is given longer than other
+// requests, growing with the number of bookmarks in the snapshot up to SnapshotRestoreMaxDuration.
+const (
+	SnapshotRestoreMinDuration time.Duration = 5 * time.Second
+	SnapshotRestoreMaxDuration time.Duration = 5 * time.Minute
+	snapshotRestorePerBookmark time.Duration = time.Millisecond
+)
+
+// SnapshotRestoreTimeout returns how long restoring a snapshot of n bookmarks may take.
+func SnapshotRestoreTimeout(n int) time.Duration {
+	return min(SnapshotRestoreMinDuration+time.Duration(n)*snapshotRestorePerBookmark, SnapshotRestoreMaxDuration)
+}
+
+// Snapshot represents a copy of all of a users bookmarks at a point in time. The bookmarks are
+// stored in Data as gzipped JSON, and Size is the length of the compressed data.
+type Snapshot struct {
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	APIKey    string    `json:"-" bson:"api_key"`
+	Name      string    `json:"name,omitempty" bson:"name,omitempty"`
+	Count     int       `json:"count" bson:"count"`
+	Size      int       `json:"size" bson:"size"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	Data      []byte    `json:"-" bson:"data,omitempty"`
+}
+
+// NewSnapshot returns a snapshot of books named name.
+func NewSnapshot(books []Bookmark, name, APIKey string, now time.Time) (*Snapshot, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(books); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &Snapshot{
+		APIKey:    APIKey,
+		Name:      name,
+		Count:     len(books),
+		Size:      buf.Len(),
+		CreatedAt: now,
+		Data:      buf.Bytes(),
+	}, nil
+}
+
+// Bookmarks returns the bookmarks stored in the snapshot.
+func (s Snapshot) Bookmarks() ([]Bookmark, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(s.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	books := []Bookmark{}
+	if err := json.NewDecoder(zr).Decode(&books); err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// SnapshotDiff represents the differences between a snapshot and a users current bookmarks, matched
+// by ID. Added bookmarks have been added since the snapshot was taken and Removed bookmarks have
+// been deleted since, so restoring the snapshot would remove the former and bring back the latter.
+type SnapshotDiff struct {
+	SnapshotID string           `json:"snapshot_id"`
+	Added      []Bookmark       `json:"added"`
+	Removed    []Bookmark       `json:"removed"`
+	Changed    []BookmarkChange `json:"changed"`
+}
+
+// BookmarkChange represents a bookmark that has changed since a snapshot was taken. Fields lists the
+// JSON names of the fields that differ.
+type BookmarkChange struct {
+	Before Bookmark `json:"before"`
+	After  Bookmark `json:"after"`
+	Fields []string `json:"fields"`
+}
+
+// diffSnapshot compares the bookmarks in a snapshot with the current bookmarks.
+func diffSnapshot(snapshot, current []Bookmark) *SnapshotDiff {
+	diff := &SnapshotDiff{Added: []Bookmark{}, Removed: []Bookmark{}, Changed: []BookmarkChange{}}
+	before := make(map[string]Bookmark, len(snapshot))
+	for _, b := range snapshot {
+		before[b.ID] = b
+	}
+	for _, b := range current {
+		old, ok := before[b.ID]
+		if !ok {
+			diff.Added = append(diff.Added, b)
+			continue
+		}
+		delete(before, b.ID)
+		if fields := changedFields(old, b); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, BookmarkChange{Before: old, After: b, Fields: fields})
+		}
+	}
+	for _, b := range snapshot {
+		if _, ok := before[b.ID]; ok {
+			diff.Removed = append(diff.Removed, b)
+		}
+	}
+	sortByPath := func(books []Bookmark) {
+		sort.SliceStable(books, func(i, j int) bool {
+			return books[i].ChildPath() < books[j].ChildPath()
+		})
+	}
+	sortByPath(diff.Added)
+	sortByPath(diff.Removed)
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return diff.Changed[i].After.ChildPath() < diff.Changed[j].After.ChildPath()
+	})
+	return diff
+}
+
+// changedFields returns the JSON names of the user editable fields that differ between two versions
+// of a bookmark.
+func changedFields(before, after Bookmark) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, "name")
+	}
+	if before.URL != after.URL {
+		fields = append(fields, "url")
+	}
+	if before.ParentID != after.ParentID {
+		fields = append(fields, "parent_id")
+	}
+	if before.Path != after.Path {
+		fields = append(fields, "path")
+	}
+	if before.Position != after.Position {
+		fields = append(fields, "position")
+	}
+	if before.Description != after.Description {
+		fields = append(fields, "description")
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		fields = append(fields, "tags")
+	}
+	return fields
+}
@@ -0,0 +1,72 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshotBookmarks(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	books := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC", ParentID: "1", Ancestors: []string{"1"}, Path: ",News,", URL: "https://www.bbc.co.uk/", Tags: []string{"news"}, ModifiedAt: now},
+	}
+	snapshot, err := NewSnapshot(books, "daily", "key", now)
+	if err != nil {
+		t.Fatalf("could not create snapshot: %v", err)
+	}
+	if snapshot.Count != 2 || snapshot.Size != len(snapshot.Data) || !snapshot.CreatedAt.Equal(now) {
+		t.Errorf("unexpected snapshot metadata: %+v", snapshot)
+	}
+	got, err := snapshot.Bookmarks()
+	if err != nil {
+		t.Fatalf("could not read snapshot bookmarks: %v", err)
+	}
+	if !cmp.Equal(books, got) {
+		t.Error(cmp.Diff(books, got))
+	}
+}
+
+func TestSnapshotRestoreTimeout(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		name string
+		n    int
+		want time.Duration
+	}{
+		{name: "empty snapshot", n: 0, want: SnapshotRestoreMinDuration},
+		{name: "large snapshot", n: 60000, want: SnapshotRestoreMinDuration + time.Minute},
+		{name: "huge snapshot", n: 1000000, want: SnapshotRestoreMaxDuration},
+	}
+	for _, c := range tc {
+		if got := SnapshotRestoreTimeout(c.n); got != c.want {
+			t.Errorf("%s: wanted %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	t.Parallel()
+	snapshot := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC", ParentID: "1", Path: ",News,", URL: "https://www.bbc.co.uk/"},
+		{ID: "3", Name: "CNN", ParentID: "1", Path: ",News,", URL: "http://www.cnn.com/"},
+	}
+	current := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true},
+		{ID: "2", Name: "BBC News", Path: BookmarksBasePath, URL: "https://www.bbc.co.uk/", Tags: []string{"news"}},
+		{ID: "4", Name: "Guardian", ParentID: "1", Path: ",News,", URL: "https://www.theguardian.com/"},
+	}
+	want := &SnapshotDiff{
+		Added:   []Bookmark{current[2]},
+		Removed: []Bookmark{snapshot[2]},
+		Changed: []BookmarkChange{{Before: snapshot[1], After: current[1], Fields: []string{"name", "parent_id", "path", "tags"}}},
+	}
+	got := diffSnapshot(snapshot, current)
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
@@ -0,0 +1,69 @@
+package bookmarks
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	BookmarksTagKey      string = "tag"
+	BookmarksTagMatchKey string = "match"
+	BookmarksTagMatchAll string = "all"
+	BookmarksTagMatchAny string = "any"
+)
+
+// TagCount represents the number of bookmarks with a given tag.
+type TagCount struct {
+	Tag   string `json:"tag" bson:"_id"`
+	Count int    `json:"count" bson:"count"`
+}
+
+// NormalizeTags trims whitespace from tags and removes empty and duplicate tags.
+func NormalizeTags(tags []string) []string {
+	var res []string
+	for _, t := range tags {
+		t = strings.TrimSpace(t)
+		if len(t) > 0 && !slices.Contains(res, t) {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// HasTags reports whether a bookmark has all of the given tags, or any of them when
+// matchAll is false.
+func HasTags(b Bookmark, tags []string, matchAll bool) bool {
+	for _, t := range tags {
+		has := slices.Contains(b.Tags, t)
+		if has && !matchAll {
+			return true
+		}
+		if !has && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+// SortTagCounts orders tags by descending count, then by name.
+func SortTagCounts(counts []TagCount) {
+	sort.SliceStable(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Tag < counts[j].Tag
+	})
+}
+
+// pruneEmptyFolders removes folders that contain no bookmarks, directly or in any subfolder.
+func pruneEmptyFolders(folder *Folder) bool {
+	kept := folder.Folders[:0]
+	for i := range folder.Folders {
+		if pruneEmptyFolders(&folder.Folders[i]) {
+			kept = append(kept, folder.Folders[i])
+		}
+	}
+	folder.Folders = kept
+	return len(folder.Bookmarks) > 0 || len(folder.Folders) > 0
+}
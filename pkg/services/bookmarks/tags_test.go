@@ -0,0 +1,55 @@
+package bookmarks
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHasTags(t *testing.T) {
+	t.Parallel()
+	b := Bookmark{Tags: []string{"go", "docs"}}
+	tc := []struct {
+		tags     []string
+		matchAll bool
+		want     bool
+	}{
+		{tags: []string{"go", "docs"}, matchAll: true, want: true},
+		{tags: []string{"go", "news"}, matchAll: true, want: false},
+		{tags: []string{"go", "news"}, matchAll: false, want: true},
+		{tags: []string{"news"}, matchAll: false, want: false},
+	}
+	for _, c := range tc {
+		if got := HasTags(b, c.tags, c.matchAll); got != c.want {
+			t.Errorf("tags %v matchAll %t: wanted %t, got %t", c.tags, c.matchAll, c.want, got)
+		}
+	}
+}
+
+func TestPruneEmptyFolders(t *testing.T) {
+	t.Parallel()
+	books := []Bookmark{
+		{ID: "1", Name: "Dev", IsFolder: true},
+		{ID: "2", Name: "Go", ParentID: "1", Ancestors: []string{"1"}, Path: ",Dev,", IsFolder: true},
+		{ID: "3", Name: "Go docs", ParentID: "2", Ancestors: []string{"1", "2"}, Path: ",Dev,Go,", URL: "https://go.dev/doc/"},
+		{ID: "4", Name: "Empty", ParentID: "1", Ancestors: []string{"1"}, Path: ",Dev,", IsFolder: true},
+		{ID: "5", Name: "News", IsFolder: true},
+	}
+	folder := organizeBookmarks(books, "", BookmarksBasePath, BookmarksBasePath)
+	pruneEmptyFolders(folder)
+	want := &Folder{
+		Folders: []Folder{{
+			ID:   "1",
+			Name: "Dev",
+			Folders: []Folder{{
+				ID:        "2",
+				Name:      "Go",
+				Path:      ",Dev,",
+				Bookmarks: []Bookmark{books[2]},
+			}},
+		}},
+	}
+	if !cmp.Equal(want, folder) {
+		t.Error(cmp.Diff(want, folder))
+	}
+}
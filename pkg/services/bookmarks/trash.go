@@ -0,0 +1,74 @@
+package bookmarks
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BookmarksTrashDefaultRetention is how long deleted bookmarks are kept in the trash when no
+// retention period is configured.
+const BookmarksTrashDefaultRetention time.Duration = 30 * 24 * time.Hour
+
+// TrashItem represents a deleted bookmark or folder in the trash. Contents is the number of
+// bookmarks and folders deleted along with a folder.
+type TrashItem struct {
+	Bookmark
+	Contents int `json:"contents"`
+}
+
+// RestoreResult represents the number of bookmarks restored from the trash. Created is the number
+// of missing parent folders that had to be recreated.
+type RestoreResult struct {
+	Restored int `json:"num_restored"`
+	Created  int `json:"num_created"`
+}
+
+// ParseTrashRetention parses a trash retention period given in days, falling back to the default
+// retention if days is empty or invalid.
+func ParseTrashRetention(days string) time.Duration {
+	n, err := strconv.Atoi(strings.TrimSpace(days))
+	if err != nil || n <= 0 {
+		return BookmarksTrashDefaultRetention
+	}
+	return time.Duration(n) * 24 * time.Hour
+}
+
+// IsTrashRoot reports whether a bookmark in the trash was deleted directly, rather than as part
+// of a folder.
+func (b Bookmark) IsTrashRoot() bool {
+	return len(b.TrashID) > 0 && b.TrashID == b.ID
+}
+
+// groupTrash returns the directly deleted bookmarks in the trash, most recently deleted first.
+func groupTrash(trashed []Bookmark) []TrashItem {
+	contents := make(map[string]int)
+	for _, b := range trashed {
+		if !b.IsTrashRoot() {
+			contents[b.TrashID]++
+		}
+	}
+	items := []TrashItem{}
+	for _, b := range trashed {
+		if b.IsTrashRoot() {
+			items = append(items, TrashItem{Bookmark: b, Contents: contents[b.ID]})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].DeletedAt == nil || items[j].DeletedAt == nil {
+			return items[j].DeletedAt == nil && items[i].DeletedAt != nil
+		}
+		return items[i].DeletedAt.After(*items[j].DeletedAt)
+	})
+	return items
+}
+
+// hasParent reports whether the folder a deleted bookmark was in still exists.
+func hasParent(deleted Bookmark, books []Bookmark) bool {
+	if len(deleted.ParentID) == 0 {
+		return deleted.Path == BookmarksBasePath
+	}
+	return slices.ContainsFunc(books, func(b Bookmark) bool { return b.IsFolder && b.ID == deleted.ParentID })
+}
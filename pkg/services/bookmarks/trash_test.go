@@ -0,0 +1,46 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTrashRetention(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		days string
+		want time.Duration
+	}{
+		{days: "", want: BookmarksTrashDefaultRetention},
+		{days: "7", want: 7 * 24 * time.Hour},
+		{days: "0", want: BookmarksTrashDefaultRetention},
+		{days: "week", want: BookmarksTrashDefaultRetention},
+	}
+	for _, c := range tc {
+		if got := ParseTrashRetention(c.days); got != c.want {
+			t.Errorf("ParseTrashRetention(%q): wanted %v, got %v", c.days, c.want, got)
+		}
+	}
+}
+
+func TestGroupTrash(t *testing.T) {
+	t.Parallel()
+	older := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	trashed := []Bookmark{
+		{ID: "1", Name: "News", IsFolder: true, TrashID: "1", DeletedAt: &older},
+		{ID: "2", Name: "BBC", Path: ",News,", TrashID: "1", DeletedAt: &older},
+		{ID: "3", Name: "World", Path: ",News,", IsFolder: true, TrashID: "1", DeletedAt: &older},
+		{ID: "4", Name: "Go", TrashID: "4", DeletedAt: &newer},
+	}
+	got := groupTrash(trashed)
+	want := []TrashItem{
+		{Bookmark: trashed[3], Contents: 0},
+		{Bookmark: trashed[0], Contents: 2},
+	}
+	if !cmp.Equal(want, got) {
+		t.Error(cmp.Diff(want, got))
+	}
+}
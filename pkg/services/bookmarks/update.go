@@ -0,0 +1,75 @@
+package bookmarks
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+)
+
+var (
+	ErrFolderURL        = errors.New("folders cannot have a url")
+	ErrFolderIntoItself = errors.New("folders cannot be moved into themselves")
+)
+
+const (
+	BookmarksDeleteModeKey      string = "mode"
+	BookmarksDeleteModeCascade  string = "cascade"
+	BookmarksDeleteModeReparent string = "reparent"
+)
+
+// DeleteResult represents the number of bookmarks affected by deleting a bookmark.
+type DeleteResult struct {
+	Deleted int
+	Moved   int
+}
+
+// ChildPath returns the path of the bookmarks stored inside a folder.
+func (b Bookmark) ChildPath() string {
+	return updatePath(b.Path, b.Name)
+}
+
+// ApplyUpdate returns the bookmark with the changes from an update request applied, moving it to
+// dest if the update moves the bookmark.
+func ApplyUpdate(b Bookmark, requestData request.UpdateBookmark, dest *Destination, now time.Time) (Bookmark, error) {
+	updated := b
+	if requestData.Name != nil {
+		updated.Name = *requestData.Name
+	}
+	if requestData.URL != nil {
+		updated.URL = *requestData.URL
+		if !b.IsFolder {
+			updated.CanonicalURL = CanonicalURL(updated.URL)
+		}
+	}
+	if b.IsFolder {
+		if len(updated.URL) > 0 {
+			return Bookmark{}, ErrFolderURL
+		}
+		if dest != nil && dest.Parent != nil && (dest.Parent.ID == b.ID || dest.Parent.IsInside(b.ID)) {
+			return Bookmark{}, ErrFolderIntoItself
+		}
+	}
+	if dest != nil {
+		dest.Move(&updated)
+	}
+	updated.ModifiedAt = now
+	return updated, nil
+}
+
+// ReplacePathPrefix moves a path from inside one folder to another, returning the
+// path unchanged if it is not inside the old folder.
+func ReplacePathPrefix(path, oldPrefix, newPrefix string) string {
+	if !strings.HasPrefix(path, oldPrefix) {
+		return path
+	}
+	rest := strings.TrimPrefix(path, oldPrefix)
+	if len(rest) == 0 {
+		return newPrefix
+	}
+	if newPrefix == BookmarksBasePath {
+		newPrefix = ","
+	}
+	return newPrefix + rest
+}
@@ -0,0 +1,86 @@
+package bookmarks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyUpdate(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	str := func(s string) *string { return &s }
+	code := &Bookmark{ID: "3", Name: "Code", IsFolder: true}
+	folder := Bookmark{ID: "1", Name: "Dev", ParentID: "3", Ancestors: []string{"3"}, Path: ",Code,", IsFolder: true}
+	tc := []struct {
+		name     string
+		bookmark Bookmark
+		req      request.UpdateBookmark
+		dest     *Destination
+		want     Bookmark
+		err      error
+	}{
+		{
+			name:     "rename and move bookmark",
+			bookmark: Bookmark{ID: "2", Name: "Go", ParentID: "1", Ancestors: []string{"3", "1"}, Path: ",Code,Dev,", URL: "https://go.dev/"},
+			req:      request.UpdateBookmark{Name: str("Go Dev"), Path: str(",Code,")},
+			dest:     &Destination{Parent: code},
+			want:     Bookmark{ID: "2", Name: "Go Dev", ParentID: "3", Ancestors: []string{"3"}, Path: ",Code,", URL: "https://go.dev/", ModifiedAt: now},
+		},
+		{
+			name:     "move folder to root",
+			bookmark: folder,
+			req:      request.UpdateBookmark{ParentID: str("")},
+			dest:     &Destination{},
+			want:     Bookmark{ID: "1", Name: "Dev", Path: BookmarksBasePath, IsFolder: true, ModifiedAt: now},
+		},
+		{name: "folder url", bookmark: folder, req: request.UpdateBookmark{URL: str("https://go.dev/")}, err: ErrFolderURL},
+		{
+			name:     "folder name with comma",
+			bookmark: folder,
+			req:      request.UpdateBookmark{Name: str("Dev,Ops")},
+			want:     Bookmark{ID: "1", Name: "Dev,Ops", Ancestors: folder.Ancestors, ParentID: folder.ParentID, Path: folder.Path, IsFolder: true, ModifiedAt: now},
+		},
+		{
+			name:     "folder into itself",
+			bookmark: folder,
+			req:      request.UpdateBookmark{ParentID: str("1")},
+			dest:     &Destination{Parent: &folder},
+			err:      ErrFolderIntoItself,
+		},
+		{
+			name:     "folder into its descendant",
+			bookmark: folder,
+			req:      request.UpdateBookmark{Path: str(",Code,Dev,Go,")},
+			dest:     &Destination{Parent: &Bookmark{ID: "4", Name: "Go", ParentID: "1", Ancestors: []string{"3", "1"}, Path: ",Code,Dev,", IsFolder: true}},
+			err:      ErrFolderIntoItself,
+		},
+	}
+	for _, c := range tc {
+		got, err := ApplyUpdate(c.bookmark, c.req, c.dest, now)
+		if err != c.err {
+			t.Errorf("%s: wanted error %v, got %v", c.name, c.err, err)
+		}
+		if !cmp.Equal(c.want, got) {
+			t.Errorf("%s: %s", c.name, cmp.Diff(c.want, got))
+		}
+	}
+}
+
+func TestReplacePathPrefix(t *testing.T) {
+	t.Parallel()
+	if got := ReplacePathPrefix(",Dev,Go,", ",Dev,", ",Code,"); got != ",Code,Go," {
+		t.Errorf("wanted ,Code,Go, got %s", got)
+	}
+	if got := ReplacePathPrefix(",Devices,", ",Dev,", ",Code,"); got != ",Devices," {
+		t.Errorf("wanted ,Devices, got %s", got)
+	}
+	if got := ReplacePathPrefix(",Dev,", ",Dev,", BookmarksBasePath); got != BookmarksBasePath {
+		t.Errorf("wanted base path, got %s", got)
+	}
+	if got := ReplacePathPrefix(",Dev,Go,", ",Dev,", BookmarksBasePath); got != ",Go," {
+		t.Errorf("wanted ,Go, got %s", got)
+	}
+}
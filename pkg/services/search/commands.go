@@ -0,0 +1,148 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/logs"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// ErrInvalidCommand is returned when a webcli command cannot be registered.
+var ErrInvalidCommand = errors.New("invalid webcli command")
+
+// commands are the webcli commands available to every search service. Each command registers
+// itself in an init function with Register.
+var commands = NewRegistry()
+
+// Command represents a webcli verb, such as ls, that is run instead of looking up a cmd.
+type Command struct {
+	Name    string
+	Aliases []string
+	// Help is a short description of what the command does.
+	Help string
+	// New returns the flag set for one run of the command, and the handler that runs it once the
+	// flags have been parsed.
+	New func() (*flag.FlagSet, CommandHandler)
+}
+
+// CommandHandler runs a webcli command, returning the URL to redirect the user to.
+type CommandHandler func(ctx context.Context, env CommandEnv) (string, error)
+
+// CommandEnv gives a webcli command handler access to the user running it and the search services
+// dependencies.
+type CommandEnv struct {
+	APIKey   string
+	Log      logs.Logger
+	DB       Repository
+	Cache    Cache
+	Enricher *bookmarks.Enricher
+	Commands *Registry
+}
+
+// CommandHelp represents the help for a webcli command.
+type CommandHelp struct {
+	Name    string     `json:"name"`
+	Aliases []string   `json:"aliases,omitempty"`
+	Help    string     `json:"help"`
+	Flags   []FlagHelp `json:"flags,omitempty"`
+}
+
+// FlagHelp represents the help for one of a webcli commands flags.
+type FlagHelp struct {
+	Name    string `json:"name"`
+	Usage   string `json:"usage"`
+	Default string `json:"default,omitempty"`
+}
+
+// Registry holds webcli commands by name and alias.
+type Registry struct {
+	commands map[string]*Command
+	names    map[string]string
+}
+
+// NewRegistry returns an empty webcli command registry.
+func NewRegistry() *Registry {
+	return &Registry{commands: map[string]*Command{}, names: map[string]string{}}
+}
+
+// Register adds a command to the registry, returning an error wrapping ErrInvalidCommand if it has
+// no name or handler, or if its name or one of its aliases is already taken.
+func (r *Registry) Register(cmd Command) error {
+	if len(cmd.Name) == 0 || cmd.New == nil {
+		return fmt.Errorf("%w: command needs a name and handler", ErrInvalidCommand)
+	}
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for i, name := range names {
+		if len(name) == 0 {
+			return fmt.Errorf("%w: %s has an empty alias", ErrInvalidCommand, cmd.Name)
+		}
+		if _, ok := r.names[name]; ok || slices.Contains(names[:i], name) {
+			return fmt.Errorf("%w: %s is already registered", ErrInvalidCommand, name)
+		}
+	}
+	r.commands[cmd.Name] = &cmd
+	for _, name := range names {
+		r.names[name] = cmd.Name
+	}
+	return nil
+}
+
+// Lookup returns the command with the given name or alias.
+func (r *Registry) Lookup(name string) (Command, bool) {
+	cmd, ok := r.commands[r.names[name]]
+	if !ok {
+		return Command{}, false
+	}
+	return *cmd, true
+}
+
+// Commands returns the registered commands ordered by name.
+func (r *Registry) Commands() []Command {
+	cmds := make([]Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		cmds = append(cmds, *cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds
+}
+
+// Help returns the help for each registered command, ordered by name.
+func (r *Registry) Help() []CommandHelp {
+	cmds := r.Commands()
+	help := make([]CommandHelp, 0, len(cmds))
+	for _, cmd := range cmds {
+		h := CommandHelp{Name: cmd.Name, Aliases: cmd.Aliases, Help: cmd.Help}
+		fs, _ := cmd.New()
+		fs.VisitAll(func(f *flag.Flag) {
+			h.Flags = append(h.Flags, FlagHelp{Name: f.Name, Usage: f.Usage, Default: f.DefValue})
+		})
+		help = append(help, h)
+	}
+	return help
+}
+
+// Register adds a command to the webcli commands available to every search service. It panics if
+// the command cannot be registered, as commands are registered when the program starts.
+func Register(cmd Command) {
+	if err := commands.Register(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// run parses the flags given to a command and runs it.
+func (cmd Command) run(ctx context.Context, env CommandEnv, args []string) (string, error) {
+	fs, handler := cmd.New()
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		env.Log.Errorf("webcli: could not parse %s flag cmds: %v", cmd.Name, err)
+		return "", apierr.NewBadRequestError(fmt.Sprintf("bad %s flags", cmd.Name))
+	}
+	return handler(ctx, env)
+}
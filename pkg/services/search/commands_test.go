@@ -0,0 +1,95 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+
+	tu "github.com/conalli/bookshelf-backend/internal/testutils"
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+)
+
+func newEchoCommand(name string, aliases ...string) Command {
+	return Command{
+		Name:    name,
+		Aliases: aliases,
+		Help:    "echo the text flag",
+		New: func() (*flag.FlagSet, CommandHandler) {
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			text := fs.String("text", "hello", "text to echo")
+			return fs, func(ctx context.Context, env CommandEnv) (string, error) {
+				return "https://echo.example.com/" + *text + "/" + env.APIKey, nil
+			}
+		},
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	if err := r.Register(newEchoCommand("echo", "say")); err != nil {
+		t.Fatalf("Expected echo command to be registered: %v", err)
+	}
+	tc := []struct {
+		name string
+		cmd  Command
+	}{
+		{name: "duplicate name", cmd: newEchoCommand("echo")},
+		{name: "name taken by alias", cmd: newEchoCommand("say")},
+		{name: "alias taken by name", cmd: newEchoCommand("shout", "echo")},
+		{name: "repeated alias", cmd: newEchoCommand("shout", "yell", "yell")},
+		{name: "empty alias", cmd: newEchoCommand("shout", "")},
+		{name: "no name", cmd: newEchoCommand("")},
+		{name: "no handler", cmd: Command{Name: "shout"}},
+	}
+	for _, c := range tc {
+		if err := r.Register(c.cmd); !errors.Is(err, ErrInvalidCommand) {
+			t.Errorf("%s: expected ErrInvalidCommand: got %v", c.name, err)
+		}
+	}
+	if _, ok := r.Lookup("shout"); ok {
+		t.Error("Expected commands that could not be registered not to be found")
+	}
+	cmd, ok := r.Lookup("say")
+	if !ok || cmd.Name != "echo" {
+		t.Errorf("Expected alias to find echo command: got %+v", cmd)
+	}
+	help := r.Help()
+	if len(help) != 1 || help[0].Name != "echo" || len(help[0].Flags) != 1 || help[0].Flags[0] != (FlagHelp{Name: "text", Usage: "text to echo", Default: "hello"}) {
+		t.Errorf("Expected help for echo command and its flag: got %+v", help)
+	}
+}
+
+func TestBuiltinCommands(t *testing.T) {
+	t.Parallel()
+	var names []string
+	for _, h := range commands.Help() {
+		names = append(names, h.Name)
+	}
+	if len(names) != 3 || names[0] != "help" || names[1] != "ls" || names[2] != "touch" {
+		t.Errorf("Expected help, ls and touch built in commands: got %v", names)
+	}
+	if cmd, ok := commands.Lookup("add"); !ok || cmd.Name != "touch" {
+		t.Errorf("Expected add to be an alias of touch: got %+v", cmd)
+	}
+}
+
+func TestEvaluateCustomCommand(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+	if err := r.Register(newEchoCommand("echo", "say")); err != nil {
+		t.Fatalf("Expected echo command to be registered: %v", err)
+	}
+	s := &service{log: tu.NewLogger(), commands: r}
+	APIKey := "bd1eb780-0124-11ed-b939-0242ac120002"
+	got, err := s.evaluateArgs(context.Background(), APIKey, []string{"say", "-text", "hi"})
+	if want := "https://echo.example.com/hi/" + APIKey; err != nil || got != want {
+		t.Errorf("wanted %s: got %s (%v)", want, got, err)
+	}
+	_, err = s.evaluateArgs(context.Background(), APIKey, []string{"echo", "-loud"})
+	var apiErr apierr.Error
+	if !errors.As(err, &apiErr) || apiErr.Status() != 400 {
+		t.Errorf("Expected bad request for unknown flag: got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	// maxCmdSuggestions is the number of cmds listed on the webcli suggestions page.
+	maxCmdSuggestions = 5
+	// minFuzzyCmdLength is the shortest input that is matched against cmds, as anything shorter
+	// is close to too many cmds to be a useful guess.
+	minFuzzyCmdLength = 2
+)
+
+// cmdMatch represents one of a users cmds that is close to a cmd that was not found. Prefix
+// matches start with the input, and other matches are within distance edits of it.
+type cmdMatch struct {
+	cmd      string
+	prefix   bool
+	distance int
+}
+
+// confident reports whether the match is close enough to be used without asking the user.
+func (m cmdMatch) confident() bool {
+	return m.prefix || m.distance <= 1
+}
+
+// matchCmds returns the cmds that start with input or are a few typos away from it, best first.
+func matchCmds(input string, cmds map[string]string) []cmdMatch {
+	input = strings.ToLower(input)
+	if len([]rune(input)) < minFuzzyCmdLength {
+		return nil
+	}
+	maxDistance := 1
+	if len([]rune(input)) > 5 {
+		maxDistance = 2
+	}
+	var matches []cmdMatch
+	for cmd := range cmds {
+		lower := strings.ToLower(cmd)
+		if strings.HasPrefix(lower, input) {
+			matches = append(matches, cmdMatch{cmd: cmd, prefix: true, distance: len(lower) - len(input)})
+			continue
+		}
+		if d := editDistance(input, lower); d <= maxDistance {
+			matches = append(matches, cmdMatch{cmd: cmd, distance: d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		return a.cmd < b.cmd
+	})
+	return matches
+}
+
+// confidentMatch returns the only confident match, if there is exactly one.
+func confidentMatch(matches []cmdMatch) (cmdMatch, bool) {
+	var found cmdMatch
+	n := 0
+	for _, m := range matches {
+		if m.confident() {
+			found = m
+			n++
+		}
+	}
+	return found, n == 1
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and transpositions of
+// adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(t)]
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+	tc := []struct {
+		a, b string
+		want int
+	}{
+		{"github", "github", 0},
+		{"githb", "github", 1},
+		{"gihtub", "github", 1},
+		{"gitlab", "github", 2},
+		{"", "bbc", 3},
+		{"ßbc", "sbc", 1},
+	}
+	for _, c := range tc {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("wanted distance %d between %s and %s: got %d", c.want, c.a, c.b, got)
+		}
+	}
+}
+
+func TestMatchCmds(t *testing.T) {
+	t.Parallel()
+	cmds := map[string]string{
+		"github":  "https://github.com",
+		"gitlab":  "https://gitlab.com",
+		"gmail":   "https://mail.google.com",
+		"bbc":     "https://www.bbc.co.uk",
+		"YouTube": "https://www.youtube.com",
+	}
+	tc := []struct {
+		name      string
+		input     string
+		want      []cmdMatch
+		confident string
+	}{
+		{name: "transposition", input: "gihtub", want: []cmdMatch{{cmd: "github", distance: 1}}, confident: "github"},
+		{name: "shared prefix", input: "git", want: []cmdMatch{{cmd: "github", prefix: true, distance: 3}, {cmd: "gitlab", prefix: true, distance: 3}}},
+		{name: "case insensitive prefix", input: "you", want: []cmdMatch{{cmd: "YouTube", prefix: true, distance: 4}}, confident: "YouTube"},
+		{name: "typo", input: "bcc", want: []cmdMatch{{cmd: "bbc", distance: 1}}, confident: "bbc"},
+		{name: "too short", input: "g"},
+		{name: "no match", input: "weather"},
+		{name: "longer input allows two typos", input: "gitlba", want: []cmdMatch{{cmd: "gitlab", distance: 1}}, confident: "gitlab"},
+		{name: "two typos", input: "gthubb", want: []cmdMatch{{cmd: "github", distance: 2}}},
+	}
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			got := matchCmds(c.input, cmds)
+			if !cmp.Equal(c.want, got, cmp.AllowUnexported(cmdMatch{})) {
+				t.Error(cmp.Diff(c.want, got, cmp.AllowUnexported(cmdMatch{})))
+			}
+			m, ok := confidentMatch(got)
+			if ok != (len(c.confident) > 0) || (ok && m.cmd != c.confident) {
+				t.Errorf("wanted confident match %q: got %q (%t)", c.confident, m.cmd, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
+)
+
+// Content types of the OpenSearch description document and suggestions.
+const (
+	OpenSearchContentType  string = "application/opensearchdescription+xml"
+	SuggestionsContentType string = "application/x-suggestions+json"
+)
+
+const (
+	openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+	// maxSuggestions is the number of suggestions returned to the browser.
+	maxSuggestions = 10
+)
+
+// OpenSearchDescription represents an OpenSearch description document, which lets browsers add
+// Bookshelf as a search engine.
+type OpenSearchDescription struct {
+	XMLName       xml.Name        `xml:"OpenSearchDescription"`
+	Xmlns         string          `xml:"xmlns,attr"`
+	ShortName     string          `xml:"ShortName"`
+	Description   string          `xml:"Description"`
+	InputEncoding string          `xml:"InputEncoding"`
+	URLs          []OpenSearchURL `xml:"Url"`
+}
+
+// OpenSearchURL represents one of the URL templates in an OpenSearch description document.
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Rel      string `xml:"rel,attr,omitempty"`
+	Method   string `xml:"method,attr,omitempty"`
+	Template string `xml:"template,attr"`
+}
+
+// Suggestions represents search suggestions in the OpenSearch suggestions format, which is
+// encoded as [query, completions, descriptions, urls].
+type Suggestions struct {
+	Query        string
+	Completions  []string
+	Descriptions []string
+	URLs         []string
+}
+
+func newSuggestions(query string) *Suggestions {
+	return &Suggestions{Query: query, Completions: []string{}, Descriptions: []string{}, URLs: []string{}}
+}
+
+// MarshalJSON encodes the suggestions as an OpenSearch suggestions array.
+func (s Suggestions) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{s.Query, s.Completions, s.Descriptions, s.URLs})
+}
+
+// add adds a suggestion, returning false when no more suggestions can be added.
+func (s *Suggestions) add(completion, description, URL string) bool {
+	if len(s.Completions) >= maxSuggestions {
+		return false
+	}
+	for _, c := range s.Completions {
+		if strings.EqualFold(c, completion) {
+			return true
+		}
+	}
+	s.Completions = append(s.Completions, completion)
+	s.Descriptions = append(s.Descriptions, description)
+	s.URLs = append(s.URLs, URL)
+	return true
+}
+
+// OpenSearch returns the OpenSearch description document for the user.
+func (s *service) OpenSearch(ctx context.Context, APIKey string) (*OpenSearchDescription, apierr.Error) {
+	ctx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	if err := s.validate.Var(APIKey, "uuid"); err != nil {
+		s.log.Errorf("could not validate OPENSEARCH request: %v", err)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	usr, err := s.getUser(ctx, APIKey)
+	if err != nil {
+		s.log.Errorf("could not get user by API key: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	description := "Open your Bookshelf cmds and bookmarks, and use the webcli."
+	if len(usr.Name) > 0 {
+		description = fmt.Sprintf("Open %s's Bookshelf cmds and bookmarks, and use the webcli.", usr.Name)
+	}
+	base := os.Getenv("SERVER_URL_BASE") + "/api/search"
+	return &OpenSearchDescription{
+		Xmlns:         openSearchNamespace,
+		ShortName:     "Bookshelf",
+		Description:   description,
+		InputEncoding: "UTF-8",
+		URLs: []OpenSearchURL{
+			{Type: "text/html", Method: "get", Template: base + "/{searchTerms}"},
+			{Type: SuggestionsContentType, Method: "get", Template: base + "/suggest?q={searchTerms}"},
+			{Type: OpenSearchContentType, Rel: "self", Template: base + "/opensearch.xml"},
+		},
+	}, nil
+}
+
+// Suggest returns suggestions for a partly typed search. While the first word is typed, the users
+// cmds and the webcli verbs starting with it are suggested, followed by cmds it may be a typo of,
+// and the users bookmarks with names matching the query are suggested after them.
+func (s *service) Suggest(ctx context.Context, APIKey, query string) (*Suggestions, apierr.Error) {
+	ctx, cancelFunc := request.CtxWithDefaultTimeout(ctx)
+	defer cancelFunc()
+	if err := s.validate.Var(APIKey, "uuid"); err != nil {
+		s.log.Errorf("could not validate SUGGEST request: %v", err)
+		return nil, apierr.NewBadRequestError("request format incorrect.")
+	}
+	suggestions := newSuggestions(query)
+	args := strings.Fields(query)
+	if len(args) == 0 {
+		return suggestions, nil
+	}
+	usr, err := s.getUser(ctx, APIKey)
+	if err != nil {
+		s.log.Errorf("could not get user by API key: %v", err)
+		return nil, apierr.NewInternalServerError()
+	}
+	if len(args) == 1 && !strings.HasSuffix(query, " ") {
+		s.suggestCmds(suggestions, args[0], usr)
+	}
+	for _, r := range s.findBookmarks(ctx, APIKey, strings.Join(args, " "), maxSuggestions) {
+		if r.IsFolder || len(r.URL) == 0 {
+			continue
+		}
+		if !suggestions.add(r.Name, r.URL, formatURL(r.URL)) {
+			break
+		}
+	}
+	return suggestions, nil
+}
+
+// suggestCmds adds the webcli verbs and the users cmds that start with input, and then the cmds
+// that input may be a typo of.
+func (s *service) suggestCmds(suggestions *Suggestions, input string, usr accounts.User) {
+	lower := strings.ToLower(input)
+	var verbs, cmds []string
+	for _, cmd := range s.commands.Commands() {
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			if strings.HasPrefix(name, lower) {
+				verbs = append(verbs, name)
+			}
+		}
+	}
+	for cmd := range usr.Cmds {
+		if strings.HasPrefix(strings.ToLower(cmd), lower) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sort.Strings(verbs)
+	sort.Strings(cmds)
+	for _, verb := range verbs {
+		cmd, _ := s.commands.Lookup(verb)
+		suggestions.add(verb, "webcli: "+cmd.Help, "")
+	}
+	for _, cmd := range cmds {
+		suggestions.add(cmd, usr.Cmds[cmd], s.expandCmd(usr.Cmds[cmd], nil))
+	}
+	for _, m := range matchCmds(input, usr.Cmds) {
+		if !m.prefix {
+			suggestions.add(m.cmd, usr.Cmds[m.cmd], s.expandCmd(usr.Cmds[m.cmd], nil))
+		}
+	}
+}
+
+// findBookmarks returns up to limit of the users bookmarks that best match query.
+func (s *service) findBookmarks(ctx context.Context, APIKey, query string, limit int) []bookmarks.SearchResult {
+	q := bookmarks.SearchQuery{Query: query, Page: 1, Limit: limit}
+	if err := s.validate.Struct(q); err != nil {
+		s.log.Infof("could not search bookmarks for %q: %v", query, err)
+		return nil
+	}
+	results, err := s.db.SearchBookmarks(ctx, q, APIKey)
+	if err != nil {
+		s.log.Errorf("could not search bookmarks: %v", err)
+		return nil
+	}
+	return results.Results
+}
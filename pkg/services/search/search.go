@@ -4,8 +4,10 @@ import (
 	"strings"
 )
 
+// formatURL adds a scheme to URLs that do not start with one.
 func formatURL(url string) string {
-	if strings.Contains(url, "http://") || strings.Contains(url, "https://") {
+	lower := strings.ToLower(url)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
 		return url
 	}
 	return "http://" + url
@@ -14,4 +14,12 @@ func TestFormatURL(t *testing.T) {
 	if want != got {
 		t.Errorf("Wanted correctly formatted URL: %s, got %s", want, got)
 	}
+	for _, u := range []string{"example.com/?u=http://evil.com", "example.com/http://evil.com"} {
+		if got := formatURL(u); got != "http://"+u {
+			t.Errorf("Wanted URL without a scheme to be given one: got %s", got)
+		}
+	}
+	if got := formatURL("HTTPS://example.com"); got != "HTTPS://example.com" {
+		t.Errorf("Wanted URL with a scheme to be kept: got %s", got)
+	}
 }
@@ -3,6 +3,7 @@ package search
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"strings"
 
@@ -11,13 +12,19 @@ import (
 	"github.com/conalli/bookshelf-backend/pkg/logs"
 	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
 	"github.com/conalli/bookshelf-backend/pkg/services/auth"
+	"github.com/conalli/bookshelf-backend/pkg/services/bookmarks"
 	"github.com/go-playground/validator/v10"
 )
 
+// searchBookmarksLimit is the number of bookmarks looked at when searching bookmarks first.
+const searchBookmarksLimit = 5
+
 // Repository provides access to storage.
 type Repository interface {
 	GetUserByAPIKey(ctx context.Context, APIKey string) (accounts.User, error)
-	AddBookmark(reqCtx context.Context, requestData request.AddBookmark, APIKey string) (int, apierr.Error)
+	AddBookmark(reqCtx context.Context, requestData request.AddBookmark, APIKey string) (*bookmarks.Bookmark, apierr.Error)
+	bookmarks.EnrichmentRepository
+	SearchBookmarks(ctx context.Context, query bookmarks.SearchQuery, APIKey string) (*bookmarks.SearchResults, apierr.Error)
 	AddCmdByAPIKey(reqCtx context.Context, requestData request.AddCmd, APIKey string) (int, apierr.Error)
 	NewRefreshToken(ctx context.Context, APIKey, refreshToken string) error
 	GetRefreshTokenByAPIKey(ctx context.Context, APIKey string) (string, error)
@@ -29,11 +36,16 @@ type Cache interface {
 	GetOneCmd(ctx context.Context, cacheKey, cmd string) (string, error)
 	AddCmds(ctx context.Context, cacheKey string, cmds map[string]string) (int64, error)
 	DeleteCmds(ctx context.Context, cacheKey string) (int64, error)
+	GetUser(ctx context.Context, userKey string) (accounts.User, error)
+	AddUser(ctx context.Context, userKey string, user accounts.User) (int64, error)
 }
 
 // Service provides the search operation.
 type Service interface {
 	Search(ctx context.Context, APIKey, args, code string, refresh bool) (string, *auth.BookshelfTokens, error)
+	OpenSearch(ctx context.Context, APIKey string) (*OpenSearchDescription, apierr.Error)
+	Suggest(ctx context.Context, APIKey, query string) (*Suggestions, apierr.Error)
+	Help(ctx context.Context) []CommandHelp
 }
 
 type service struct {
@@ -41,11 +53,13 @@ type service struct {
 	validate *validator.Validate
 	db       Repository
 	cache    Cache
+	enricher *bookmarks.Enricher
+	commands *Registry
 }
 
 // NewService creates a search service with the necessary dependencies.
 func NewService(l logs.Logger, v *validator.Validate, r Repository, c Cache) Service {
-	return &service{l, v, r, c}
+	return &service{l, v, r, c, bookmarks.NewDefaultEnricher(l, r), commands}
 }
 
 type refreshResult struct {
@@ -75,6 +89,10 @@ func (s *service) Search(ctx context.Context, APIKey, args, code string, refresh
 		close(refChan)
 	}
 	cmds := strings.Fields(args)
+	if len(cmds) == 0 {
+		s.log.Error("no cmd given in search")
+		return "", nil, apierr.NewBadRequestError("no cmd given")
+	}
 	url, err := s.evaluateArgs(ctx, APIKey, cmds)
 	if err != nil {
 		s.log.Error("could not evaluate args in search")
@@ -89,104 +107,116 @@ func (s *service) Search(ctx context.Context, APIKey, args, code string, refresh
 }
 
 func (s *service) evaluateArgs(ctx context.Context, APIKey string, args []string) (string, error) {
-	switch args[0] {
-	case "help":
-		s.log.Info("webcli: help")
-		return fmt.Sprintf("%s/webcli/help", os.Getenv("ALLOWED_URL_BASE")), nil
-
-	case "ls":
-		ls := NewLSFlagset()
-		err := ls.Parse(args[1:])
-		if err != nil || *ls.b && *ls.c {
-			s.log.Error("webcli: could not parse ls flag cmds")
-			return "", apierr.NewBadRequestError("bad ls flags")
-		}
-		if *ls.b && *ls.c || len(*ls.bf) > 0 && *ls.c || *ls.b && len(*ls.bf) > 0 {
-			s.log.Error("webcli: incorrect flags passed")
-			return fmt.Sprintf("%s/404", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-		if *ls.b {
-			s.log.Info("webcli: list bookmarks")
-			return fmt.Sprintf("%s/webcli/bookmark", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-		if *ls.bf != "" {
-			s.log.Infof("FLAG: %s", *ls.bf)
-			s.log.Info("webcli: list bookmark folder")
-			return fmt.Sprintf("%s/webcli/bookmark?folder=%s", os.Getenv("ALLOWED_URL_BASE"), *ls.bf), nil
-		}
-		if *ls.c {
-			s.log.Info("webcli: list commands")
-			return fmt.Sprintf("%s/webcli/command", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-	case "touch", "add":
-		touch := NewTouchFlagset()
-		err := touch.Parse(args[1:])
-		if err != nil {
-			s.log.Error("could not parse touch flag cmds")
-			return "", apierr.NewBadRequestError("bad touch flags")
-		}
-		if len(*touch.url) < 5 || *touch.b && len(*touch.c) > 0 {
-			s.log.Error("webcli: incorrect flags passed")
-			return fmt.Sprintf("%s/404", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-		if *touch.b {
-			req := request.AddBookmark{
-				Name: *touch.name,
-				URL:  *touch.url,
-				Path: *touch.path,
-			}
-			res, err := s.db.AddBookmark(ctx, req, APIKey)
-			if err != nil {
-				return "", err
-			}
-			if res == 0 {
-				return fmt.Sprintf("%s/404", os.Getenv("ALLOWED_URL_BASE")), nil
-			}
-			return fmt.Sprintf("%s/webcli/success", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-		if touch.c != nil {
-			req := request.AddCmd{
-				Cmd: *touch.c,
-				URL: *touch.url,
-			}
-			res, err := s.db.AddCmdByAPIKey(ctx, req, APIKey)
-			if err != nil {
-				return "", err
-			}
-			if res == 0 {
-				return fmt.Sprintf("%s/404", os.Getenv("ALLOWED_URL_BASE")), nil
-			}
-			s.cache.DeleteCmds(ctx, APIKey)
-			return fmt.Sprintf("%s/webcli/success", os.Getenv("ALLOWED_URL_BASE")), nil
-		}
-	default:
-		cachedURL, err := s.cache.GetOneCmd(ctx, APIKey, args[0])
-		if err == nil {
-			s.log.Info("retrieved search data from cache")
-			return formatURL(cachedURL), nil
+	if cmd, ok := s.commands.Lookup(args[0]); ok {
+		env := CommandEnv{
+			APIKey:   APIKey,
+			Log:      s.log,
+			DB:       s.db,
+			Cache:    s.cache,
+			Enricher: s.enricher,
+			Commands: s.commands,
 		}
-		s.log.Infof("could not get search data from cache: %v", err)
-		defaultSearch := fmt.Sprintf("http://www.google.com/search?q=%s", args[0])
-		usr, err := s.db.GetUserByAPIKey(ctx, APIKey)
-		if err != nil {
-			s.log.Errorf("could not get user by API key: %v", err)
-			return defaultSearch, err
-		}
-		numAdded, err := s.cache.AddCmds(ctx, APIKey, usr.Cmds)
-		if err != nil {
-			s.log.Errorf("could not add cmds to cache: %v", err)
-		}
-		if numAdded == 0 {
-			s.log.Error("could not add cmds to cache")
+		return cmd.run(ctx, env, args[1:])
+	}
+	cachedURL, err := s.cache.GetOneCmd(ctx, APIKey, args[0])
+	if err == nil {
+		s.log.Info("retrieved search data from cache")
+		return s.expandCmd(cachedURL, args[1:]), nil
+	}
+	s.log.Infof("could not get search data from cache: %v", err)
+	usr, err := s.getUser(ctx, APIKey)
+	if err != nil {
+		s.log.Errorf("could not get user by API key: %v", err)
+		return s.defaultSearch(ctx, APIKey, accounts.User{}.Settings(), args), err
+	}
+	cmdURL, ok := usr.Cmds[args[0]]
+	if !ok {
+		s.log.Infof("Cmd %s does not exist", args[0])
+		return s.cmdNotFound(ctx, APIKey, usr, args), nil
+	}
+	return s.expandCmd(cmdURL, args[1:]), nil
+}
+
+// Help returns the help for each of the webcli commands.
+func (s *service) Help(ctx context.Context) []CommandHelp {
+	return s.commands.Help()
+}
+
+// getUser gets the user from the cache if their cmds are cached, and otherwise from the db, adding
+// them to the cache.
+func (s *service) getUser(ctx context.Context, APIKey string) (accounts.User, error) {
+	if usr, err := s.cache.GetUser(ctx, APIKey); err == nil && len(usr.APIKey) > 0 && len(usr.Cmds) > 0 {
+		s.log.Info("retrieved user from cache")
+		return usr, nil
+	}
+	usr, err := s.db.GetUserByAPIKey(ctx, APIKey)
+	if err != nil {
+		return accounts.User{}, err
+	}
+	numAdded, err := s.cache.AddUser(ctx, APIKey, usr)
+	if err != nil {
+		s.log.Errorf("could not add user to cache: %v", err)
+	}
+	if numAdded == 0 {
+		s.log.Error("could not add user to cache")
+	}
+	return usr, nil
+}
+
+// cmdNotFound returns the URL to go to when args[0] is not one of the users cmds. Depending on the
+// users cmd matching setting, a cmd close to args[0] is opened, the closest cmds are listed in the
+// webcli, or args are searched for with the users search engine.
+func (s *service) cmdNotFound(ctx context.Context, APIKey string, usr accounts.User, args []string) string {
+	settings := usr.Settings()
+	if settings.CmdMatching == accounts.CmdMatchingOff {
+		return s.defaultSearch(ctx, APIKey, settings, args)
+	}
+	matches := matchCmds(args[0], usr.Cmds)
+	if len(matches) == 0 {
+		return s.defaultSearch(ctx, APIKey, settings, args)
+	}
+	if m, ok := confidentMatch(matches); ok && settings.CmdMatching == accounts.CmdMatchingAuto {
+		s.log.Infof("webcli: using cmd %s for %s", m.cmd, args[0])
+		return s.expandCmd(usr.Cmds[m.cmd], args[1:])
+	}
+	query := url.Values{"q": {strings.Join(args, " ")}}
+	for i := 0; i < len(matches) && i < maxCmdSuggestions; i++ {
+		query.Add("cmd", matches[i].cmd)
+	}
+	s.log.Info("webcli: suggest cmds")
+	return fmt.Sprintf("%s/webcli/suggest?%s", os.Getenv("ALLOWED_URL_BASE"), query.Encode())
+}
+
+// defaultSearch returns the URL to search for args, the whole query, with the users search engine.
+// When the user searches their bookmarks first, their best matching bookmark is opened if they have one.
+func (s *service) defaultSearch(ctx context.Context, APIKey string, settings accounts.Settings, args []string) string {
+	if settings.SearchEngine == accounts.SearchEngineBookmarks {
+		if bookmarkURL, ok := s.searchBookmarks(ctx, APIKey, strings.Join(args, " ")); ok {
+			return formatURL(bookmarkURL)
 		}
-		url, ok := usr.Cmds[args[0]]
-		if !ok {
-			s.log.Infof("Cmd %s does not exist. Returning default search", args[0])
-			return defaultSearch, nil
+	}
+	return s.expandCmd(settings.WebSearchURL(), args)
+}
+
+// searchBookmarks returns the URL of the users bookmark that best matches query.
+func (s *service) searchBookmarks(ctx context.Context, APIKey, query string) (string, bool) {
+	for _, r := range s.findBookmarks(ctx, APIKey, query, searchBookmarksLimit) {
+		if !r.IsFolder && len(r.URL) > 0 {
+			return r.URL, true
 		}
-		return formatURL(url), nil
 	}
-	return "", nil
+	return "", false
+}
+
+// expandCmd fills in the placeholders of a cmd URL from the arguments given after the cmd. Cmds
+// saved before templates were supported are returned as they are if they are not valid templates.
+func (s *service) expandCmd(cmdURL string, args []string) string {
+	tmpl, err := accounts.ParseCmdTemplate(cmdURL)
+	if err != nil {
+		s.log.Infof("cmd url is not a template: %v", err)
+		return formatURL(cmdURL)
+	}
+	return formatURL(tmpl.Expand(args))
 }
 
 func (s *service) refresh(ctx context.Context, APIKey, code string) (*auth.BookshelfTokens, error) {
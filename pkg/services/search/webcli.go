@@ -1,6 +1,62 @@
 package search
 
-import "flag"
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/conalli/bookshelf-backend/pkg/apierr"
+	"github.com/conalli/bookshelf-backend/pkg/http/request"
+	"github.com/conalli/bookshelf-backend/pkg/services/accounts"
+)
+
+func init() {
+	Register(Command{
+		Name: "help",
+		Help: "show the webcli help",
+		New: func() (*flag.FlagSet, CommandHandler) {
+			fs := flag.NewFlagSet("help", flag.ContinueOnError)
+			return fs, func(ctx context.Context, env CommandEnv) (string, error) {
+				return help(env, fs.Args()), nil
+			}
+		},
+	})
+	Register(Command{
+		Name: "ls",
+		Help: "list bookmarks or cmds",
+		New: func() (*flag.FlagSet, CommandHandler) {
+			ls := NewLSFlagset()
+			return ls.FlagSet, ls.run
+		},
+	})
+	Register(Command{
+		Name:    "touch",
+		Aliases: []string{"add"},
+		Help:    "add a bookmark or cmd",
+		New: func() (*flag.FlagSet, CommandHandler) {
+			touch := NewTouchFlagset()
+			return touch.FlagSet, touch.run
+		},
+	})
+}
+
+// webcliURL returns the URL of a page of the webcli.
+func webcliURL(path string) string {
+	return fmt.Sprintf("%s%s", os.Getenv("ALLOWED_URL_BASE"), path)
+}
+
+// help returns the URL of the webcli help, for the command named in args if there is one.
+func help(env CommandEnv, args []string) string {
+	env.Log.Info("webcli: help")
+	if len(args) > 0 {
+		if cmd, ok := env.Commands.Lookup(args[0]); ok {
+			return webcliURL("/webcli/help?" + url.Values{"cmd": {cmd.Name}}.Encode())
+		}
+	}
+	return webcliURL("/webcli/help")
+}
 
 // LSFlag represents the possible flags for the ls command.
 type LSFlag struct {
@@ -25,14 +81,40 @@ func NewLSFlagset() LSFlag {
 	return ls
 }
 
+func (ls LSFlag) run(ctx context.Context, env CommandEnv) (string, error) {
+	if *ls.b && *ls.c {
+		env.Log.Error("webcli: could not parse ls flag cmds")
+		return "", apierr.NewBadRequestError("bad ls flags")
+	}
+	if len(*ls.bf) > 0 && *ls.c || *ls.b && len(*ls.bf) > 0 {
+		env.Log.Error("webcli: incorrect flags passed")
+		return webcliURL("/404"), nil
+	}
+	if *ls.b {
+		env.Log.Info("webcli: list bookmarks")
+		return webcliURL("/webcli/bookmark"), nil
+	}
+	if *ls.bf != "" {
+		env.Log.Infof("FLAG: %s", *ls.bf)
+		env.Log.Info("webcli: list bookmark folder")
+		return webcliURL("/webcli/bookmark?folder=" + *ls.bf), nil
+	}
+	if *ls.c {
+		env.Log.Info("webcli: list commands")
+		return webcliURL("/webcli/command"), nil
+	}
+	return "", nil
+}
+
 // TouchFlag represents the possible flags for the touch command.
 type TouchFlag struct {
 	*flag.FlagSet
-	b    *bool
-	c    *string
-	url  *string
-	path *string
-	name *string
+	b        *bool
+	c        *string
+	url      *string
+	path     *string
+	name     *string
+	noEnrich *bool
 }
 
 // NewTouchFlagset returns a new flag set for the touch command.
@@ -43,13 +125,58 @@ func NewTouchFlagset() TouchFlag {
 	url := fs.String("url", "", "url for new bookmark")
 	path := fs.String("path", "", "folder path for new bookmark")
 	name := fs.String("name", "", "name for new bookmark")
+	noEnrich := fs.Bool("noenrich", false, "do not fill in the name and icons of the new bookmark from the page")
 	ls := TouchFlag{
-		FlagSet: fs,
-		b:       b,
-		c:       c,
-		url:     url,
-		path:    path,
-		name:    name,
+		FlagSet:  fs,
+		b:        b,
+		c:        c,
+		url:      url,
+		path:     path,
+		name:     name,
+		noEnrich: noEnrich,
 	}
 	return ls
 }
+
+func (touch TouchFlag) run(ctx context.Context, env CommandEnv) (string, error) {
+	if len(*touch.url) < 5 || *touch.b && len(*touch.c) > 0 {
+		env.Log.Error("webcli: incorrect flags passed")
+		return webcliURL("/404"), nil
+	}
+	if *touch.b {
+		req := request.AddBookmark{
+			Name:           *touch.name,
+			URL:            *touch.url,
+			Path:           *touch.path,
+			SkipEnrichment: *touch.noEnrich,
+		}
+		res, err := env.DB.AddBookmark(ctx, req, env.APIKey)
+		if err != nil {
+			return "", err
+		}
+		if res == nil {
+			return webcliURL("/404"), nil
+		}
+		if !req.SkipEnrichment {
+			env.Enricher.Enqueue(*res)
+		}
+		return webcliURL("/webcli/success"), nil
+	}
+	if _, err := accounts.ParseCmdTemplate(*touch.url); err != nil {
+		env.Log.Errorf("webcli: %v", err)
+		return webcliURL("/404"), nil
+	}
+	req := request.AddCmd{
+		Cmd: *touch.c,
+		URL: *touch.url,
+	}
+	res, err := env.DB.AddCmdByAPIKey(ctx, req, env.APIKey)
+	if err != nil {
+		return "", err
+	}
+	if res == 0 {
+		return webcliURL("/404"), nil
+	}
+	env.Cache.DeleteCmds(ctx, env.APIKey)
+	return webcliURL("/webcli/success"), nil
+}